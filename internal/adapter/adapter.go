@@ -2,6 +2,9 @@ package adapter
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -20,6 +23,10 @@ type RunParams struct {
 	ReadOnly   ReadOnlyMode
 	Timeout    time.Duration
 	Env        []string
+	// Attachments are absolute paths of files, such as screenshots, sent
+	// along with the prompt. Only adapters for which SupportsAttachments is
+	// true pass them on.
+	Attachments []string
 }
 
 type Cost struct {
@@ -46,3 +53,42 @@ type Adapter interface {
 func PromptFileInstruction(promptFile string) string {
 	return fmt.Sprintf("Read the file at %s and follow the instructions within it.", promptFile)
 }
+
+// SupportsAttachments reports whether a passes RunParams.Attachments to its
+// CLI. The prompt-file adapters reference them in the prompt file (see
+// AttachmentInstruction); gemini uses its @path syntax.
+func SupportsAttachments(a Adapter) bool {
+	switch a.(type) {
+	case *ClaudeAdapter, *CodexAdapter, *CursorAdapter, *GeminiAdapter:
+		return true
+	}
+	return false
+}
+
+// AttachmentInstruction returns the section appended to a prompt file that
+// tells the AI CLI reading it to open the attached files. It is empty when
+// there are no attachments.
+func AttachmentInstruction(attachments []string) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n## Attachments\n\nThe following files are attached to this request. " +
+		"Open each of them (they may be images such as screenshots or diagrams) before answering:\n")
+	for _, a := range attachments {
+		fmt.Fprintf(&b, "- %s\n", a)
+	}
+	return b.String()
+}
+
+// attachmentDirs returns the distinct directories holding attachments, for
+// CLIs that only read files in directories they were given.
+func attachmentDirs(attachments []string) []string {
+	var dirs []string
+	for _, a := range attachments {
+		if d := filepath.Dir(a); !slices.Contains(dirs, d) {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
@@ -104,3 +104,59 @@ func TestCustomBuildInvocationStdin(t *testing.T) {
 	assert.Equal(t, "review this", inv.Stdin)
 	assert.NotContains(t, inv.Args, "review this")
 }
+
+func TestModelFromFlags(t *testing.T) {
+	assert.Equal(t, "opus", ModelFromFlags([]string{"--model", "opus"}))
+	assert.Equal(t, "gemini-2.5-pro", ModelFromFlags([]string{"-m", "gemini-2.5-pro"}))
+	assert.Equal(t, "gpt-5.3-codex", ModelFromFlags([]string{"-m", "gpt-5.3-codex", "-c", "model_reasoning_effort=high"}))
+	assert.Equal(t, "sonnet", ModelFromFlags([]string{"--model=sonnet"}))
+	assert.Equal(t, "", ModelFromFlags([]string{"-x"}))
+	assert.Equal(t, "", ModelFromFlags([]string{"--model"}))
+	assert.Equal(t, "", ModelFromFlags(nil))
+}
+
+func TestContextBudget(t *testing.T) {
+	assert.Equal(t, 32000, ContextBudget("claude", ""))
+	assert.Equal(t, 16000, ContextBudget("claude", "haiku"))
+	assert.Equal(t, 64000, ContextBudget("gemini", "gemini-3.1-pro-preview"))
+	assert.Equal(t, 32000, ContextBudget("gemini", "gemini-2.5-flash"))
+	assert.Equal(t, DefaultContextBudget, ContextBudget("custom", ""))
+}
+
+func TestAttachments(t *testing.T) {
+	attachments := []string{"/tmp/run/attachments/ui shot.png", "/tmp/run/attachments/arch.svg"}
+
+	assert.True(t, SupportsAttachments(NewClaudeAdapter("claude", nil)))
+	assert.True(t, SupportsAttachments(NewCodexAdapter("codex", nil)))
+	assert.True(t, SupportsAttachments(NewCursorAdapter("cursor-agent", nil)))
+	assert.True(t, SupportsAttachments(NewGeminiAdapter("gemini", nil)))
+	assert.False(t, SupportsAttachments(NewAmpAdapter("amp", nil)))
+	assert.False(t, SupportsAttachments(NewCustomAdapter("mytool", "mytool", nil, true)))
+
+	assert.Empty(t, AttachmentInstruction(nil))
+	assert.Equal(t, "\n\n## Attachments\n\nThe following files are attached to this request. "+
+		"Open each of them (they may be images such as screenshots or diagrams) before answering:\n"+
+		"- /tmp/run/attachments/ui shot.png\n- /tmp/run/attachments/arch.svg\n", AttachmentInstruction(attachments))
+
+	inv := NewGeminiAdapter("gemini", nil).BuildInvocation(RunParams{Prompt: "review the UI", ReadOnly: ReadOnlyEnforced, Attachments: attachments})
+	assert.Contains(t, inv.Stdin, "review the UI\n\nAttached files: @/tmp/run/attachments/ui\\ shot.png @/tmp/run/attachments/arch.svg\n\n")
+	assert.Equal(t, 1, countArg(inv.Args, "--include-directories"))
+	assert.Contains(t, inv.Args, "/tmp/run/attachments")
+
+	inv = NewClaudeAdapter("claude", nil).BuildInvocation(RunParams{PromptFile: "/tmp/run/prompt.md", ReadOnly: ReadOnlyEnforced, Attachments: attachments})
+	assert.Equal(t, 1, countArg(inv.Args, "--add-dir"))
+	assert.Contains(t, inv.Args[len(inv.Args)-1], "/tmp/run/prompt.md")
+
+	inv = NewClaudeAdapter("claude", nil).BuildInvocation(RunParams{PromptFile: "/tmp/run/prompt.md"})
+	assert.NotContains(t, inv.Args, "--add-dir")
+}
+
+func countArg(args []string, arg string) int {
+	n := 0
+	for _, a := range args {
+		if a == arg {
+			n++
+		}
+	}
+	return n
+}
@@ -25,6 +25,10 @@ func (a *ClaudeAdapter) BuildInvocation(p RunParams) Invocation {
 		)
 	}
 
+	for _, dir := range attachmentDirs(p.Attachments) {
+		args = append(args, "--add-dir", dir)
+	}
+
 	args = append(args, PromptFileInstruction(p.PromptFile))
 
 	return Invocation{
@@ -1,5 +1,7 @@
 package adapter
 
+import "strings"
+
 var geminiReadOnlyTools = []string{
 	"read_file", "list_directory", "search_file_content",
 	"glob", "google_web_search", "codebase_investigator",
@@ -28,9 +30,20 @@ func (a *GeminiAdapter) BuildInvocation(p RunParams) Invocation {
 		}
 	}
 
+	for _, dir := range attachmentDirs(p.Attachments) {
+		args = append(args, "--include-directories", dir)
+	}
+
 	args = append(args, "--output-format", "text")
 
-	prompt := p.Prompt + "\n\nIMPORTANT: Do not narrate or describe the tools you are using. Go straight to the answer."
+	prompt := p.Prompt
+	if len(p.Attachments) > 0 {
+		prompt += "\n\nAttached files:"
+		for _, a := range p.Attachments {
+			prompt += " @" + strings.ReplaceAll(a, " ", `\ `)
+		}
+	}
+	prompt += "\n\nIMPORTANT: Do not narrate or describe the tools you are using. Go straight to the answer."
 
 	return Invocation{
 		Binary: a.binary,
@@ -1,5 +1,7 @@
 package adapter
 
+import "strings"
+
 type Model struct {
 	ID          string
 	DisplayName string
@@ -55,3 +57,51 @@ func RecommendedModel(adapterName string) *Model {
 	}
 	return nil
 }
+
+// ModelFromFlags returns the model named by a --model or -m flag in an
+// agent's extra flags, or "" when none is set.
+func ModelFromFlags(flags []string) string {
+	for i, f := range flags {
+		switch {
+		case (f == "--model" || f == "-m") && i+1 < len(flags):
+			return flags[i+1]
+		case strings.HasPrefix(f, "--model="):
+			return strings.TrimPrefix(f, "--model=")
+		}
+	}
+	return ""
+}
+
+// DefaultContextBudget is the context budget, in tokens, for adapters and
+// models without an estimate, such as custom adapters.
+const DefaultContextBudget = 12800
+
+// contextBudgets estimate, in tokens, how much gathered context each
+// adapter's default model handles comfortably alongside its own file
+// reading. They are deliberately well below the models' context windows.
+var contextBudgets = map[string]int{
+	"claude":       32000,
+	"codex":        32000,
+	"gemini":       64000,
+	"amp":          32000,
+	"cursor-agent": 32000,
+}
+
+// modelContextBudgets override contextBudgets for smaller models.
+var modelContextBudgets = map[string]int{
+	"haiku":                  16000,
+	"gemini-3-flash-preview": 32000,
+	"gemini-2.5-flash":       32000,
+}
+
+// ContextBudget returns the estimated context budget, in tokens, for an
+// adapter running the given model ("" for the adapter's default).
+func ContextBudget(adapterName, model string) int {
+	if n, ok := modelContextBudgets[model]; ok {
+		return n
+	}
+	if n, ok := contextBudgets[adapterName]; ok {
+		return n
+	}
+	return DefaultContextBudget
+}
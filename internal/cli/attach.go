@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codebeauty/horde/internal/adapter"
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/output"
+)
+
+// attachmentsDir is where attachments are copied, relative to the run
+// directory.
+const attachmentsDir = "attachments"
+
+// resolveAttachments checks that each --attach path is a regular file and
+// returns the absolute paths.
+func resolveAttachments(paths []string) ([]string, error) {
+	abs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("--attach: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("--attach %s: not a regular file", p)
+		}
+		a, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("--attach %s: %w", p, err)
+		}
+		abs = append(abs, a)
+	}
+	return abs, nil
+}
+
+// filterAttachable handles agents whose CLI cannot take attachments: they
+// are dropped when required is set, and otherwise run with the prompt alone
+// after a warning.
+func filterAttachable(cfg *config.Config, toolIDs []string, required bool) ([]string, error) {
+	tools, err := buildTools(cfg, toolIDs)
+	if err != nil {
+		return nil, err
+	}
+	var kept, unsupported []string
+	for _, t := range tools {
+		if adapter.SupportsAttachments(t.Adapter) {
+			kept = append(kept, t.ID)
+		} else {
+			unsupported = append(unsupported, t.ID)
+		}
+	}
+	if len(unsupported) == 0 {
+		return toolIDs, nil
+	}
+	names := strings.Join(unsupported, ", ")
+	if !required {
+		fmt.Fprintf(os.Stderr, "warning: %s cannot take attachments and will get the prompt alone (use --attach-required to skip them)\n", names)
+		return toolIDs, nil
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("--attach-required: none of the selected agents can take attachments (%s)", names)
+	}
+	fmt.Fprintf(os.Stderr, "Skipping %s: cannot take attachments\n", names)
+	return kept, nil
+}
+
+// copyAttachments copies the attachments into the run directory, so the
+// run keeps what the agents saw, and returns them for the manifest along
+// with the paths of the copies.
+func copyAttachments(runDir string, paths []string) ([]output.Attachment, []string, error) {
+	if len(paths) == 0 {
+		return nil, nil, nil
+	}
+	dir := filepath.Join(runDir, attachmentsDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+	var (
+		attached []output.Attachment
+		copies   []string
+		used     = make(map[string]bool)
+	)
+	for _, p := range paths {
+		name := uniqueName(filepath.Base(p), used)
+		dest := filepath.Join(dir, name)
+		n, err := copyFile(p, dest)
+		if err != nil {
+			return nil, nil, fmt.Errorf("copying attachment %s: %w", p, err)
+		}
+		attached = append(attached, output.Attachment{Source: p, File: filepath.Join(attachmentsDir, name), Bytes: n})
+		copies = append(copies, dest)
+	}
+	return attached, copies, nil
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is already
+// in used, and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[name] = true
+	return name
+}
+
+func copyFile(src, dest string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
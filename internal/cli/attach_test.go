@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestResolveAttachments(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	assert.NoError(t, os.WriteFile("shot.png", []byte("png"), 0o600))
+
+	paths, err := resolveAttachments([]string{"shot.png"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "shot.png")}, paths)
+
+	_, err = resolveAttachments([]string{"missing.png"})
+	assert.ErrorContains(t, err, "--attach: stat missing.png")
+	_, err = resolveAttachments([]string{"."})
+	assert.EqualError(t, err, "--attach .: not a regular file")
+}
+
+func TestFilterAttachable(t *testing.T) {
+	cfg := config.NewDefaults()
+	cfg.Tools["claude"] = config.ToolConfig{Binary: "claude", Adapter: "claude", Enabled: true}
+	cfg.Tools["gemini"] = config.ToolConfig{Binary: "gemini", Adapter: "gemini", Enabled: true}
+	cfg.Tools["amp"] = config.ToolConfig{Binary: "amp", Adapter: "amp", Enabled: true}
+	cfg.Tools["mytool"] = config.ToolConfig{Binary: "mytool", Enabled: true}
+
+	ids := []string{"claude", "amp", "gemini", "mytool"}
+	got, err := filterAttachable(cfg, ids, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ids, got, "without --attach-required every agent runs")
+
+	got, err = filterAttachable(cfg, ids, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"claude", "gemini"}, got)
+
+	_, err = filterAttachable(cfg, []string{"amp", "mytool"}, true)
+	assert.EqualError(t, err, "--attach-required: none of the selected agents can take attachments (amp, mytool)")
+}
+
+func TestCopyAttachments(t *testing.T) {
+	src := t.TempDir()
+	a := filepath.Join(src, "shot.png")
+	b := filepath.Join(src, "other", "shot.png")
+	assert.NoError(t, os.WriteFile(a, []byte("first"), 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(b), 0o700))
+	assert.NoError(t, os.WriteFile(b, []byte("second!"), 0o600))
+
+	runDir := t.TempDir()
+	attached, copies, err := copyAttachments(runDir, []string{a, b})
+	assert.NoError(t, err)
+	assert.Equal(t, []output.Attachment{
+		{Source: a, File: filepath.Join("attachments", "shot.png"), Bytes: 5},
+		{Source: b, File: filepath.Join("attachments", "shot-2.png"), Bytes: 7},
+	}, attached)
+	assert.Equal(t, []string{filepath.Join(runDir, "attachments", "shot.png"), filepath.Join(runDir, "attachments", "shot-2.png")}, copies)
+	data, err := os.ReadFile(copies[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "second!", string(data))
+
+	attached, copies, err = copyAttachments(runDir, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, attached)
+	assert.Nil(t, copies)
+}
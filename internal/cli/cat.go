@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func newCatCmd() *cobra.Command {
+	var (
+		outputDir string
+		format    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cat <run> [agent...]",
+		Short: "Print the responses of a run to stdout",
+		Long: "Prints the agent responses of a past run for piping into other tools. <run> is a run directory, " +
+			"its name in the output directory, or \"latest\". Agents are matched by ID (claude@security) " +
+			"or name (claude); without any, all responses are printed.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.ValidateResponseFormat(format); err != nil {
+				return err
+			}
+			runDir, err := resolveRunDir(args[0], outputDir)
+			if err != nil {
+				return err
+			}
+			m, err := output.ReadManifest(runDir)
+			if err != nil {
+				return fmt.Errorf("reading manifest: %w", err)
+			}
+			results, err := output.LoadResponses(m, runDir, args[1:])
+			if err != nil {
+				return err
+			}
+			return output.WriteResponses(cmd.OutOrStdout(), format, m, runDir, results)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, markdown, json, jsonl")
+	return cmd
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestCatCmd(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "run-111")
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	assert.NoError(t, output.WriteManifest(dir, &output.Manifest{
+		Prompt:    "review",
+		StartedAt: time.Now(),
+		Results: []output.ManifestResult{
+			{ToolID: "claude", Status: "success", Duration: "1s", OutputFile: "claude.md"},
+			{ToolID: "gemini", Status: "success", Duration: "2s", OutputFile: "gemini.md"},
+		},
+	}))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "claude.md"), []byte("from claude\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "gemini.md"), []byte("from gemini\n"), 0o600))
+
+	out, err := runRoot(t, "cat", "latest", "-o", base)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "from claude")
+	assert.Contains(t, out, "from gemini")
+
+	out, err = runRoot(t, "cat", "run-111", "gemini", "--format", "markdown", "-o", base)
+	assert.NoError(t, err)
+	assert.Equal(t, "## gemini\n\n_success · 2s_\n\nfrom gemini\n", out)
+
+	_, err = runRoot(t, "cat", "run-111", "--format", "yaml", "-o", base)
+	assert.ErrorContains(t, err, "invalid format")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+// Exit statuses of a raid gated with --fail-on or --fail-on-severity.
+const (
+	exitAgentsFailed = 1
+	exitFindings     = 2
+)
+
+// exitError is returned by commands that exit with a specific status.
+type exitError struct {
+	code int
+	msg  string
+}
+
+func (e *exitError) Error() string { return e.msg }
+
+// ExitCode returns the process exit status for an error returned by Execute.
+func ExitCode(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return 1
+}
+
+// validateFailOn checks --fail-on and --fail-on-severity and returns the
+// effective --fail-on mode: CI mode fails on any failed agent by default.
+func validateFailOn(failOn, minSeverity string, ci, findings bool) (string, error) {
+	switch failOn {
+	case "":
+		failOn = "none"
+		if ci {
+			failOn = "any"
+		}
+	case "any", "all", "none":
+	default:
+		return "", fmt.Errorf("invalid --fail-on %q: must be any, all, or none", failOn)
+	}
+	if minSeverity != "" {
+		if !findings {
+			return "", fmt.Errorf("--fail-on-severity requires --findings")
+		}
+		if !output.ValidSeverity(minSeverity) {
+			return "", fmt.Errorf("invalid --fail-on-severity %q: must be one of %s", minSeverity, strings.Join(output.Severities, ", "))
+		}
+	}
+	return failOn, nil
+}
+
+// checkFailOn returns an exitError when a finished raid trips --fail-on or
+// --fail-on-severity.
+func checkFailOn(m *output.Manifest, failOn string, report *output.FindingsReport, minSeverity string) error {
+	failed := 0
+	for _, r := range m.Results {
+		if r.Status != "success" {
+			failed++
+		}
+	}
+	switch {
+	case failOn == "any" && failed > 0:
+		return &exitError{exitAgentsFailed, fmt.Sprintf("%d of %d agent(s) failed", failed, len(m.Results))}
+	case failOn == "all" && failed == len(m.Results) && failed > 0:
+		return &exitError{exitAgentsFailed, fmt.Sprintf("all %d agent(s) failed", failed)}
+	}
+
+	if minSeverity == "" {
+		return nil
+	}
+	// Zero findings only passes the gate if some agent reported them.
+	if report == nil || report.Agents == 0 {
+		return &exitError{exitAgentsFailed, fmt.Sprintf("no agent returned a valid findings block to check --fail-on-severity %s against", minSeverity)}
+	}
+	n := 0
+	for _, f := range report.Findings {
+		if output.SeverityAtLeast(f.Severity, minSeverity) {
+			n++
+		}
+	}
+	if n > 0 {
+		return &exitError{exitFindings, fmt.Sprintf("%d finding(s) at or above %s severity", n, minSeverity)}
+	}
+	return nil
+}
+
+// writeCIReports writes junit.xml into the run and prints GitHub Actions
+// annotations for failed agents and findings to stderr.
+func writeCIReports(m *output.Manifest, runDir string, report *output.FindingsReport) {
+	data, err := output.BuildJUnit(m, runDir)
+	if err == nil {
+		err = output.AtomicWrite(filepath.Join(runDir, output.JUnitFile), data, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to write %s: %v\n", output.JUnitFile, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "JUnit report: %s\n", filepath.Join(runDir, output.JUnitFile))
+	}
+	for _, line := range output.GitHubAnnotations(m, runDir, report) {
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
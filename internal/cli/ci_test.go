@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestValidateFailOn(t *testing.T) {
+	mode, err := validateFailOn("", "", false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "none", mode)
+
+	mode, err = validateFailOn("", "", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "any", mode, "CI mode fails on any agent by default")
+
+	mode, err = validateFailOn("none", "", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "none", mode)
+
+	_, err = validateFailOn("some", "", false, false)
+	assert.ErrorContains(t, err, "invalid --fail-on")
+	_, err = validateFailOn("", "high", false, false)
+	assert.ErrorContains(t, err, "requires --findings")
+	_, err = validateFailOn("", "severe", false, true)
+	assert.ErrorContains(t, err, "invalid --fail-on-severity")
+}
+
+func TestCheckFailOn(t *testing.T) {
+	m := &output.Manifest{Results: []output.ManifestResult{
+		{ToolID: "a", Status: "success"},
+		{ToolID: "b", Status: "failed"},
+	}}
+
+	assert.NoError(t, checkFailOn(m, "none", nil, ""))
+	assert.NoError(t, checkFailOn(m, "all", nil, ""))
+	err := checkFailOn(m, "any", nil, "")
+	assert.EqualError(t, err, "1 of 2 agent(s) failed")
+	assert.Equal(t, exitAgentsFailed, ExitCode(err))
+
+	m.Results[0].Status = "timeout"
+	assert.EqualError(t, checkFailOn(m, "all", nil, ""), "all 2 agent(s) failed")
+
+	m.Results[0].Status, m.Results[1].Status = "success", "success"
+	report := &output.FindingsReport{Agents: 2, Findings: []output.MergedFinding{
+		{Finding: output.Finding{Severity: "medium"}},
+		{Finding: output.Finding{Severity: "critical"}},
+	}}
+	err = checkFailOn(m, "any", report, "high")
+	assert.EqualError(t, err, "1 finding(s) at or above high severity")
+	assert.Equal(t, exitFindings, ExitCode(err))
+	assert.NoError(t, checkFailOn(m, "any", report, ""))
+	report.Findings = report.Findings[:1]
+	assert.NoError(t, checkFailOn(m, "any", report, "high"))
+
+	// No findings because no agent returned a valid findings block is not a
+	// pass: the severity gate could not be checked.
+	m.Results[0].Status = "failed"
+	noFindings := checkFailOn(m, "none", &output.FindingsReport{Errors: map[string]string{"b": "no findings block"}}, "high")
+	assert.EqualError(t, noFindings, "no agent returned a valid findings block to check --fail-on-severity high against")
+	assert.Equal(t, exitAgentsFailed, ExitCode(noFindings))
+	assert.Error(t, checkFailOn(m, "none", nil, "high"))
+	assert.NoError(t, checkFailOn(m, "none", nil, ""))
+
+	assert.Equal(t, 1, ExitCode(fmt.Errorf("plain")))
+	assert.Equal(t, exitFindings, ExitCode(fmt.Errorf("wrapped: %w", err)))
+}
@@ -3,61 +3,198 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
+	"github.com/codebeauty/horde/internal/config"
 	"github.com/codebeauty/horde/internal/output"
 )
 
+// defaultMaxAge applies when neither flags nor config set an age, size or
+// keep-last rule.
+const defaultMaxAge = "1d"
+
 type jsonCandidate struct {
-	Name  string    `json:"name"`
-	Path  string    `json:"path"`
-	Mtime time.Time `json:"mtime"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Mtime     time.Time `json:"mtime"`
+	StartedAt time.Time `json:"startedAt,omitzero"`
+	Size      int64     `json:"size,omitempty"`
+	Reason    string    `json:"reason"`
 }
 
-func toJSONCandidates(candidates []output.Candidate) []jsonCandidate {
+func toJSONCandidates(candidates []output.PruneCandidate) []jsonCandidate {
 	jc := make([]jsonCandidate, len(candidates))
 	for i, c := range candidates {
-		jc[i] = jsonCandidate{Name: c.Name, Path: c.Path, Mtime: c.Mtime}
+		jc[i] = jsonCandidate{Name: c.Name, Path: c.Path, Mtime: c.Mtime, StartedAt: c.StartedAt, Size: c.Size, Reason: c.Reason}
 	}
 	return jc
 }
 
+// parseRetention converts a configured retention policy.
+func parseRetention(rc config.RetentionConfig) (output.RetentionPolicy, error) {
+	p := output.RetentionPolicy{KeepLast: rc.KeepLast}
+	var err error
+	if rc.MaxAge != "" {
+		if p.MaxAge, err = output.ParseDuration(rc.MaxAge); err != nil {
+			return p, fmt.Errorf("invalid max age: %w", err)
+		}
+		// An explicit zero age selects every run rather than disabling the rule.
+		p.MaxAge = max(p.MaxAge, time.Nanosecond)
+	}
+	if rc.KeepFailed != "" {
+		if p.KeepFailed, err = output.ParseDuration(rc.KeepFailed); err != nil {
+			return p, fmt.Errorf("invalid keep-failed age: %w", err)
+		}
+	}
+	if rc.MaxSize != "" {
+		if p.MaxSize, err = output.ParseSize(rc.MaxSize); err != nil {
+			return p, fmt.Errorf("invalid max size: %w", err)
+		}
+	}
+	if p.KeepLast < 0 {
+		return p, fmt.Errorf("keep-last must not be negative")
+	}
+	return p, nil
+}
+
+// removeRuns archives (when archive is set) and removes each candidate,
+// logging progress to log, and returns the runs actually removed. A run
+// that could not be archived, or that is pinned or locked on disk, is never
+// removed.
+func removeRuns(candidates []output.PruneCandidate, archive string, log io.Writer) []output.PruneCandidate {
+	if archive != "" {
+		if err := os.MkdirAll(archive, 0o700); err != nil {
+			fmt.Fprintf(log, "  error creating archive dir: %v\n", err)
+			return nil
+		}
+	}
+
+	var removed []output.PruneCandidate
+	for _, c := range candidates {
+		if archive != "" {
+			dest := filepath.Join(archive, c.Name+".tar.gz")
+			if _, err := output.ExportRunFile(c.Path, dest, output.ExportOptions{}); err != nil {
+				fmt.Fprintf(log, "  error archiving %s: %v\n", c.Name, err)
+				continue
+			}
+			fmt.Fprintf(log, "  archived: %s\n", dest)
+		}
+		// The candidate came from the run index; check the run itself in case
+		// it was pinned or reopened since.
+		if reason := output.KeepReason(c.Path); reason != "" {
+			fmt.Fprintf(log, "  kept %s: %s\n", c.Name, reason)
+			continue
+		}
+		if err := os.RemoveAll(c.Path); err != nil {
+			fmt.Fprintf(log, "  error removing %s: %v\n", c.Name, err)
+			continue
+		}
+		_ = output.UnindexRun(c.Path)
+		removed = append(removed, c)
+		fmt.Fprintf(log, "  removed: %s\n", c.Name)
+	}
+	return removed
+}
+
+// autoPrune applies the configured retention policy after a raid when
+// retention.autoPrune is set. The run just written is never removed.
+func autoPrune(cfg *config.Config, runDir string) int {
+	if !cfg.Retention.AutoPrune || cfg.Retention.IsZero() {
+		return 0
+	}
+	policy, err := parseRetention(cfg.Retention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: retention: %v\n", err)
+		return 0
+	}
+	candidates, _, err := output.SelectForPrune(filepath.Dir(runDir), policy, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: auto-prune: %v\n", err)
+		return 0
+	}
+	var prune []output.PruneCandidate
+	for _, c := range candidates {
+		if filepath.Clean(c.Path) != filepath.Clean(runDir) {
+			prune = append(prune, c)
+		}
+	}
+	return len(removeRuns(prune, "", io.Discard))
+}
+
 func newCleanupCmd() *cobra.Command {
 	var (
-		olderThan string
-		outputDir string
-		dryRun    bool
-		yes       bool
-		jsonOut   bool
+		olderThan  string
+		keepLast   int
+		maxSize    string
+		keepFailed string
+		outputDir  string
+		dryRun     bool
+		yes        bool
+		jsonOut    bool
+		archive    string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "cleanup",
 		Short: "Remove old output directories",
+		Long: "Removes runs selected by the retention policy: the \"retention\" section of the config, " +
+			"overridden rule by rule by flags. Age is measured from when a run started. Pinned runs, runs " +
+			"still in progress and directories not created by horde are never removed. --keep-last alone " +
+			"removes all but the newest runs. Without an age, size or keep-last rule, runs older than " +
+			defaultMaxAge + " are removed.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			dur, err := output.ParseDuration(olderThan)
+			cfg, err := config.LoadMerged(mustGetwd())
 			if err != nil {
-				return fmt.Errorf("invalid --older-than: %w", err)
+				return fmt.Errorf("loading config: %w", err)
 			}
-
-			baseDir, err := resolveOutputDir(outputDir)
+			rc := cfg.Retention
+			if cmd.Flags().Changed("older-than") {
+				rc.MaxAge = olderThan
+			}
+			if cmd.Flags().Changed("keep-last") {
+				rc.KeepLast = keepLast
+			}
+			if cmd.Flags().Changed("max-size") {
+				rc.MaxSize = maxSize
+			}
+			if cmd.Flags().Changed("keep-failed") {
+				rc.KeepFailed = keepFailed
+			}
+			// keep-last on its own is a complete rule; keep-failed only
+			// extends an age limit, so it gets the default one.
+			if rc.MaxAge == "" && rc.MaxSize == "" && rc.KeepLast == 0 {
+				rc.MaxAge = defaultMaxAge
+			}
+			policy, err := parseRetention(rc)
 			if err != nil {
 				return err
 			}
 
-			cutoff := time.Now().Add(-dur)
-			candidates, err := output.ScanCandidates(baseDir, cutoff)
+			baseDir := outputDir
+			if baseDir == "" {
+				baseDir = cfg.Defaults.OutputDir
+			}
+
+			candidates, skipped, err := output.SelectForPrune(baseDir, policy, time.Now())
 			if err != nil {
 				return err
 			}
+			if len(skipped) > 0 {
+				fmt.Fprintf(os.Stderr, "Skipping %d director(ies):\n", len(skipped))
+				for _, d := range skipped {
+					fmt.Fprintf(os.Stderr, "  %s: %s\n", d.Name, d.Reason)
+				}
+			}
 
 			if len(candidates) == 0 {
 				if jsonOut {
-					fmt.Println("[]")
+					fmt.Fprintln(cmd.OutOrStdout(), "[]")
 				} else {
 					fmt.Fprintln(os.Stderr, "No directories to clean up.")
 				}
@@ -66,13 +203,17 @@ func newCleanupCmd() *cobra.Command {
 
 			if dryRun {
 				if jsonOut {
-					enc := json.NewEncoder(os.Stdout)
+					enc := json.NewEncoder(cmd.OutOrStdout())
 					enc.SetIndent("", "  ")
 					return enc.Encode(toJSONCandidates(candidates))
 				}
-				fmt.Fprintf(os.Stderr, "Would remove %d director(ies):\n", len(candidates))
+				verb := "remove"
+				if archive != "" {
+					verb = "archive to " + archive + " and remove"
+				}
+				fmt.Fprintf(os.Stderr, "Would %s %d director(ies):\n", verb, len(candidates))
 				for _, c := range candidates {
-					fmt.Fprintf(os.Stderr, "  %s (modified %s)\n", c.Name, c.Mtime.Format(time.RFC3339))
+					fmt.Fprintf(os.Stderr, "  %s: %s\n", c.Name, c.Reason)
 				}
 				return nil
 			}
@@ -83,7 +224,7 @@ func newCleanupCmd() *cobra.Command {
 				}
 				fmt.Fprintf(os.Stderr, "Will remove %d director(ies):\n", len(candidates))
 				for _, c := range candidates {
-					fmt.Fprintf(os.Stderr, "  %s\n", c.Name)
+					fmt.Fprintf(os.Stderr, "  %s: %s\n", c.Name, c.Reason)
 				}
 				fmt.Fprintf(os.Stderr, "\nProceed? [y/N] ")
 				var answer string
@@ -94,34 +235,32 @@ func newCleanupCmd() *cobra.Command {
 				}
 			}
 
-			removed := 0
-			for _, c := range candidates {
-				if err := os.RemoveAll(c.Path); err != nil {
-					fmt.Fprintf(os.Stderr, "  error removing %s: %v\n", c.Name, err)
-				} else {
-					removed++
-					if !jsonOut {
-						fmt.Fprintf(os.Stderr, "  removed: %s\n", c.Name)
-					}
-				}
+			var log io.Writer = os.Stderr
+			if jsonOut {
+				log = io.Discard
 			}
-			fmt.Fprintf(os.Stderr, "Removed %d director(ies)\n", removed)
+			removed := removeRuns(candidates, archive, log)
+			fmt.Fprintf(os.Stderr, "Removed %d director(ies)\n", len(removed))
 
 			if jsonOut {
-				enc := json.NewEncoder(os.Stdout)
+				enc := json.NewEncoder(cmd.OutOrStdout())
 				enc.SetIndent("", "  ")
-				return enc.Encode(toJSONCandidates(candidates))
+				return enc.Encode(toJSONCandidates(removed))
 			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&olderThan, "older-than", "1d", "Age threshold (e.g., 1d, 2w, 30m)")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Age threshold (e.g., 1d, 2w, 30m; default "+defaultMaxAge+" without an age, size or keep-last rule)")
+	cmd.Flags().IntVar(&keepLast, "keep-last", 0, "Always keep the N most recent runs")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Remove the oldest runs until the output directory fits (e.g., 500MB, 2GB)")
+	cmd.Flags().StringVar(&keepFailed, "keep-failed", "", "Age threshold for runs with failed agents, if longer than --older-than")
 	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed without deleting")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed, and why, without deleting")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
 	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output in JSON format")
+	cmd.Flags().StringVar(&archive, "archive", "", "Archive each run to <dir>/<run>.tar.gz before removing it")
 
 	return cmd
 }
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestCleanupKeepLast(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	setupRunDir(t, base, "run-1", now.Add(-3*time.Hour))
+	setupRunDir(t, base, "run-2", now.Add(-2*time.Hour))
+	setupRunDir(t, base, "run-3", now.Add(-time.Hour))
+
+	_, err := runRoot(t, "cleanup", "--older-than", "0s", "--keep-last", "2", "-y", "-o", base)
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(base, "run-1"))
+	assert.DirExists(t, filepath.Join(base, "run-2"))
+	assert.DirExists(t, filepath.Join(base, "run-3"))
+}
+
+func TestCleanupRejectsInvalidRules(t *testing.T) {
+	_, err := runRoot(t, "cleanup", "--max-size", "lots", "-o", t.TempDir())
+	assert.ErrorContains(t, err, "invalid max size")
+}
+
+func TestAutoPrune(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	old := setupRunDir(t, base, "run-old", now.Add(-48*time.Hour))
+	current := setupRunDir(t, base, "run-current", now.Add(-72*time.Hour))
+
+	cfg := config.NewDefaults()
+	cfg.Retention = config.RetentionConfig{MaxAge: "1d"}
+	assert.Equal(t, 0, autoPrune(cfg, current), "autoPrune disabled")
+
+	cfg.Retention.AutoPrune = true
+	assert.Equal(t, 1, autoPrune(cfg, current))
+	assert.NoDirExists(t, old)
+	assert.DirExists(t, current)
+}
+
+func TestCleanupRechecksRunsBeforeRemoving(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	pinned := setupRunDir(t, base, "run-pinned", now.Add(-3*time.Hour))
+	active := setupRunDir(t, base, "run-active", now.Add(-2*time.Hour))
+	old := setupRunDir(t, base, "run-old", now.Add(-time.Hour))
+
+	// Index the runs, then change them on disk behind the index's back.
+	candidates, _, err := output.SelectForPrune(base, output.RetentionPolicy{MaxAge: time.Nanosecond}, now)
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 3)
+	m, err := output.ReadManifest(pinned)
+	assert.NoError(t, err)
+	m.Pinned = true
+	data, _ := json.Marshal(m)
+	assert.NoError(t, os.WriteFile(filepath.Join(pinned, "run.json"), data, 0o600))
+	assert.NoError(t, output.LockRun(active))
+
+	var log strings.Builder
+	removed := removeRuns(candidates, "", &log)
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, "run-old", removed[0].Name)
+	}
+	assert.DirExists(t, pinned)
+	assert.DirExists(t, active)
+	assert.NoDirExists(t, old)
+	assert.Contains(t, log.String(), "kept run-pinned: pinned")
+	assert.Contains(t, log.String(), "kept run-active: still in progress")
+}
+
+func TestCleanupJSONListsOnlyRemovedRuns(t *testing.T) {
+	base := t.TempDir()
+	setupRunDir(t, base, "run-1", time.Now().Add(-time.Hour))
+	notDir := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(notDir, nil, 0o600))
+
+	out, err := runRoot(t, "cleanup", "--older-than", "0s", "-y", "--json", "--archive", notDir, "-o", base)
+	assert.NoError(t, err)
+	assert.JSONEq(t, "[]", out, "a run that could not be archived was not removed")
+	assert.DirExists(t, filepath.Join(base, "run-1"))
+
+	out, err = runRoot(t, "cleanup", "--older-than", "0s", "-y", "--json", "-o", base)
+	assert.NoError(t, err)
+	var removed []jsonCandidate
+	assert.NoError(t, json.Unmarshal([]byte(out), &removed))
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, "run-1", removed[0].Name)
+	}
+}
+
+func TestCleanupKeepLastAlone(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"run-1", "run-2", "run-3", "run-4"} {
+		setupRunDir(t, base, name, now.Add(-time.Duration(4-i)*time.Minute))
+	}
+
+	_, err := runRoot(t, "cleanup", "--keep-last", "1", "-y", "-o", base)
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(base, "run-1"))
+	assert.NoDirExists(t, filepath.Join(base, "run-3"))
+	assert.DirExists(t, filepath.Join(base, "run-4"))
+}
+
+func TestCleanupKeepFailedAlone(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	ok := setupRunDir(t, base, "run-ok", now.Add(-72*time.Hour))
+	failed := setupRunDir(t, base, "run-failed", now.Add(-72*time.Hour))
+	m, err := output.ReadManifest(failed)
+	assert.NoError(t, err)
+	m.Results = []output.ManifestResult{{ToolID: "claude", Status: "failed"}}
+	assert.NoError(t, output.WriteManifest(failed, m))
+
+	// Successful runs fall back to the default age limit.
+	_, err = runRoot(t, "cleanup", "--keep-failed", "1w", "-y", "-o", base)
+	assert.NoError(t, err)
+	assert.NoDirExists(t, ok)
+	assert.DirExists(t, failed)
+}
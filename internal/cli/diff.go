@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func newDiffCmd() *cobra.Command {
+	var (
+		outputDir  string
+		sideBySide bool
+		width      int
+		statsOnly  bool
+		jsonOut    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <runA> <runB>",
+		Short: "Compare two runs agent by agent",
+		Long: "Aligns the agents of two runs by tool ID and raider, shows status, duration and cost deltas, " +
+			"diffs each agent's response, and reports which structured findings disappeared, persisted " +
+			"or newly appeared. Runs are directories, names in the output directory, or \"latest\".",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirA, err := resolveRunDir(args[0], outputDir)
+			if err != nil {
+				return err
+			}
+			dirB, err := resolveRunDir(args[1], outputDir)
+			if err != nil {
+				return err
+			}
+			a, err := output.ReadManifest(dirA)
+			if err != nil {
+				return fmt.Errorf("reading manifest of %s: %w", args[0], err)
+			}
+			b, err := output.ReadManifest(dirB)
+			if err != nil {
+				return fmt.Errorf("reading manifest of %s: %w", args[1], err)
+			}
+
+			d := output.DiffRuns(a, dirA, b, dirB)
+			if jsonOut {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(d)
+			}
+			printRunDiff(cmd.OutOrStdout(), d, !statsOnly, sideBySide, width)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	cmd.Flags().BoolVarP(&sideBySide, "side-by-side", "y", false, "Show response diffs in two columns")
+	cmd.Flags().IntVar(&width, "width", 60, "Column width for --side-by-side")
+	cmd.Flags().BoolVar(&statsOnly, "stat", false, "Only show status/duration/cost deltas and findings")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output the comparison as JSON")
+	return cmd
+}
+
+func printRunDiff(w io.Writer, d *output.RunDiff, showText, sideBySide bool, width int) {
+	fmt.Fprintf(w, "Comparing %s → %s\n\n", d.RunA, d.RunB)
+
+	for _, ad := range d.Agents {
+		name := ad.ToolID
+		switch {
+		case ad.B == nil:
+			fmt.Fprintf(w, "  %-28s only in %s (%s)\n", name, d.RunA, ad.A.Status)
+			continue
+		case ad.A == nil:
+			fmt.Fprintf(w, "  %-28s only in %s (%s)\n", name, d.RunB, ad.B.Status)
+			continue
+		}
+		status := ad.B.Status
+		if ad.A.Status != ad.B.Status {
+			status = ad.A.Status + " → " + ad.B.Status
+		}
+		line := fmt.Sprintf("  %-28s %-20s %s → %s", name, status, ad.A.Duration, ad.B.Duration)
+		if ad.DurationDelta != "" {
+			line += " (" + ad.DurationDelta + ")"
+		}
+		if ad.A.CostUSD > 0 || ad.B.CostUSD > 0 {
+			line += fmt.Sprintf("   $%.2f → $%.2f (%s)", ad.A.CostUSD, ad.B.CostUSD, formatCostDelta(ad.CostDelta))
+		}
+		if !ad.Changed {
+			line += "   response unchanged"
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	if showText {
+		for _, ad := range d.Agents {
+			if !ad.Changed || ad.A == nil || ad.B == nil {
+				continue
+			}
+			fmt.Fprintf(w, "\n=== %s ===\n", ad.ToolID)
+			if sideBySide {
+				ta, tb := ad.Text()
+				fmt.Fprint(w, output.SideBySideDiff(ta, tb, width))
+			} else {
+				fmt.Fprint(w, ad.Diff)
+			}
+		}
+	}
+
+	if f := d.Findings; f != nil {
+		fmt.Fprintf(w, "\nFindings: %d disappeared, %d persisted, %d new\n",
+			len(f.Disappeared), len(f.Persisted), len(f.New))
+		printFindingList(w, "-", f.Disappeared)
+		printFindingList(w, "=", f.Persisted)
+		printFindingList(w, "+", f.New)
+	}
+}
+
+func printFindingList(w io.Writer, mark string, findings []output.Finding) {
+	for _, f := range findings {
+		loc := ""
+		if f.File != "" {
+			loc = " — " + f.File
+			if f.StartLine > 0 {
+				loc += fmt.Sprintf(":%d", f.StartLine)
+			}
+		}
+		fmt.Fprintf(w, "  %s [%s] %s%s\n", mark, f.Severity, strings.TrimSpace(f.Title), loc)
+	}
+}
+
+func formatCostDelta(d float64) string {
+	if d < 0 {
+		return fmt.Sprintf("-$%.2f", -d)
+	}
+	return fmt.Sprintf("+$%.2f", d)
+}
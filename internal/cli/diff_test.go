@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func setupDiffRuns(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	now := time.Now()
+	for i, text := range []string{"first answer\n", "second answer\n"} {
+		name := []string{"review-run-111", "review-run-222"}[i]
+		dir := setupRunDir(t, base, name, now.Add(time.Duration(i)*time.Minute))
+		m, err := output.ReadManifest(dir)
+		assert.NoError(t, err)
+		m.Results = []output.ManifestResult{{ToolID: "claude", Status: "success", Duration: "10s", OutputFile: "claude.md"}}
+		assert.NoError(t, output.WriteManifest(dir, m))
+		os.WriteFile(filepath.Join(dir, "claude.md"), []byte(text), 0o600)
+	}
+	return base
+}
+
+func TestDiffCmd(t *testing.T) {
+	base := setupDiffRuns(t)
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"diff", "review-run-111", "review-run-222", "-o", base})
+	assert.NoError(t, root.Execute())
+	out := stdout.String()
+	assert.Contains(t, out, "Comparing review-run-111 → review-run-222")
+	assert.Contains(t, out, "-first answer")
+	assert.Contains(t, out, "+second answer")
+}
+
+func TestDiffCmdJSON(t *testing.T) {
+	base := setupDiffRuns(t)
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"diff", "review-run-111", "latest", "-o", base, "--json"})
+	assert.NoError(t, root.Execute())
+
+	var d output.RunDiff
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+	assert.Equal(t, "review-run-222", d.RunB)
+	assert.Len(t, d.Agents, 1)
+	assert.True(t, d.Agents[0].Changed)
+	assert.Equal(t, "+0s", d.Agents[0].DurationDelta)
+	assert.Nil(t, d.Findings)
+}
@@ -105,9 +105,7 @@ func newDoctorCmd() *cobra.Command {
 					}
 
 					// Version check (only if binary found)
-					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-					out, err := exec.CommandContext(ctx, binPath, "--version").CombinedOutput()
-					cancel()
+					firstLine, err := agentVersion(binPath)
 					if err != nil {
 						if rich {
 							warn("  Could not determine version")
@@ -115,7 +113,6 @@ func newDoctorCmd() *cobra.Command {
 							warn(fmt.Sprintf("%s: could not determine version", toolID))
 						}
 					} else {
-						firstLine := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
 						if rich {
 							pass(fmt.Sprintf("  Version: %s", firstLine))
 						} else {
@@ -165,3 +162,15 @@ func newDoctorCmd() *cobra.Command {
 		},
 	}
 }
+
+// agentVersion runs "<binary> --version" and returns the first line of its
+// output. Shared by doctor and the raid manifest.
+func agentVersion(binPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, binPath, "--version").CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]), nil
+}
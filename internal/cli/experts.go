@@ -7,9 +7,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
+	"github.com/codebeauty/horde/internal/adapter"
 	"github.com/codebeauty/horde/internal/config"
 	"github.com/codebeauty/horde/internal/raider"
 )
@@ -27,6 +29,7 @@ func newExpertsCmd() *cobra.Command {
 	cmd.AddCommand(newExpertsEditCmd())
 	cmd.AddCommand(newExpertsResetCmd())
 	cmd.AddCommand(newExpertsDeleteCmd())
+	cmd.AddCommand(newExpertsLintCmd())
 	return cmd
 }
 
@@ -44,14 +47,24 @@ func newExpertsListCmd() *cobra.Command {
 				fmt.Println("No raiders found. Run 'horde wake' or 'horde raiders reset' to install built-in presets.")
 				return nil
 			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
 			for _, id := range ids {
 				label := id
 				if _, ok := raider.Builtins[id]; ok {
-					label += "  (built-in)"
+					label += " (built-in)"
 				}
-				fmt.Println(label)
+				r, err := raider.Read(id, dir)
+				if err != nil {
+					fmt.Fprintf(w, "%s\t(invalid: run 'horde raiders lint %s')\t\n", label, id)
+					continue
+				}
+				var tags string
+				if len(r.Meta.Tags) > 0 {
+					tags = "[" + strings.Join(r.Meta.Tags, ", ") + "]"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", label, r.Meta.Description, tags)
 			}
-			return nil
+			return w.Flush()
 		},
 	}
 }
@@ -63,13 +76,17 @@ func newExpertsShowCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir := raider.Dir()
-			content, err := raider.Load(args[0], dir)
+			if _, err := raider.Read(args[0], dir); err != nil {
+				return err
+			}
+			data, err := os.ReadFile(filepath.Join(dir, args[0]+".md"))
 			if err != nil {
 				return err
 			}
-			fmt.Print(content)
+			content := string(data)
+			fmt.Fprint(cmd.OutOrStdout(), content)
 			if len(content) > 0 && content[len(content)-1] != '\n' {
-				fmt.Println()
+				fmt.Fprintln(cmd.OutOrStdout())
 			}
 			return nil
 		},
@@ -97,7 +114,7 @@ func newExpertsCreateCmd() *cobra.Command {
 				return fmt.Errorf("raider %q already exists — use 'horde raiders edit %s'", id, id)
 			}
 
-			template := fmt.Sprintf("You are a %s.\n\nFocus on:\n- \n", id)
+			template := fmt.Sprintf("---\ndescription: \ntags: []\n---\nYou are a %s.\n\nFocus on:\n- \n", id)
 			if err := os.WriteFile(path, []byte(template), 0o600); err != nil {
 				return err
 			}
@@ -184,6 +201,93 @@ func newExpertsDeleteCmd() *cobra.Command {
 	return cmd
 }
 
+func newExpertsLintCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lint [id|file.md...]",
+		Short: "Check raider files and their front matter",
+		Long: "Checks raider files for malformed or unknown front matter fields, invalid values, " +
+			"a minimum horde version newer than this one, recommended agents that are not configured, and missing role text. " +
+			"Without arguments, every raider in the raiders directory is checked.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			paths, err := raiderPaths(args)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			failed := 0
+			for _, path := range paths {
+				problems, err := lintRaider(path, cfg)
+				if err != nil {
+					return err
+				}
+				if len(problems) > 0 {
+					failed++
+				}
+				for _, p := range problems {
+					fmt.Fprintf(out, "%s: %s\n", path, p)
+				}
+			}
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d raider(s) have problems", failed, len(paths))
+			}
+			fmt.Fprintf(out, "%d raider(s) OK\n", len(paths))
+			return nil
+		},
+	}
+}
+
+// raiderPaths resolves lint arguments, raider IDs or paths to .md files,
+// to file paths. No arguments means every raider in the raiders directory.
+func raiderPaths(args []string) ([]string, error) {
+	dir := raider.Dir()
+	if len(args) == 0 {
+		ids, err := raider.List(dir)
+		if err != nil {
+			return nil, err
+		}
+		args = ids
+	}
+	paths := make([]string, 0, len(args))
+	for _, a := range args {
+		if strings.HasSuffix(a, ".md") {
+			paths = append(paths, a)
+			continue
+		}
+		if err := raider.ValidateID(a); err != nil {
+			return nil, err
+		}
+		paths = append(paths, filepath.Join(dir, a+".md"))
+	}
+	return paths, nil
+}
+
+// lintRaider checks a raider file, including that its recommended agents
+// are configured agents or built-in adapters.
+func lintRaider(path string, cfg *config.Config) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	problems := raider.Lint(string(data), version)
+	if meta, _, err := raider.Parse(string(data)); err == nil {
+		for _, a := range meta.RecommendedAgents {
+			if _, ok := cfg.Tools[a]; ok {
+				continue
+			}
+			if _, err := adapter.Get(a); err == nil {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("recommended agent %q is not configured", a))
+		}
+	}
+	return problems, nil
+}
+
 func findExpertTeamRefs(expertID string, cfg *config.Config) []string {
 	var refs []string
 	for name, members := range cfg.Teams {
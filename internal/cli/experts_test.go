@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/raider"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,3 +28,45 @@ func TestExpertTeamRefsNone(t *testing.T) {
 	refs := findExpertTeamRefs("security", cfg)
 	assert.Empty(t, refs)
 }
+
+func TestExpertsListAndLint(t *testing.T) {
+	cfg := config.NewDefaults()
+	cfg.Tools["claude-opus"] = config.ToolConfig{Binary: "claude", Adapter: "claude", Enabled: true}
+	setupConfig(t, cfg)
+	dir := raider.Dir()
+	_, err := raider.SyncBuiltins(dir, nil)
+	assert.NoError(t, err)
+	write := func(id, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, id+".md"), []byte(content), 0o600))
+	}
+	write("golang", "---\ndescription: Go specialist\ntags: [go]\nrecommendedAgents: [claude-opus, gemini]\n---\nYou are a Go expert.\n")
+
+	out, err := runRoot(t, "raiders", "list")
+	assert.NoError(t, err)
+	assert.Regexp(t, `golang +Go specialist +\[go\]\n`, out)
+	assert.Regexp(t, `security \(built-in\) +Finds vulnerabilities`, out)
+
+	out, err = runRoot(t, "raiders", "lint")
+	assert.NoError(t, err)
+	assert.Equal(t, "7 raider(s) OK\n", out)
+
+	out, err = runRoot(t, "raiders", "show", "golang")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "description: Go specialist")
+
+	write("broken", "---\ntimeout: -1\nrecommendedAgents: [nope]\n---\nYou are broken.\n")
+	out, err = runRoot(t, "raiders", "lint", "broken", "golang")
+	assert.EqualError(t, err, "1 of 2 raider(s) have problems")
+	path := filepath.Join(dir, "broken.md")
+	assert.Equal(t, path+": timeout -1 must not be negative\n"+path+`: recommended agent "nope" is not configured`+"\n", out)
+
+	project := filepath.Join(t.TempDir(), "team.md")
+	assert.NoError(t, os.WriteFile(project, []byte("---\nunknown: x\n---\nYou are on the team.\n"), 0o600))
+	out, err = runRoot(t, "raiders", "lint", project)
+	assert.Error(t, err)
+	assert.Contains(t, out, project+": front matter line 1: field unknown is not a known field")
+
+	out, err = runRoot(t, "raiders", "list")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "broken")
+}
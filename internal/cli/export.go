@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export run results to other formats",
+	}
+	cmd.AddCommand(newExportSarifCmd())
+	return cmd
+}
+
+func newExportSarifCmd() *cobra.Command {
+	var (
+		outFile   string
+		outputDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sarif <run>",
+		Short: "Export structured findings as SARIF 2.1.0",
+		Long: "Converts the structured findings of a run (see 'horde raid --findings') into SARIF 2.1.0 " +
+			"for GitHub code scanning and IDE SARIF viewers. Each agent and raider becomes its own tool driver.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runDir, err := resolveRunDir(args[0], outputDir)
+			if err != nil {
+				return err
+			}
+			m, err := output.ReadManifest(runDir)
+			if err != nil {
+				return fmt.Errorf("reading manifest: %w", err)
+			}
+			log, err := output.BuildSARIF(m, runDir)
+			if err != nil {
+				return err
+			}
+			data, err := output.MarshalSARIF(log)
+			if err != nil {
+				return err
+			}
+			if outFile == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err := output.AtomicWrite(outFile, data, 0o600); err != nil {
+				return fmt.Errorf("writing %s: %w", outFile, err)
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outFile, "output", "o", "", "Write to file instead of stdout")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Output directory (default: from config)")
+	return cmd
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+const findingsResponse = "Looks risky.\n\n```json\n" +
+	`{"findings": [{"severity": "medium", "title": "Unchecked error", "file": "main.go", "startLine": 7}]}` +
+	"\n```\n"
+
+func TestExportSarifCmd(t *testing.T) {
+	base := t.TempDir()
+	runDir := setupRunDir(t, base, "review-run-111", time.Now())
+	m, err := output.ReadManifest(runDir)
+	assert.NoError(t, err)
+	m.Results = []output.ManifestResult{
+		{ToolID: "claude@security", Expert: "security", Status: "success", OutputFile: "claude@security.md"},
+	}
+	assert.NoError(t, output.WriteManifest(runDir, m))
+	os.WriteFile(filepath.Join(runDir, "claude@security.md"), []byte(findingsResponse), 0o600)
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"export", "sarif", "latest", "--output-dir", base})
+	assert.NoError(t, root.Execute())
+
+	var log output.SarifLog
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	assert.Equal(t, "horde/claude@security", log.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, "warning", log.Runs[0].Results[0].Level)
+
+	outFile := filepath.Join(t.TempDir(), "horde.sarif")
+	root = newRootCmd()
+	root.SetArgs([]string{"export", "sarif", runDir, "-o", outFile})
+	assert.NoError(t, root.Execute())
+	data, err := os.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.Equal(t, stdout.String(), string(data))
+}
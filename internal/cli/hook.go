@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/gather"
+	"github.com/codebeauty/horde/internal/output"
+)
+
+// hookMarker identifies hooks written by horde, so that uninstall and
+// reinstall never touch hooks managed by something else.
+const hookMarker = "# horde-hook"
+
+// defaultHookPrompt is sent when the hook is installed without --prompt.
+const defaultHookPrompt = "Review the commits about to be pushed. Flag bugs, regressions, " +
+	"security issues and missing tests, and say whether they are safe to push."
+
+// maxHookFindings bounds the findings listed in the hook summary.
+const maxHookFindings = 5
+
+var supportedHooks = []string{"pre-push"}
+
+// hookOptions are the raid settings baked into an installed hook.
+type hookOptions struct {
+	agents    string
+	loadout   string
+	raider    string
+	prompt    string
+	block     bool
+	failOnSev string
+}
+
+func (o *hookOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.agents, "agents", "a", "", "Comma-separated agent IDs")
+	cmd.Flags().StringVarP(&o.loadout, "loadout", "l", "", "Named loadout from config")
+	cmd.Flags().StringVarP(&o.raider, "raider", "R", "", "Raider ID to apply to all agents")
+	cmd.Flags().StringVar(&o.prompt, "prompt", "", "Review prompt (default: a generic pre-push review)")
+	cmd.Flags().BoolVar(&o.block, "block", false, "Block the push when an agent fails")
+	cmd.Flags().StringVar(&o.failOnSev, "fail-on-severity", "", "Block the push on findings at or above this severity (enables --findings)")
+}
+
+// args returns the flags that reproduce o on the command line.
+func (o *hookOptions) args() []string {
+	var args []string
+	for _, f := range []struct{ name, value string }{
+		{"--agents", o.agents},
+		{"--loadout", o.loadout},
+		{"--raider", o.raider},
+		{"--prompt", o.prompt},
+		{"--fail-on-severity", o.failOnSev},
+	} {
+		if f.value != "" {
+			args = append(args, f.name, f.value)
+		}
+	}
+	if o.block {
+		args = append(args, "--block")
+	}
+	return args
+}
+
+// raidArgs returns the horde raid invocation reviewing diffRange.
+func (o *hookOptions) raidArgs(diffRange string) []string {
+	args := []string{"raid", "--diff", diffRange, "--yes"}
+	if o.agents != "" {
+		args = append(args, "--agents", o.agents)
+	}
+	if o.loadout != "" {
+		args = append(args, "--loadout", o.loadout)
+	}
+	if o.raider != "" {
+		args = append(args, "--raider", o.raider)
+	}
+	if o.failOnSev != "" {
+		args = append(args, "--findings", "--fail-on-severity", o.failOnSev)
+	}
+	if o.block {
+		args = append(args, "--fail-on", "any")
+	}
+	prompt := o.prompt
+	if prompt == "" {
+		prompt = defaultHookPrompt
+	}
+	return append(args, "--", prompt)
+}
+
+func newHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Review outgoing changes from git hooks",
+	}
+	cmd.AddCommand(newHookInstallCmd())
+	cmd.AddCommand(newHookUninstallCmd())
+	cmd.AddCommand(newHookRunCmd())
+	return cmd
+}
+
+func validateHook(name string) error {
+	if !slices.Contains(supportedHooks, name) {
+		return fmt.Errorf("unsupported hook %q (supported: %s)", name, strings.Join(supportedHooks, ", "))
+	}
+	return nil
+}
+
+// hookScript returns the shell script installed as the named git hook.
+func hookScript(name string, opts hookOptions) string {
+	quoted := make([]string, 0, len(opts.args()))
+	for _, a := range opts.args() {
+		quoted = append(quoted, shellQuote(a))
+	}
+	cmdline := strings.Join(append([]string{"exec horde hook run", name}, quoted...), " ")
+	return fmt.Sprintf(`#!/bin/sh
+%s: installed by "horde hook install %s"; remove with "horde hook uninstall %s".
+# Set HORDE_SKIP=1 to skip the review.
+if [ -n "$HORDE_SKIP" ]; then
+	exit 0
+fi
+%s "$@"
+`, hookMarker, name, name, cmdline)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isHordeHook reports whether the hook at path was written by horde.
+func isHordeHook(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(data), hookMarker), nil
+}
+
+func newHookInstallCmd() *cobra.Command {
+	var (
+		opts  hookOptions
+		force bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install <hook>",
+		Short: "Install a git hook that raids the outgoing changes",
+		Long: "Installs a git hook that reviews changes with a raid. The pre-push hook reviews the commits " +
+			"being pushed (for a new branch, those not on the remote yet), prints a condensed summary and, with --block or " +
+			"--fail-on-severity, stops the push when the review fails. Set HORDE_SKIP=1 to skip it.",
+		Example: "  horde hook install pre-push --loadout fast --raider reviewer\n" +
+			"  horde hook install pre-push --fail-on-severity high",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := validateHook(name); err != nil {
+				return err
+			}
+			if opts.loadout != "" && opts.agents != "" {
+				return fmt.Errorf("--agents and --loadout are mutually exclusive")
+			}
+			if opts.failOnSev != "" && !output.ValidSeverity(opts.failOnSev) {
+				return fmt.Errorf("invalid --fail-on-severity %q: must be one of %s",
+					opts.failOnSev, strings.Join(output.Severities, ", "))
+			}
+			dir, err := gather.HooksDir(mustGetwd())
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(dir, name)
+			ours, err := isHordeHook(path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			if err == nil && !ours && !force {
+				return fmt.Errorf("%s already exists and was not installed by horde (use --force to replace it)", path)
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			if err := output.AtomicWrite(path, []byte(hookScript(name, opts)), 0o755); err != nil {
+				return fmt.Errorf("writing hook: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Installed %s hook: %s\n", name, path)
+			return nil
+		},
+	}
+
+	opts.addFlags(cmd)
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an existing hook not installed by horde")
+	return cmd
+}
+
+func newHookUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall [hook]",
+		Short: "Remove a git hook installed by horde",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := supportedHooks[0]
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if err := validateHook(name); err != nil {
+				return err
+			}
+			dir, err := gather.HooksDir(mustGetwd())
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(dir, name)
+			ours, err := isHordeHook(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("no %s hook installed", name)
+			}
+			if err != nil {
+				return err
+			}
+			if !ours {
+				return fmt.Errorf("%s was not installed by horde; leaving it in place", path)
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %s hook: %s\n", name, path)
+			return nil
+		},
+	}
+}
+
+func newHookRunCmd() *cobra.Command {
+	var opts hookOptions
+
+	cmd := &cobra.Command{
+		Use:    "run <hook> [git hook args...]",
+		Short:  "Run a horde git hook (invoked by git)",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateHook(args[0]); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			log := cmd.ErrOrStderr()
+			if os.Getenv("HORDE_SKIP") != "" {
+				fmt.Fprintln(log, "horde: HORDE_SKIP is set, skipping review")
+				return nil
+			}
+
+			// git passes the remote name and URL as arguments and the
+			// ref updates on stdin.
+			remote := ""
+			if len(args) > 1 {
+				remote = args[1]
+			}
+			updates, err := gather.ParsePushUpdates(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			if len(updates) == 0 {
+				fmt.Fprintln(log, "horde: nothing to push, skipping review")
+				return nil
+			}
+
+			wd := mustGetwd()
+			var raidErr error
+			for _, u := range updates {
+				rng, ok := gather.PushRange(wd, remote, u)
+				if !ok {
+					fmt.Fprintf(log, "horde: push deletes %s, skipping review\n", u.RemoteRef)
+					continue
+				}
+				if !gather.HasChanges(wd, rng) {
+					fmt.Fprintf(log, "horde: no changes in %s, skipping review\n", rng)
+					continue
+				}
+				fmt.Fprintf(log, "horde: reviewing %s for %s (set HORDE_SKIP=1 to skip)\n", rng, u.RemoteRef)
+				if err := runHookRaid(log, opts, rng); err != nil && raidErr == nil {
+					raidErr = err
+				}
+			}
+
+			if raidErr == nil {
+				return nil
+			}
+			if opts.block || opts.failOnSev != "" {
+				return fmt.Errorf("push blocked: %w (set HORDE_SKIP=1 to push anyway)", raidErr)
+			}
+			fmt.Fprintf(log, "horde: warning: %v\n", raidErr)
+			return nil
+		},
+	}
+
+	opts.addFlags(cmd)
+	return cmd
+}
+
+// runHookRaid raids diffRange and prints the summary of the run.
+func runHookRaid(log io.Writer, opts hookOptions, diffRange string) error {
+	started := time.Now().Add(-time.Second)
+	raid := newRootCmd()
+	raid.SetArgs(opts.raidArgs(diffRange))
+	raid.SilenceErrors = true
+	raidErr := raid.Execute()
+
+	if runDir, err := resolveRunDir("latest", ""); err == nil {
+		if m, err := output.ReadManifest(runDir); err == nil && !m.StartedAt.Before(started) {
+			printHookSummary(log, m, runDir)
+		}
+	}
+	return raidErr
+}
+
+// printHookSummary writes a short account of a run: agent outcomes, finding
+// counts by severity and the most severe findings.
+func printHookSummary(w io.Writer, m *output.Manifest, runDir string) {
+	ok := 0
+	for _, r := range m.Results {
+		if r.Status == "success" {
+			ok++
+		}
+	}
+	fmt.Fprintf(w, "\nhorde: %d/%d agent(s) succeeded", ok, len(m.Results))
+
+	var report *output.FindingsReport
+	if m.FindingsFile != "" {
+		report, _ = output.ReadFindings(runDir)
+	}
+	if report != nil {
+		counts := make(map[string]int)
+		for _, f := range report.Findings {
+			counts[f.Severity]++
+		}
+		var parts []string
+		for _, sev := range output.Severities {
+			if counts[sev] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "none")
+		}
+		fmt.Fprintf(w, ", findings: %s", strings.Join(parts, ", "))
+	}
+	fmt.Fprintln(w)
+
+	if report != nil {
+		for i, f := range report.Findings {
+			if i == maxHookFindings {
+				fmt.Fprintf(w, "  ... %d more\n", len(report.Findings)-maxHookFindings)
+				break
+			}
+			loc := f.File
+			if loc != "" && f.StartLine > 0 {
+				loc = fmt.Sprintf("%s:%d", f.File, f.StartLine)
+			}
+			if loc != "" {
+				loc = " " + loc
+			}
+			fmt.Fprintf(w, "  [%s]%s %s\n", f.Severity, loc, f.Title)
+		}
+	}
+	fmt.Fprintf(w, "horde: full review: %s\n", runDir)
+}
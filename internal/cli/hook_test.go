@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	out, err := exec.Command("git", "init", "-q", dir).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	return dir
+}
+
+func TestHookInstallUninstall(t *testing.T) {
+	dir := initGitRepo(t)
+	t.Chdir(dir)
+	hook := filepath.Join(dir, ".git", "hooks", "pre-push")
+
+	_, err := runRoot(t, "hook", "install", "pre-push", "--loadout", "fast", "--raider", "reviewer", "--prompt", "it's risky?")
+	assert.NoError(t, err)
+	info, err := os.Stat(hook)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	}
+	script, _ := os.ReadFile(hook)
+	assert.Contains(t, string(script), hookMarker)
+	assert.Contains(t, string(script), `if [ -n "$HORDE_SKIP" ]; then`)
+	assert.Contains(t, string(script), `exec horde hook run pre-push '--loadout' 'fast' '--raider' 'reviewer' '--prompt' 'it'\''s risky?' "$@"`)
+
+	// Reinstalling over our own hook is fine.
+	_, err = runRoot(t, "hook", "install", "pre-push", "--block")
+	assert.NoError(t, err)
+
+	_, err = runRoot(t, "hook", "uninstall")
+	assert.NoError(t, err)
+	assert.NoFileExists(t, hook)
+	_, err = runRoot(t, "hook", "uninstall", "pre-push")
+	assert.ErrorContains(t, err, "no pre-push hook installed")
+}
+
+func TestHookInstallKeepsForeignHooks(t *testing.T) {
+	dir := initGitRepo(t)
+	t.Chdir(dir)
+	hook := filepath.Join(dir, ".git", "hooks", "pre-push")
+	assert.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\nmake test\n"), 0o755))
+
+	_, err := runRoot(t, "hook", "install", "pre-push")
+	assert.ErrorContains(t, err, "not installed by horde")
+	_, err = runRoot(t, "hook", "uninstall")
+	assert.ErrorContains(t, err, "leaving it in place")
+	script, _ := os.ReadFile(hook)
+	assert.Equal(t, "#!/bin/sh\nmake test\n", string(script))
+
+	_, err = runRoot(t, "hook", "install", "pre-push", "--force")
+	assert.NoError(t, err)
+	script, _ = os.ReadFile(hook)
+	assert.Contains(t, string(script), hookMarker)
+}
+
+func TestHookInstallRejectsInvalid(t *testing.T) {
+	t.Chdir(initGitRepo(t))
+	_, err := runRoot(t, "hook", "install", "pre-commit")
+	assert.ErrorContains(t, err, "unsupported hook")
+	_, err = runRoot(t, "hook", "install", "pre-push", "--fail-on-severity", "severe")
+	assert.ErrorContains(t, err, "invalid --fail-on-severity")
+
+	t.Chdir(t.TempDir())
+	_, err = runRoot(t, "hook", "install", "pre-push")
+	assert.ErrorContains(t, err, "not a git repository")
+}
+
+func TestHookRunSkips(t *testing.T) {
+	t.Chdir(initGitRepo(t))
+	var stderr bytes.Buffer
+	root := newRootCmd()
+	root.SetErr(&stderr)
+
+	t.Setenv("HORDE_SKIP", "1")
+	root.SetArgs([]string{"hook", "run", "pre-push", "origin"})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stderr.String(), "HORDE_SKIP is set")
+
+	t.Setenv("HORDE_SKIP", "")
+	stderr.Reset()
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{"hook", "run", "pre-push", "origin"})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stderr.String(), "nothing to push")
+
+	// Deleting a remote branch has nothing to review.
+	zero := strings.Repeat("0", 40)
+	stderr.Reset()
+	root.SetIn(strings.NewReader("(delete) " + zero + " refs/heads/old " + strings.Repeat("1", 40) + "\n"))
+	root.SetArgs([]string{"hook", "run", "pre-push", "origin"})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stderr.String(), "push deletes refs/heads/old")
+}
+
+func TestHookRunReviewsNewBranch(t *testing.T) {
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not available")
+	}
+	cfg := config.NewDefaults()
+	cfg.Tools["echo"] = config.ToolConfig{Binary: cat, Adapter: "echo", Enabled: true, Stdin: true}
+	setupConfig(t, cfg)
+
+	dir := initGitRepo(t)
+	t.Chdir(dir)
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	os.WriteFile("a.txt", []byte("first line\n"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	head := git("rev-parse", "HEAD")
+
+	// The first push of a branch has no remote commit to compare with.
+	var stderr bytes.Buffer
+	root := newRootCmd()
+	root.SetErr(&stderr)
+	root.SetIn(strings.NewReader("refs/heads/topic " + head + " refs/heads/topic " + strings.Repeat("0", 40) + "\n"))
+	root.SetArgs([]string{"hook", "run", "pre-push", "origin", "--agents", "echo", "--block"})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stderr.String(), ".."+head+" for refs/heads/topic")
+	assert.Contains(t, stderr.String(), "1/1 agent(s) succeeded")
+
+	// The echo agent answers with its prompt, which holds the pushed diff.
+	runDir, err := resolveRunDir("latest", "")
+	if assert.NoError(t, err) {
+		data, _ := os.ReadFile(filepath.Join(runDir, "echo.md"))
+		assert.Contains(t, string(data), "+first line")
+	}
+}
+
+func TestHookRaidArgs(t *testing.T) {
+	opts := hookOptions{loadout: "fast", raider: "reviewer", failOnSev: "high", block: true}
+	assert.Equal(t, []string{
+		"raid", "--diff", "@{push}..HEAD", "--yes", "--loadout", "fast", "--raider", "reviewer",
+		"--findings", "--fail-on-severity", "high", "--fail-on", "any", "--", defaultHookPrompt,
+	}, opts.raidArgs("@{push}..HEAD"))
+}
+
+func TestPrintHookSummary(t *testing.T) {
+	dir := t.TempDir()
+	report := output.FindingsReport{Findings: []output.MergedFinding{
+		{Finding: output.Finding{Severity: "high", Title: "Unchecked error", File: "main.go", StartLine: 12}},
+		{Finding: output.Finding{Severity: "low", Title: "Naming"}},
+	}}
+	assert.NoError(t, output.WriteFindings(dir, &report))
+	m := &output.Manifest{
+		StartedAt:    time.Now(),
+		FindingsFile: output.FindingsFile,
+		Results: []output.ManifestResult{
+			{ToolID: "claude", Status: "success"},
+			{ToolID: "codex", Status: "failed"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printHookSummary(&buf, m, dir)
+	assert.Equal(t, "\nhorde: 1/2 agent(s) succeeded, findings: 1 high, 1 low\n"+
+		"  [high] main.go:12 Unchecked error\n"+
+		"  [low] Naming\n"+
+		"horde: full review: "+dir+"\n", buf.String())
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func newIndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Manage the run index used by summary, search and cleanup",
+	}
+	cmd.AddCommand(newIndexRebuildCmd())
+	return cmd
+}
+
+func newIndexRebuildCmd() *cobra.Command {
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:   "rebuild",
+		Short: "Reconstruct the run index from the run directories on disk",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := resolveOutputDir(outputDir)
+			if err != nil {
+				return err
+			}
+			n, err := output.RebuildIndex(baseDir)
+			if err != nil {
+				return fmt.Errorf("rebuilding index: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Indexed %d run(s) in %s\n", n, baseDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	return cmd
+}
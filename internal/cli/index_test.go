@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestIndexRebuildCmd(t *testing.T) {
+	base := t.TempDir()
+	setupRunDir(t, base, "run-111", time.Now())
+	setupRunDir(t, base, "run-222", time.Now())
+	os.Remove(filepath.Join(base, output.IndexFile))
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"index", "rebuild", "-o", base})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stdout.String(), "Indexed 2 run(s)")
+
+	entries, _, err := output.ReadIndex(base)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
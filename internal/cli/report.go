@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func newReportCmd() *cobra.Command {
+	var (
+		htmlOut   bool
+		outFile   string
+		outputDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report <run>",
+		Short: "Render a run as a shareable report",
+		Long: "Renders a run as a single self-contained HTML file with the prompt, each agent's response " +
+			"in tabs or side-by-side columns, status/duration/cost badges, failure diagnoses and the " +
+			"findings table. The file works offline and loads nothing from the network. " +
+			"<run> is a run directory, its name in the output directory, or \"latest\".",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !htmlOut {
+				return fmt.Errorf("specify a report format: --html")
+			}
+			runDir, err := resolveRunDir(args[0], outputDir)
+			if err != nil {
+				return err
+			}
+			m, err := output.ReadManifest(runDir)
+			if err != nil {
+				return fmt.Errorf("reading manifest: %w", err)
+			}
+			data, err := output.BuildHTMLReport(m, runDir)
+			if err != nil {
+				return err
+			}
+			if outFile == "" {
+				outFile = filepath.Join(runDir, output.ReportFile)
+			}
+			if err := output.AtomicWrite(outFile, data, 0o600); err != nil {
+				return fmt.Errorf("writing %s: %w", outFile, err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&htmlOut, "html", false, "Write a self-contained HTML report")
+	cmd.Flags().StringVarP(&outFile, "output", "o", "", "Report file (default: report.html in the run directory)")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Output directory (default: from config)")
+	return cmd
+}
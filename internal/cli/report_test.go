@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestReportCmdHTML(t *testing.T) {
+	base := t.TempDir()
+	runDir := setupRunDir(t, base, "review-run-111", time.Now())
+	m, err := output.ReadManifest(runDir)
+	assert.NoError(t, err)
+	m.Results = []output.ManifestResult{{ToolID: "claude", Status: "success", OutputFile: "claude.md"}}
+	assert.NoError(t, output.WriteManifest(runDir, m))
+	os.WriteFile(filepath.Join(runDir, "claude.md"), []byte("# Answer\n\nAll good."), 0o600)
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"report", "latest", "--html", "--output-dir", base})
+	assert.NoError(t, root.Execute())
+
+	path := strings.TrimSpace(stdout.String())
+	assert.Equal(t, filepath.Join(runDir, output.ReportFile), path)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<h1>Answer</h1>")
+}
+
+func TestReportCmdRequiresFormat(t *testing.T) {
+	root := newRootCmd()
+	root.SetArgs([]string{"report", "latest"})
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	err := root.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--html")
+}
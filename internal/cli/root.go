@@ -23,6 +23,16 @@ func newRootCmd() *cobra.Command {
 	root.AddCommand(newAgentCmd())
 	root.AddCommand(newExpertsCmd())
 	root.AddCommand(newTeamsCmd())
+	root.AddCommand(newSynthesizeCmd())
+	root.AddCommand(newExportCmd())
+	root.AddCommand(newReportCmd())
+	root.AddCommand(newDiffCmd())
+	root.AddCommand(newSearchCmd())
+	root.AddCommand(newIndexCmd())
+	root.AddCommand(newRunsCmd())
+	root.AddCommand(newCatCmd())
+	root.AddCommand(newHookCmd())
+	root.AddCommand(newTemplatesCmd())
 
 	// Top-level aliases
 	addCmd := newToolsAddCmd()
@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
@@ -24,6 +25,8 @@ import (
 	"github.com/codebeauty/horde/internal/gather"
 	"github.com/codebeauty/horde/internal/output"
 	"github.com/codebeauty/horde/internal/runner"
+	"github.com/codebeauty/horde/internal/secrets"
+	"github.com/codebeauty/horde/internal/templates"
 	"github.com/codebeauty/horde/internal/tui"
 	"github.com/codebeauty/horde/internal/ui"
 )
@@ -36,12 +39,36 @@ func newRunCmd() *cobra.Command {
 		timeout     int
 		outputDir   string
 		jsonOutput  bool
+		formatFlag  string
 		fileFlag    string
 		dryRun      bool
 		contextFlag string
+		diffFlag    string
+		commitFlag  string
+		sinceFlag   string
+		prBaseFlag  string
+		diffStat    bool
+		diffContext int
+		ctxTokens   int
+		ctxCmds     []string
+		ctxCmdTime  int
+		ctxSymbols  []string
+		tmplFlag    string
+		varFlags    []string
+		secretsFlag string
+		attachFlags []string
+		attachReq   bool
+		symbolDepth int
 		expertFlag  string
 		teamFlag    string
 		yesFlag     bool
+		synthFlag   string
+		findings    bool
+		tagFlags    []string
+		noteFlag    string
+		failOn      string
+		failOnSev   string
+		ciFlag      bool
 	)
 
 	cmd := &cobra.Command{
@@ -72,22 +99,110 @@ func newRunCmd() *cobra.Command {
 			if teamFlag != "" && expertFlag != "" {
 				return fmt.Errorf("--squad and --raider are mutually exclusive")
 			}
+			failOn, err = validateFailOn(failOn, failOnSev, ciFlag, findings)
+			if err != nil {
+				return err
+			}
+			if formatFlag != "" {
+				if jsonOutput {
+					return fmt.Errorf("--json and --format are mutually exclusive")
+				}
+				if err := output.ValidateResponseFormat(formatFlag); err != nil {
+					return err
+				}
+			}
+
+			if synthFlag != "" {
+				if _, ok := cfg.Tools[synthFlag]; !ok {
+					return fmt.Errorf("unknown synthesis agent: %q", synthFlag)
+				}
+			}
+
+			tags, err := output.NormalizeTags(tagFlags)
+			if err != nil {
+				return err
+			}
 
 			prompt, err := resolvePrompt(fileFlag, args)
 			if err != nil {
 				return err
 			}
 
-			if contextFlag != "" && fileFlag == "" {
+			meta := runMeta{SynthesizeWith: synthFlag, Findings: findings, Tags: tags, Note: noteFlag}
+			if attachReq && len(attachFlags) == 0 {
+				return fmt.Errorf("--attach-required requires --attach")
+			}
+			if meta.Attachments, err = resolveAttachments(attachFlags); err != nil {
+				return err
+			}
+			diffRange, err := resolveDiffRange(mustGetwd(), diffFlag, commitFlag, sinceFlag, prBaseFlag)
+			if err != nil {
+				return err
+			}
+			if diffContext < 0 {
+				return fmt.Errorf("--diff-context must not be negative")
+			}
+			if ctxCmdTime <= 0 {
+				return fmt.Errorf("--context-cmd-timeout must be positive")
+			}
+			if symbolDepth < 0 {
+				return fmt.Errorf("--context-symbol-depth must not be negative")
+			}
+			vars, err := templates.ParseVars(varFlags)
+			if err != nil {
+				return err
+			}
+			if len(vars) > 0 && tmplFlag == "" {
+				return fmt.Errorf("--var requires --template")
+			}
+			var tmpl *templates.Template
+			if tmplFlag != "" {
+				if tmpl, err = templates.Load(tmplFlag, mustGetwd()); err != nil {
+					return err
+				}
+			}
+			var gathered *gather.Result
+			if contextFlag != "" || diffRange != "" || len(ctxCmds) > 0 || len(ctxSymbols) > 0 {
 				var patterns []string
-				if contextFlag != "." {
+				if contextFlag != "" && contextFlag != "." {
 					patterns = strings.Split(contextFlag, ",")
 				}
-				ctx, err := gather.Gather(patterns, 50, mustGetwd())
+				spec := gather.DiffSpec{Range: diffRange, Stat: diffStat, ContextLines: diffContext}
+				budget := contextBudget(cfg, ctxTokens, toolsFlag, groupFlag)
+				var symbols []gather.Snippet
+				if len(ctxSymbols) > 0 {
+					if symbols, err = gather.FindSymbols(mustGetwd(), ctxSymbols, symbolDepth); err != nil {
+						return fmt.Errorf("--context-symbol: %w", err)
+					}
+				}
+				outputs := runContextCommands(ctxCmds, time.Duration(ctxCmdTime)*time.Second, dryRun)
+				gathered, err = gather.GatherRange(patterns, budget, mustGetwd(), spec, outputs, symbols)
 				if err != nil {
 					return fmt.Errorf("gathering context: %w", err)
 				}
-				prompt = gather.BuildPrompt(prompt, ctx)
+				reportContext(gathered)
+				meta.ContextReport = contextReport(gathered)
+				meta.ContextSources = contextSources(patterns, diffRange, ctxCmds, ctxSymbols)
+			}
+			if tmpl != nil || gathered != nil {
+				if prompt, err = applyTemplate(tmpl, prompt, vars, gathered); err != nil {
+					return err
+				}
+			}
+
+			if findings {
+				prompt += "\n\n" + output.FindingsInstructions
+			}
+
+			policy := cfg.Defaults.SecretPolicy
+			if secretsFlag != "" {
+				policy = config.SecretPolicy(secretsFlag)
+			}
+			if policy, err = config.ValidateSecretPolicy(string(policy)); err != nil {
+				return err
+			}
+			if prompt, meta.Secrets, err = scanPrompt(prompt, policy); err != nil {
+				return err
 			}
 
 			if outputDir != "" {
@@ -95,6 +210,7 @@ func newRunCmd() *cobra.Command {
 			}
 			if timeout > 0 {
 				cfg.Defaults.Timeout = timeout
+				meta.TimeoutFlag = true
 			}
 
 			ro := config.ReadOnlyMode(cfg.Defaults.ReadOnly)
@@ -107,7 +223,8 @@ func newRunCmd() *cobra.Command {
 			}
 
 			// --- TUI path: interactive terminal with alt-screen ---
-			if shouldUseTUI(jsonOutput, dryRun) {
+			gated := ciFlag || failOn != "none" || failOnSev != ""
+			if shouldUseTUI(jsonOutput || formatFlag != "" || gated, dryRun) {
 				toolIDs, preSelected, err := resolveToolIDsForTUI(cfg, toolsFlag, groupFlag)
 				if err != nil {
 					return err
@@ -115,6 +232,11 @@ func newRunCmd() *cobra.Command {
 				if len(toolIDs) == 0 {
 					return fmt.Errorf("no agents configured — run 'horde wake' to set up agents")
 				}
+				if len(meta.Attachments) > 0 {
+					if toolIDs, err = filterAttachable(cfg, toolIDs, attachReq); err != nil {
+						return err
+					}
+				}
 				if teamFlag != "" {
 					teamExperts, err := lookupTeam(cfg, teamFlag)
 					if err != nil {
@@ -128,7 +250,7 @@ func newRunCmd() *cobra.Command {
 				} else {
 					toolIDs = expandDuplicateToolIDs(toolIDs, cfg)
 				}
-				return runTUI(cfg, prompt, toolIDs, ro, expertFlag, teamFlag, preSelected)
+				return runTUI(cfg, prompt, toolIDs, ro, expertFlag, teamFlag, preSelected, meta)
 			}
 
 			// --- Non-TUI path: JSON, dry-run, piped, or non-interactive ---
@@ -147,6 +269,11 @@ func newRunCmd() *cobra.Command {
 					return err
 				}
 			}
+			if len(meta.Attachments) > 0 {
+				if toolIDs, err = filterAttachable(cfg, toolIDs, attachReq); err != nil {
+					return err
+				}
+			}
 
 			if teamFlag != "" {
 				teamExperts, err := lookupTeam(cfg, teamFlag)
@@ -180,24 +307,25 @@ func newRunCmd() *cobra.Command {
 			if dryRun {
 				var dryExpertIDs []string
 				if teamFlag != "" {
-					eids, _, dryErr := resolveTeamExperts(toolIDs, raider.Dir())
+					eids, _, _, dryErr := resolveTeamExperts(toolIDs, raider.Dir())
 					if dryErr != nil {
 						fmt.Fprintf(os.Stderr, "warning: %v\n", dryErr)
 					}
 					dryExpertIDs = eids
 				} else {
-					eids, _, dryErr := resolveToolExperts(tools, cfg, expertFlag)
+					eids, _, _, dryErr := resolveToolExperts(tools, cfg, expertFlag)
 					if dryErr != nil {
 						fmt.Fprintf(os.Stderr, "warning: %v\n", dryErr)
 					}
 					dryExpertIDs = eids
 				}
 				params := adapter.RunParams{
-					Prompt:     prompt,
-					PromptFile: "<output>/prompt.md",
-					WorkDir:    mustGetwd(),
-					ReadOnly:   adapter.ReadOnlyMode(ro),
-					Timeout:    time.Duration(cfg.Defaults.Timeout) * time.Second,
+					Prompt:      prompt,
+					PromptFile:  "<output>/prompt.md",
+					WorkDir:     mustGetwd(),
+					ReadOnly:    adapter.ReadOnlyMode(ro),
+					Timeout:     time.Duration(cfg.Defaults.Timeout) * time.Second,
+					Attachments: meta.Attachments,
 				}
 				for i, tool := range tools {
 					inv := tool.Adapter.BuildInvocation(params)
@@ -216,11 +344,24 @@ func newRunCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			defer output.UnlockRun(runDir)
 			promptFilePath := filepath.Join(runDir, "prompt.md")
-			if err := output.WritePrompt(runDir, prompt); err != nil {
+			var attachments []string
+			if meta.Attached, attachments, err = copyAttachments(runDir, meta.Attachments); err != nil {
+				return err
+			}
+			if err := output.WritePrompt(runDir, prompt+adapter.AttachmentInstruction(attachments)); err != nil {
 				return fmt.Errorf("writing prompt: %w", err)
 			}
 
+			expertIDs, expertContents, expertMetas, err := resolveExperts(tools, toolIDs, cfg, expertFlag, teamFlag)
+			if err != nil {
+				return err
+			}
+			for _, w := range raiderWarnings(expertIDs, expertMetas) {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+			}
+
 			fmt.Fprintf(os.Stderr, "Deploying to %d agent(s): %s\n", len(tools), strings.Join(toolIDs, ", "))
 			fmt.Fprintf(os.Stderr, "Output: %s\n", runDir)
 
@@ -231,6 +372,9 @@ func newRunCmd() *cobra.Command {
 			r := runner.New(cfg.Defaults.MaxParallel)
 
 			prog := ui.NewProgress(toolIDs)
+			if ciFlag {
+				prog = ui.NewLogProgress(toolIDs)
+			}
 			r.SetProgressFunc(func(toolID, event string, result *runner.Result) {
 				switch event {
 				case "started":
@@ -245,23 +389,22 @@ func newRunCmd() *cobra.Command {
 			prog.Start()
 			defer prog.Stop()
 
-			expertIDs, expertContents, err := resolveExperts(tools, toolIDs, cfg, expertFlag, teamFlag)
-			if err != nil {
-				return err
-			}
-
 			baseParams := adapter.RunParams{
-				Prompt:     prompt,
-				PromptFile: promptFilePath,
-				WorkDir:    mustGetwd(),
-				ReadOnly:   adapter.ReadOnlyMode(ro),
-				Timeout:    time.Duration(cfg.Defaults.Timeout) * time.Second,
+				Prompt:      prompt,
+				PromptFile:  promptFilePath,
+				WorkDir:     mustGetwd(),
+				ReadOnly:    adapter.ReadOnlyMode(ro),
+				Timeout:     time.Duration(cfg.Defaults.Timeout) * time.Second,
+				Attachments: attachments,
 			}
 
 			perToolParams, err := buildExpertParams(tools, expertContents, baseParams, prompt, runDir)
 			if err != nil {
 				return err
 			}
+			if !meta.TimeoutFlag && perToolParams != nil {
+				applyRaiderTimeouts(perToolParams, expertMetas)
+			}
 
 			var results []runner.Result
 			if perToolParams == nil {
@@ -270,12 +413,38 @@ func newRunCmd() *cobra.Command {
 				results = r.RunWithParams(ctx, tools, perToolParams, runDir)
 			}
 
-			manifest := writeManifestAndSummary(runDir, prompt, startedAt, results, expertIDs, cfg, ro)
+			meta.ExpertIDs, meta.ExpertContents = expertIDs, expertContents
+			manifest := writeManifestAndSummary(runDir, prompt, startedAt, results, cfg, ro, meta)
+			if synthFlag != "" {
+				prog.Stop()
+				fmt.Fprintf(os.Stderr, "Synthesizing with %s...\n", synthFlag)
+				if err := synthesizeRun(ctx, cfg, runDir, manifest, synthFlag, ro); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				}
+			}
+			if n := autoPrune(cfg, runDir); n > 0 && !jsonOutput {
+				fmt.Fprintf(os.Stderr, "Pruned %d old run(s)\n", n)
+			}
+
+			var report *output.FindingsReport
+			if manifest.FindingsFile != "" {
+				report, _ = output.ReadFindings(runDir)
+			}
+			if ciFlag {
+				writeCIReports(manifest, runDir, report)
+			}
+			gateErr := checkFailOn(manifest, failOn, report, failOnSev)
+			if gateErr != nil {
+				cmd.SilenceUsage = true
+			}
 
 			if jsonOutput {
 				enc := json.NewEncoder(os.Stdout)
 				enc.SetIndent("", "  ")
-				return enc.Encode(manifest)
+				if err := enc.Encode(manifest); err != nil {
+					return err
+				}
+				return gateErr
 			}
 
 			// Rich summary for TTY, plain for non-TTY
@@ -284,7 +453,16 @@ func newRunCmd() *cobra.Command {
 			} else {
 				printSummary(results, runDir)
 			}
-			return nil
+			if formatFlag != "" {
+				responses, err := output.LoadResponses(manifest, runDir, nil)
+				if err != nil {
+					return err
+				}
+				if err := output.WriteResponses(os.Stdout, formatFlag, manifest, runDir, responses); err != nil {
+					return err
+				}
+			}
+			return gateErr
 		},
 	}
 
@@ -294,12 +472,36 @@ func newRunCmd() *cobra.Command {
 	cmd.Flags().IntVar(&timeout, "timeout", 0, "Per-agent timeout in seconds")
 	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "Output directory override")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output manifest as JSON")
+	cmd.Flags().StringVar(&formatFlag, "format", "", "Print responses to stdout: text, markdown, json, jsonl")
 	cmd.Flags().StringVarP(&fileFlag, "file", "f", "", "Read prompt from file")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show invocations without executing")
 	cmd.Flags().StringVarP(&contextFlag, "context", "c", "", "Gather context from paths (comma-separated, or \".\" for git diff)")
+	cmd.Flags().StringVar(&diffFlag, "diff", "", "Gather the git diff and log of a commit range (e.g. main...HEAD) instead of working tree changes")
+	cmd.Flags().StringVar(&commitFlag, "commit", "", "Gather the diff and message of a single commit")
+	cmd.Flags().StringVar(&sinceFlag, "since", "", "Gather the diff and log of the commits after a ref (<ref>..HEAD)")
+	cmd.Flags().StringVar(&prBaseFlag, "pr-base", "", "Gather what a pull request into a branch would contain (<branch>...HEAD)")
+	cmd.Flags().BoolVar(&diffStat, "diff-stat", false, "Include a diffstat before the git diff")
+	cmd.Flags().IntVar(&ctxTokens, "context-tokens", 0, "Token budget for gathered context (default: from config, else the smallest agent budget)")
+	cmd.Flags().StringVar(&tmplFlag, "template", "", "Prompt template to fill with the prompt and context (see 'horde templates list')")
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "Template variable as key=value (repeatable)")
+	cmd.Flags().StringVar(&secretsFlag, "secrets", "", "What to do with secrets found in the prompt: block, redact, warn (default: redact)")
+	cmd.Flags().StringArrayVar(&attachFlags, "attach", nil, "Send a file, such as a screenshot or diagram, with the prompt (repeatable)")
+	cmd.Flags().BoolVar(&attachReq, "attach-required", false, "Skip agents that cannot take attachments instead of sending them the prompt alone")
+	cmd.Flags().StringArrayVar(&ctxSymbols, "context-symbol", nil, "Add a Go declaration to the context, e.g. internal/runner.Runner.Run (repeatable)")
+	cmd.Flags().IntVar(&symbolDepth, "context-symbol-depth", 0, "Also add declarations up to this many references away from each --context-symbol")
+	cmd.Flags().StringArrayVar(&ctxCmds, "context-cmd", nil, "Run a shell command and add its output to the context (repeatable)")
+	cmd.Flags().IntVar(&ctxCmdTime, "context-cmd-timeout", 60, "Timeout in seconds for each --context-cmd")
+	cmd.Flags().IntVar(&diffContext, "diff-context", 0, "Lines of context around each change in the git diff (default: git's, usually 3)")
 	cmd.Flags().StringVarP(&expertFlag, "raider", "R", "", "Raider ID to apply to all agents")
 	cmd.Flags().StringVarP(&teamFlag, "squad", "S", "", "Named squad of raiders from config")
 	cmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip confirmation prompts")
+	cmd.Flags().StringVar(&synthFlag, "synthesize", "", "Agent ID that merges all responses into synthesis.md")
+	cmd.Flags().BoolVar(&findings, "findings", false, "Ask agents for structured findings and merge them into findings.json")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "Exit non-zero when agents fail: any, all, none (default none, any with --ci)")
+	cmd.Flags().StringVar(&failOnSev, "fail-on-severity", "", "Exit with status 2 when a finding is at or above this severity (requires --findings)")
+	cmd.Flags().BoolVar(&ciFlag, "ci", false, "CI mode: plain timestamped progress, GitHub Actions annotations and junit.xml")
+	cmd.Flags().StringArrayVar(&tagFlags, "tag", nil, "Tag the run (repeatable)")
+	cmd.Flags().StringVar(&noteFlag, "note", "", "Attach a note to the run")
 
 	// Hidden backward-compat aliases (old flag names, no short flags)
 	cmd.Flags().String("tools", "", "")
@@ -435,6 +637,32 @@ func resolveOutputDir(flagValue string) (string, error) {
 	return cfg.Defaults.OutputDir, nil
 }
 
+// resolveRunDir turns a run reference into a run directory. ref may be
+// "latest", a path to a run directory, or a run directory name inside the
+// output directory.
+func resolveRunDir(ref, outputDirFlag string) (string, error) {
+	baseDir, err := resolveOutputDir(outputDirFlag)
+	if err != nil {
+		return "", err
+	}
+	if ref == "latest" {
+		runs, err := output.ScanRuns(baseDir)
+		if err != nil {
+			return "", err
+		}
+		if len(runs) == 0 {
+			return "", fmt.Errorf("no runs found in %s", baseDir)
+		}
+		return runs[0].Path, nil
+	}
+	for _, dir := range []string{ref, filepath.Join(baseDir, ref)} {
+		if _, err := os.Stat(filepath.Join(dir, "run.json")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("run %q not found (no run.json in %s or %s)", ref, ref, filepath.Join(baseDir, ref))
+}
+
 func selectToolsInteractive(toolIDs []string) ([]string, error) {
 	sort.Strings(toolIDs)
 	fmt.Fprintln(os.Stderr, "Available tools:")
@@ -538,10 +766,11 @@ func stderrSnippet(stderr []byte) string {
 	return s
 }
 
-func resolveToolExperts(tools []runner.Tool, cfg *config.Config, expertFlag string) (ids []string, contents []string, err error) {
+func resolveToolExperts(tools []runner.Tool, cfg *config.Config, expertFlag string) (ids []string, contents []string, metas []raider.Meta, err error) {
 	expertDir := raider.Dir()
 	ids = make([]string, len(tools))
 	contents = make([]string, len(tools))
+	metas = make([]raider.Meta, len(tools))
 
 	for i, tool := range tools {
 		eid := expertFlag // CLI flag wins
@@ -553,17 +782,18 @@ func resolveToolExperts(tools []runner.Tool, cfg *config.Config, expertFlag stri
 		if eid == "" {
 			continue
 		}
-		content, err := raider.Load(eid, expertDir)
+		r, err := raider.Read(eid, expertDir)
 		if err != nil {
-			return nil, nil, fmt.Errorf("loading expert %q for %s: %w", eid, tool.ID, err)
+			return nil, nil, nil, fmt.Errorf("loading expert %q for %s: %w", eid, tool.ID, err)
 		}
 		ids[i] = eid
-		contents[i] = content
+		contents[i] = r.Body
+		metas[i] = r.Meta
 	}
-	return ids, contents, nil
+	return ids, contents, metas, nil
 }
 
-func resolveExperts(tools []runner.Tool, toolIDs []string, cfg *config.Config, expertFlag, teamFlag string) (ids []string, contents []string, err error) {
+func resolveExperts(tools []runner.Tool, toolIDs []string, cfg *config.Config, expertFlag, teamFlag string) (ids []string, contents []string, metas []raider.Meta, err error) {
 	if teamFlag != "" {
 		return resolveTeamExperts(toolIDs, raider.Dir())
 	}
@@ -588,7 +818,7 @@ func buildExpertParams(tools []runner.Tool, expertContents []string, baseParams
 		if expertContents[i] != "" {
 			p.Prompt = raider.Inject(expertContents[i], prompt)
 			toolPromptPath := filepath.Join(runDir, tool.ID+".prompt.md")
-			if err := os.WriteFile(toolPromptPath, []byte(p.Prompt), 0o600); err != nil {
+			if err := os.WriteFile(toolPromptPath, []byte(p.Prompt+adapter.AttachmentInstruction(p.Attachments)), 0o600); err != nil {
 				return nil, fmt.Errorf("writing expert prompt for %s: %w", tool.ID, err)
 			}
 			p.PromptFile = toolPromptPath
@@ -598,19 +828,293 @@ func buildExpertParams(tools []runner.Tool, expertContents []string, baseParams
 	return params, nil
 }
 
-func writeManifestAndSummary(runDir, prompt string, startedAt time.Time, results []runner.Result, expertIDs []string, cfg *config.Config, ro config.ReadOnlyMode) *output.Manifest {
+// applyRaiderTimeouts gives each agent the timeout its raider suggests. It
+// is used when --timeout is not given.
+func applyRaiderTimeouts(params []adapter.RunParams, metas []raider.Meta) {
+	for i := range params {
+		if t := metas[i].Timeout; t > 0 {
+			params[i].Timeout = time.Duration(t) * time.Second
+		}
+	}
+}
+
+// raiderWarnings lists, once per raider, the raiders that need a newer
+// horde than this one.
+func raiderWarnings(ids []string, metas []raider.Meta) []string {
+	var warnings []string
+	seen := make(map[string]bool)
+	for i, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if p := metas[i].Requires(version); p != "" {
+			warnings = append(warnings, fmt.Sprintf("raider %q %s", id, p))
+		}
+	}
+	return warnings
+}
+
+// runMeta carries per-raid provenance that is recorded in the manifest.
+type runMeta struct {
+	ExpertIDs      []string
+	ExpertContents []string
+	ContextSources []string
+	ContextReport  *output.ContextReport
+	Attachments    []string            // absolute paths given to --attach
+	Attached       []output.Attachment // their copies in the run directory
+	Secrets        *output.SecretsReport
+	SynthesizeWith string // agent ID for the optional synthesis step
+	TimeoutFlag    bool   // --timeout was given and overrides raider timeouts
+	Findings       bool   // agents were asked for a structured findings block
+	Tags           []string
+	Note           string
+}
+
+// resolveDiffRange turns the git range flags into a single commit range
+// for gather.DiffSpec. At most one of them may be set; an empty range means
+// working tree changes.
+func resolveDiffRange(workDir, diff, commit, since, prBase string) (string, error) {
+	set := 0
+	for _, v := range []string{diff, commit, since, prBase} {
+		if v != "" {
+			set++
+		}
+		if strings.HasPrefix(v, "-") {
+			return "", fmt.Errorf("invalid git revision %q", v)
+		}
+	}
+	if set > 1 {
+		return "", fmt.Errorf("--diff, --commit, --since and --pr-base are mutually exclusive")
+	}
+
+	switch {
+	case diff != "":
+		if err := gather.VerifyRange(workDir, diff); err != nil {
+			return "", fmt.Errorf("--diff: %w", err)
+		}
+		return diff, nil
+	case commit != "":
+		if err := gather.VerifyRef(workDir, commit); err != nil {
+			return "", err
+		}
+		return commit + "^!", nil
+	case since != "":
+		if err := gather.VerifyRef(workDir, since); err != nil {
+			return "", err
+		}
+		return since + "..HEAD", nil
+	case prBase != "":
+		// Fall back to the remote-tracking branch when the base only
+		// exists on origin, as in fresh CI checkouts.
+		if err := gather.VerifyRef(workDir, prBase); err != nil {
+			if gather.VerifyRef(workDir, "origin/"+prBase) != nil {
+				return "", err
+			}
+			prBase = "origin/" + prBase
+		}
+		return prBase + "...HEAD", nil
+	}
+	return "", nil
+}
+
+// contextBudget returns the token budget for gathered context: the
+// --context-tokens flag, else defaults.contextTokens, else the smallest
+// budget among the agents that may run. An agent's budget is its
+// contextTokens setting or the estimate for its adapter and model.
+func contextBudget(cfg *config.Config, flagTokens int, toolsFlag, groupFlag string) int {
+	if flagTokens > 0 {
+		return flagTokens
+	}
+	if cfg.Defaults.ContextTokens > 0 {
+		return cfg.Defaults.ContextTokens
+	}
+	ids, err := resolveTools(cfg, toolsFlag, groupFlag)
+	if err != nil || len(ids) == 0 {
+		return adapter.DefaultContextBudget
+	}
+	budget := 0
+	for _, id := range ids {
+		tc, ok := cfg.Tools[id]
+		if !ok {
+			continue
+		}
+		n := tc.ContextTokens
+		if n <= 0 {
+			n = adapter.ContextBudget(tc.Adapter, adapter.ModelFromFlags(tc.ExtraFlags))
+		}
+		if budget == 0 || n < budget {
+			budget = n
+		}
+	}
+	if budget == 0 {
+		return adapter.DefaultContextBudget
+	}
+	return budget
+}
+
+// reportContext tells the user which context was cut or left out, and
+// which passages look like prompt injection.
+func reportContext(res *gather.Result) {
+	if len(res.Suspects) > 0 {
+		fmt.Fprintf(os.Stderr, "Context: %d passage(s) look like instructions to the agents (possible prompt injection):\n", len(res.Suspects))
+		for _, s := range res.Suspects {
+			fmt.Fprintf(os.Stderr, "  %s:%d (%s): %s\n", s.Source, s.Line, s.Rule, s.Excerpt)
+		}
+	}
+	if len(res.Truncated) == 0 && len(res.Dropped) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Context: ~%d of %d tokens, %d file(s) included, %d truncated, %d dropped:\n",
+		res.UsedTokens, res.BudgetTokens, len(res.Included), len(res.Truncated), len(res.Dropped))
+	for _, t := range res.Truncated {
+		fmt.Fprintf(os.Stderr, "  %s: truncated, %s\n", t.Path, t.Reason)
+	}
+	for _, d := range res.Dropped {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", d.Path, d.Reason)
+	}
+}
+
+// contextReport converts a gather result for the manifest.
+func contextReport(res *gather.Result) *output.ContextReport {
+	r := &output.ContextReport{BudgetTokens: res.BudgetTokens, UsedTokens: res.UsedTokens, Included: res.Included}
+	for _, t := range res.Truncated {
+		r.Truncated = append(r.Truncated, output.ContextItem{Path: t.Path, Reason: t.Reason})
+	}
+	for _, d := range res.Dropped {
+		r.Dropped = append(r.Dropped, output.ContextItem{Path: d.Path, Reason: d.Reason})
+	}
+	for _, s := range res.Suspects {
+		r.Suspicious = append(r.Suspicious, output.SuspiciousItem{Source: s.Source, Line: s.Line, Rule: s.Rule, Excerpt: s.Excerpt})
+	}
+	return r
+}
+
+// contextSources describes what --context gathered, for the manifest.
+func contextSources(patterns []string, diffRange string, cmds, symbols []string) []string {
+	var sources []string
+	for _, s := range symbols {
+		sources = append(sources, "symbol "+s)
+	}
+	sources = append(sources, patterns...)
+	if diffRange == "" {
+		sources = append(sources, "git diff")
+	} else {
+		sources = append(sources, "git log "+diffRange, "git diff "+diffRange)
+	}
+	for _, c := range cmds {
+		sources = append(sources, "$ "+c)
+	}
+	return sources
+}
+
+// scanPrompt looks for secrets in the prompt, including the values of the
+// agent credentials in the environment, and applies policy: block fails,
+// redact replaces them with placeholders and warn only reports them. It
+// returns the prompt to send and a report, nil when nothing was found.
+func scanPrompt(prompt string, policy config.SecretPolicy) (string, *output.SecretsReport, error) {
+	rules := append(secrets.EnvRules(os.Environ()), secrets.Rules...)
+	matches := secrets.FindRules(prompt, rules)
+	if len(matches) == 0 {
+		return prompt, nil, nil
+	}
+	report := &output.SecretsReport{Policy: string(policy), Matches: matches}
+	fmt.Fprintf(os.Stderr, "Secrets: %d possible secret(s) in the prompt (policy: %s):\n", len(matches), policy)
+	for _, m := range matches {
+		fmt.Fprintf(os.Stderr, "  line %d: %s\n", m.Line, m.Rule)
+	}
+	switch policy {
+	case config.SecretsBlock:
+		return "", report, fmt.Errorf("prompt contains %d possible secret(s); remove them or rerun with --secrets redact", len(matches))
+	case config.SecretsRedact:
+		report.Redacted = len(matches)
+		return secrets.RedactMatches(prompt, matches), report, nil
+	}
+	return prompt, report, nil
+}
+
+// applyTemplate fills tmpl, or the default template when nil, with the
+// prompt, the gathered context (if any) and vars.
+func applyTemplate(tmpl *templates.Template, prompt string, vars map[string]string, gathered *gather.Result) (string, error) {
+	if tmpl == nil {
+		var err error
+		if tmpl, err = templates.Load("default", mustGetwd()); err != nil {
+			return "", err
+		}
+	}
+	data := templates.Data{Prompt: prompt, Vars: vars}
+	if gathered != nil {
+		data.Context = gathered.Context
+		data.Files = gathered.Included
+	}
+	if state, ok := gather.GitState(mustGetwd()); ok {
+		data.GitBranch = state.Branch
+	}
+	return templates.Render(tmpl, data)
+}
+
+// runContextCommands runs each --context-cmd in the working directory,
+// reporting progress on stderr. With dryRun the commands are only listed.
+func runContextCommands(cmds []string, timeout time.Duration, dryRun bool) []gather.CommandOutput {
+	var outputs []gather.CommandOutput
+	for _, c := range cmds {
+		if dryRun {
+			fmt.Fprintf(os.Stderr, "Context command (not run in dry run): %s\n", c)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Running context command: %s\n", c)
+		out := gather.RunCommand(context.Background(), mustGetwd(), c, timeout)
+		if out.TimedOut {
+			fmt.Fprintf(os.Stderr, "  timed out after %s\n", timeout)
+		} else {
+			fmt.Fprintf(os.Stderr, "  exit %d in %s\n", out.ExitCode, out.Duration.Round(time.Millisecond))
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs
+}
+
+func writeManifestAndSummary(runDir, prompt string, startedAt time.Time, results []runner.Result, cfg *config.Config, ro config.ReadOnlyMode, meta runMeta) *output.Manifest {
 	manifest := output.BuildManifest(prompt, startedAt, results, output.ManifestConfig{
 		ReadOnly:    string(ro),
 		Timeout:     cfg.Defaults.Timeout,
 		MaxParallel: cfg.Defaults.MaxParallel,
 	})
-	for i, eid := range expertIDs {
+	manifest.HordeVersion = version
+	manifest.Context = meta.ContextSources
+	manifest.Attachments = meta.Attached
+	manifest.ContextReport = meta.ContextReport
+	manifest.Secrets = meta.Secrets
+	manifest.Tags = meta.Tags
+	manifest.Note = meta.Note
+	if state, ok := gather.GitState(mustGetwd()); ok {
+		manifest.Git = &output.GitInfo{Head: state.Head, Branch: state.Branch, Dirty: state.Dirty}
+	}
+	for i, eid := range meta.ExpertIDs {
 		if eid != "" && i < len(manifest.Results) {
 			manifest.Results[i].Expert = eid
+			manifest.Results[i].ExpertSHA256 = output.SHA256Hex(meta.ExpertContents[i])
+		}
+	}
+	versions := collectAgentVersions(cfg, results)
+	for i := range manifest.Results {
+		mr := &manifest.Results[i]
+		if tc, ok := cfg.Tools[mr.ToolID]; ok {
+			mr.Adapter = tc.Adapter
+			mr.Model = adapter.ModelFromFlags(tc.ExtraFlags)
+			mr.AgentVersion = versions[tc.Binary]
+		}
+	}
+	if meta.Findings {
+		report := output.CollectFindings(manifest, runDir)
+		if err := output.WriteFindings(runDir, report); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to write findings: %v\n", err)
+		} else {
+			manifest.FindingsFile = output.FindingsFile
 		}
 	}
 	if err := output.WriteManifest(runDir, manifest); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to write manifest: %v\n", err)
+		fmt.Fprintf(os.Stderr, "warning: run manifest: %v\n", err)
 	}
 	summary := output.BuildSummary(manifest, runDir)
 	if err := output.WriteSummary(runDir, summary); err != nil {
@@ -618,3 +1122,34 @@ func writeManifestAndSummary(runDir, prompt string, startedAt time.Time, results
 	}
 	return manifest
 }
+
+// collectAgentVersions returns "--version" output keyed by configured binary,
+// querying each distinct binary once and in parallel.
+func collectAgentVersions(cfg *config.Config, results []runner.Result) map[string]string {
+	versions := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]bool)
+	for _, r := range results {
+		tc, ok := cfg.Tools[r.ToolID]
+		if !ok || seen[tc.Binary] {
+			continue
+		}
+		seen[tc.Binary] = true
+		wg.Add(1)
+		go func(binary string) {
+			defer wg.Done()
+			binPath := findBinary(binary)
+			if binPath == "" {
+				return
+			}
+			if v, err := agentVersion(binPath); err == nil {
+				mu.Lock()
+				versions[binary] = v
+				mu.Unlock()
+			}
+		}(tc.Binary)
+	}
+	wg.Wait()
+	return versions
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/adapter"
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/raider"
+)
+
+func TestResolveDiffRange(t *testing.T) {
+	dir := initGitRepo(t)
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o600))
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	git("branch", "base")
+	git("update-ref", "refs/remotes/origin/release", "HEAD")
+
+	tests := []struct {
+		name                        string
+		diff, commit, since, prBase string
+		want, wantErr               string
+	}{
+		{name: "none"},
+		{name: "diff", diff: "base...HEAD", want: "base...HEAD"},
+		{name: "diff open end", diff: "base..", want: "base.."},
+		{name: "diff single commit", diff: "HEAD^!", want: "HEAD^!"},
+		{name: "diff typo", diff: "mian...HEAD", wantErr: `--diff: unknown git revision "mian"`},
+		{name: "diff typo on the right", diff: "base..HAED", wantErr: `--diff: unknown git revision "HAED"`},
+		{name: "commit", commit: "HEAD", want: "HEAD^!"},
+		{name: "since", since: "base", want: "base..HEAD"},
+		{name: "pr base", prBase: "base", want: "base...HEAD"},
+		{name: "pr base on origin", prBase: "release", want: "origin/release...HEAD"},
+		{name: "unknown ref", since: "nope", wantErr: `unknown git revision "nope"`},
+		{name: "option injection", diff: "--output=/tmp/x", wantErr: "invalid git revision"},
+		{name: "exclusive", commit: "HEAD", since: "base", wantErr: "mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDiffRange(dir, tt.diff, tt.commit, tt.since, tt.prBase)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	cfg := config.NewDefaults()
+	cfg.Tools["claude-haiku"] = config.ToolConfig{Adapter: "claude", Enabled: true, ExtraFlags: []string{"--model", "haiku"}}
+	cfg.Tools["gemini"] = config.ToolConfig{Adapter: "gemini", Enabled: true}
+	cfg.Tools["mine"] = config.ToolConfig{Adapter: "custom", Enabled: true, ContextTokens: 90000}
+	cfg.Groups["big"] = []string{"gemini", "mine"}
+
+	assert.Equal(t, 16000, contextBudget(cfg, 0, "", ""), "smallest budget of all enabled agents")
+	assert.Equal(t, 64000, contextBudget(cfg, 0, "", "big"))
+	assert.Equal(t, 90000, contextBudget(cfg, 0, "mine", ""))
+	assert.Equal(t, adapter.DefaultContextBudget, contextBudget(cfg, 0, "unknown", ""))
+
+	cfg.Defaults.ContextTokens = 8000
+	assert.Equal(t, 8000, contextBudget(cfg, 0, "gemini", ""))
+	assert.Equal(t, 5000, contextBudget(cfg, 5000, "gemini", ""))
+}
+
+func TestScanPrompt(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "my-local-anthropic-credential")
+	prompt := "Why does this fail?\nDB_PASSWORD=hunter2hunter2\nkey: my-local-anthropic-credential\n"
+
+	got, report, err := scanPrompt(prompt, config.SecretsRedact)
+	assert.NoError(t, err)
+	assert.Equal(t, "Why does this fail?\nDB_PASSWORD=[REDACTED:assignment]\nkey: [REDACTED:env:ANTHROPIC_API_KEY]\n", got)
+	if assert.NotNil(t, report) {
+		assert.Equal(t, "redact", report.Policy)
+		assert.Equal(t, 2, report.Redacted)
+		assert.Equal(t, 3, report.Matches[1].Line)
+	}
+
+	got, report, err = scanPrompt(prompt, config.SecretsWarn)
+	assert.NoError(t, err)
+	assert.Equal(t, prompt, got)
+	assert.Zero(t, report.Redacted)
+	assert.Len(t, report.Matches, 2)
+
+	_, _, err = scanPrompt(prompt, config.SecretsBlock)
+	assert.EqualError(t, err, "prompt contains 2 possible secret(s); remove them or rerun with --secrets redact")
+
+	got, report, err = scanPrompt("nothing to see", config.SecretsBlock)
+	assert.NoError(t, err)
+	assert.Equal(t, "nothing to see", got)
+	assert.Nil(t, report)
+}
+
+func TestApplyRaiderTimeouts(t *testing.T) {
+	params := []adapter.RunParams{{Timeout: 540 * time.Second}, {Timeout: 540 * time.Second}}
+	applyRaiderTimeouts(params, []raider.Meta{{Timeout: 900}, {}})
+	assert.Equal(t, 900*time.Second, params[0].Timeout)
+	assert.Equal(t, 540*time.Second, params[1].Timeout, "raiders without a timeout keep the default")
+}
+
+func TestRaiderWarnings(t *testing.T) {
+	old := version
+	t.Cleanup(func() { version = old })
+	version = "1.2.0"
+
+	ids := []string{"security", "", "security", "golang"}
+	metas := []raider.Meta{{MinHordeVersion: "1.5.0"}, {}, {MinHordeVersion: "1.5.0"}, {MinHordeVersion: "1.0"}}
+	assert.Equal(t, []string{`raider "security" requires horde 1.5.0 or newer (this is 1.2.0)`}, raiderWarnings(ids, metas))
+
+	version = "dev"
+	assert.Empty(t, raiderWarnings(ids, metas))
+}
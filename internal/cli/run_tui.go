@@ -18,7 +18,7 @@ import (
 	"github.com/codebeauty/horde/internal/tui"
 )
 
-func runTUI(cfg *config.Config, prompt string, toolIDs []string, ro config.ReadOnlyMode, expertFlag, teamFlag string, preSelected bool) error {
+func runTUI(cfg *config.Config, prompt string, toolIDs []string, ro config.ReadOnlyMode, expertFlag, teamFlag string, preSelected bool, meta runMeta) error {
 	adapters := make(map[string]string, len(toolIDs))
 	for _, id := range toolIDs {
 		if tc, ok := cfg.Tools[id]; ok {
@@ -56,7 +56,7 @@ func runTUI(cfg *config.Config, prompt string, toolIDs []string, ro config.ReadO
 	var program *tea.Program
 
 	dispatch := func(ctx context.Context, selectedToolIDs []string, selectedExpert string) {
-		err := executeTUIRun(ctx, program, cfg, prompt, selectedToolIDs, ro, selectedExpert, teamFlag)
+		err := executeTUIRun(ctx, program, cfg, prompt, selectedToolIDs, ro, selectedExpert, teamFlag, meta)
 		if err != nil {
 			program.Send(tui.ErrorMsg{Err: err})
 		}
@@ -70,13 +70,17 @@ func runTUI(cfg *config.Config, prompt string, toolIDs []string, ro config.ReadO
 		return fmt.Errorf("TUI error: %w", err)
 	}
 
-	if m, ok := finalModel.(tui.Model); ok && m.Err != nil {
-		return m.Err
+	m, ok := finalModel.(tui.Model)
+	if !ok {
+		return nil
 	}
-	return nil
+	for _, w := range m.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	return m.Err
 }
 
-func executeTUIRun(ctx context.Context, program *tea.Program, cfg *config.Config, prompt string, toolIDs []string, ro config.ReadOnlyMode, expertFlag, teamFlag string) error {
+func executeTUIRun(ctx context.Context, program *tea.Program, cfg *config.Config, prompt string, toolIDs []string, ro config.ReadOnlyMode, expertFlag, teamFlag string, meta runMeta) error {
 	tools, err := buildTools(cfg, toolIDs)
 	if err != nil {
 		return err
@@ -86,8 +90,13 @@ func executeTUIRun(ctx context.Context, program *tea.Program, cfg *config.Config
 	if err != nil {
 		return err
 	}
+	defer output.UnlockRun(runDir)
 	promptFilePath := filepath.Join(runDir, "prompt.md")
-	if err := output.WritePrompt(runDir, prompt); err != nil {
+	var attachments []string
+	if meta.Attached, attachments, err = copyAttachments(runDir, meta.Attachments); err != nil {
+		return err
+	}
+	if err := output.WritePrompt(runDir, prompt+adapter.AttachmentInstruction(attachments)); err != nil {
 		return fmt.Errorf("writing prompt: %w", err)
 	}
 
@@ -105,23 +114,28 @@ func executeTUIRun(ctx context.Context, program *tea.Program, cfg *config.Config
 		}
 	})
 
-	expertIDs, expertContents, err := resolveExperts(tools, toolIDs, cfg, expertFlag, teamFlag)
+	expertIDs, expertContents, expertMetas, err := resolveExperts(tools, toolIDs, cfg, expertFlag, teamFlag)
 	if err != nil {
 		return err
 	}
+	warnings := raiderWarnings(expertIDs, expertMetas)
 
 	baseParams := adapter.RunParams{
-		Prompt:     prompt,
-		PromptFile: promptFilePath,
-		WorkDir:    mustGetwd(),
-		ReadOnly:   adapter.ReadOnlyMode(ro),
-		Timeout:    time.Duration(cfg.Defaults.Timeout) * time.Second,
+		Prompt:      prompt,
+		PromptFile:  promptFilePath,
+		WorkDir:     mustGetwd(),
+		ReadOnly:    adapter.ReadOnlyMode(ro),
+		Timeout:     time.Duration(cfg.Defaults.Timeout) * time.Second,
+		Attachments: attachments,
 	}
 
 	perToolParams, err := buildExpertParams(tools, expertContents, baseParams, prompt, runDir)
 	if err != nil {
 		return err
 	}
+	if !meta.TimeoutFlag && perToolParams != nil {
+		applyRaiderTimeouts(perToolParams, expertMetas)
+	}
 
 	var results []runner.Result
 	if perToolParams == nil {
@@ -130,11 +144,22 @@ func executeTUIRun(ctx context.Context, program *tea.Program, cfg *config.Config
 		results = r.RunWithParams(ctx, tools, perToolParams, runDir)
 	}
 
-	writeManifestAndSummary(runDir, prompt, startedAt, results, expertIDs, cfg, ro)
+	meta.ExpertIDs, meta.ExpertContents = expertIDs, expertContents
+	manifest := writeManifestAndSummary(runDir, prompt, startedAt, results, cfg, ro, meta)
+	if meta.SynthesizeWith != "" {
+		// A failed synthesis agent is recorded in the manifest and summary;
+		// errors before it ran are printed when the TUI exits. Either way the
+		// TUI still shows the individual results.
+		if err := synthesizeRun(ctx, cfg, runDir, manifest, meta.SynthesizeWith, ro); err != nil {
+			warnings = append(warnings, "synthesis: "+err.Error())
+		}
+	}
+	autoPrune(cfg, runDir)
 
 	program.Send(tui.AllCompletedMsg{
-		Results: results,
-		RunDir:  runDir,
+		Results:  results,
+		RunDir:   runDir,
+		Warnings: warnings,
 	})
 	return nil
 }
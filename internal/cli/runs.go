@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func newRunsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Annotate, export and import past runs",
+		Long: "Annotates runs after the fact and moves them between machines. <run> is a run directory, " +
+			"its name in the output directory, or \"latest\". Pinned runs are never removed by 'horde cleanup'.",
+	}
+
+	cmd.AddCommand(newRunsTagCmd())
+	cmd.AddCommand(newRunsNoteCmd())
+	cmd.AddCommand(newRunsPinCmd(true))
+	cmd.AddCommand(newRunsPinCmd(false))
+	cmd.AddCommand(newRunsExportCmd())
+	cmd.AddCommand(newRunsImportCmd())
+
+	return cmd
+}
+
+// updateRun applies fn to the manifest of a run and writes it back, which
+// also refreshes the run index.
+func updateRun(ref, outputDir string, fn func(m *output.Manifest) error) (string, *output.Manifest, error) {
+	runDir, err := resolveRunDir(ref, outputDir)
+	if err != nil {
+		return "", nil, err
+	}
+	m, err := output.ReadManifest(runDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := fn(m); err != nil {
+		return "", nil, err
+	}
+	if err := output.WriteManifest(runDir, m); err != nil {
+		return "", nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	return runDir, m, nil
+}
+
+func newRunsTagCmd() *cobra.Command {
+	var (
+		outputDir string
+		remove    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tag <run> <tag>...",
+		Short: "Add tags to a run (or remove them with --remove)",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tags, err := output.NormalizeTags(args[1:])
+			if err != nil {
+				return err
+			}
+			_, m, err := updateRun(args[0], outputDir, func(m *output.Manifest) error {
+				if remove {
+					var kept []string
+					for _, t := range m.Tags {
+						if !slices.Contains(tags, t) {
+							kept = append(kept, t)
+						}
+					}
+					m.Tags = kept
+					return nil
+				}
+				m.Tags, err = output.NormalizeTags(append(m.Tags, tags...))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if len(m.Tags) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Tags: (none)")
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Tags: %s\n", strings.Join(m.Tags, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	cmd.Flags().BoolVar(&remove, "remove", false, "Remove the given tags instead of adding them")
+	return cmd
+}
+
+func newRunsNoteCmd() *cobra.Command {
+	var (
+		outputDir string
+		clearNote bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "note <run> [text...]",
+		Short: "Set, show or clear the note of a run",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text := strings.TrimSpace(strings.Join(args[1:], " "))
+			if text == "" && !clearNote {
+				runDir, err := resolveRunDir(args[0], outputDir)
+				if err != nil {
+					return err
+				}
+				m, err := output.ReadManifest(runDir)
+				if err != nil {
+					return fmt.Errorf("reading manifest: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), m.Note)
+				return nil
+			}
+			if text != "" && clearNote {
+				return fmt.Errorf("--clear takes no text")
+			}
+			_, _, err := updateRun(args[0], outputDir, func(m *output.Manifest) error {
+				m.Note = text
+				return nil
+			})
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	cmd.Flags().BoolVar(&clearNote, "clear", false, "Remove the note")
+	return cmd
+}
+
+func newRunsPinCmd(pin bool) *cobra.Command {
+	var outputDir string
+
+	use, short := "pin <run>", "Pin a run so cleanup never removes it"
+	if !pin {
+		use, short = "unpin <run>", "Unpin a run so cleanup may remove it again"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runDir, _, err := updateRun(args[0], outputDir, func(m *output.Manifest) error {
+				m.Pinned = pin
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			state := "Pinned"
+			if !pin {
+				state = "Unpinned"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", state, runDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	return cmd
+}
+
+func newRunsExportCmd() *cobra.Command {
+	var (
+		outFile   string
+		outputDir string
+		opts      output.ExportOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <run>",
+		Short: "Write a run to a .tar.gz archive",
+		Long: "Writes a run directory to a gzipped tarball that 'horde runs import' can restore, " +
+			"for attaching to tickets or moving between machines.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runDir, err := resolveRunDir(args[0], outputDir)
+			if err != nil {
+				return err
+			}
+			if outFile == "" {
+				outFile = filepath.Base(runDir) + ".tar.gz"
+			}
+			n, err := output.ExportRunFile(runDir, outFile, opts)
+			if err != nil {
+				return fmt.Errorf("exporting run: %w", err)
+			}
+			if opts.Redact {
+				fmt.Fprintf(os.Stderr, "Redacted %d secret(s)\n", n)
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outFile, "output", "o", "", "Archive path (default: <run>.tar.gz)")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Output directory (default: from config)")
+	cmd.Flags().BoolVar(&opts.Redact, "redact", false, "Replace API keys, tokens and other secrets with placeholders")
+	cmd.Flags().BoolVar(&opts.NoStderr, "no-stderr", false, "Leave out agent stderr files")
+	return cmd
+}
+
+func newRunsImportCmd() *cobra.Command {
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Restore a run from an archive written by 'horde runs export'",
+		Long: "Extracts a run archive into the output directory. The archive must contain a single run " +
+			"with a valid run.json. A numeric suffix is added when a run of the same name exists.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := resolveOutputDir(outputDir)
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			runDir, err := output.ImportRun(f, baseDir)
+			if err != nil {
+				return fmt.Errorf("importing %s: %w", args[0], err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %s\n", runDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	return cmd
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func runRoot(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetErr(&bytes.Buffer{})
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	root.SetArgs(args)
+	err := root.Execute()
+	return stdout.String(), err
+}
+
+func TestRunsTagNotePin(t *testing.T) {
+	base := t.TempDir()
+	dir := setupRunDir(t, base, "run-111", time.Now())
+
+	out, err := runRoot(t, "runs", "tag", "run-111", "release", "perf", "-o", base)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Tags: release, perf")
+
+	out, err = runRoot(t, "runs", "tag", "run-111", "perf", "--remove", "-o", base)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Tags: release")
+
+	_, err = runRoot(t, "runs", "tag", "run-111", "bad tag", "-o", base)
+	assert.Error(t, err)
+
+	_, err = runRoot(t, "runs", "note", "run-111", "baseline", "before", "refactor", "-o", base)
+	assert.NoError(t, err)
+	out, err = runRoot(t, "runs", "note", "run-111", "-o", base)
+	assert.NoError(t, err)
+	assert.Equal(t, "baseline before refactor\n", out)
+
+	_, err = runRoot(t, "runs", "pin", "run-111", "-o", base)
+	assert.NoError(t, err)
+
+	m, err := output.ReadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"release"}, m.Tags)
+	assert.True(t, m.Pinned)
+
+	_, err = runRoot(t, "runs", "note", "run-111", "--clear", "-o", base)
+	assert.NoError(t, err)
+	_, err = runRoot(t, "runs", "unpin", "run-111", "-o", base)
+	assert.NoError(t, err)
+	m, err = output.ReadManifest(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, m.Note)
+	assert.False(t, m.Pinned)
+}
+
+func TestSummaryListFiltersByTagAndPinned(t *testing.T) {
+	base := t.TempDir()
+	setupRunDir(t, base, "run-111", time.Now().Add(-time.Hour))
+	setupRunDir(t, base, "run-222", time.Now())
+	_, err := runRoot(t, "runs", "tag", "run-111", "release", "-o", base)
+	assert.NoError(t, err)
+	_, err = runRoot(t, "runs", "pin", "run-222", "-o", base)
+	assert.NoError(t, err)
+
+	out, err := runRoot(t, "summary", "list", "--tag", "release", "-o", base)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "run-111")
+	assert.NotContains(t, out, "run-222")
+	assert.Contains(t, out, "release")
+
+	out, err = runRoot(t, "summary", "list", "--pinned", "-o", base)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "run-222")
+	assert.NotContains(t, out, "run-111")
+}
+
+func TestRunsExportImport(t *testing.T) {
+	src := t.TempDir()
+	setupRunDir(t, src, "run-111", time.Now())
+	archive := filepath.Join(t.TempDir(), "run.tar.gz")
+
+	_, err := runRoot(t, "runs", "export", "run-111", "-o", archive, "--output-dir", src, "--redact", "--no-stderr")
+	assert.NoError(t, err)
+	assert.FileExists(t, archive)
+
+	dst := t.TempDir()
+	out, err := runRoot(t, "runs", "import", archive, "-o", dst)
+	assert.NoError(t, err)
+	assert.Contains(t, out, filepath.Join(dst, "run-111"))
+	assert.FileExists(t, filepath.Join(dst, "run-111", "run.json"))
+}
+
+func TestCleanupArchive(t *testing.T) {
+	base := t.TempDir()
+	setupRunDir(t, base, "run-old", time.Now().Add(-48*time.Hour))
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+
+	_, err := runRoot(t, "cleanup", "--older-than", "1d", "-y", "--archive", archiveDir, "-o", base)
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(archiveDir, "run-old.tar.gz"))
+	assert.NoDirExists(t, filepath.Join(base, "run-old"))
+}
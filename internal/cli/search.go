@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/output"
+	"github.com/codebeauty/horde/internal/tui"
+)
+
+func newSearchCmd() *cobra.Command {
+	var (
+		outputDir string
+		agent     string
+		raider    string
+		status    string
+		since     string
+		tags      []string
+		limit     int
+		open      int
+		jsonOut   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search prompts and agent responses across runs",
+		Long: "Searches the prompts, notes and agent responses of all runs in the output directory, newest first. " +
+			"Every word of the query must appear (case-insensitive). Use --open N to view the Nth hit " +
+			"in the results viewer with the matching agent tab selected.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := resolveOutputDir(outputDir)
+			if err != nil {
+				return err
+			}
+			opts := output.SearchOptions{Agent: agent, Raider: raider, Status: status, Tags: tags, Limit: limit}
+			if since != "" {
+				d, err := output.ParseDuration(since)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				opts.Since = time.Now().Add(-d)
+			}
+
+			hits, err := output.SearchRuns(baseDir, strings.Join(args, " "), opts)
+			if err != nil {
+				return err
+			}
+
+			if open > 0 {
+				if open > len(hits) {
+					return fmt.Errorf("--open %d: only %d hit(s)", open, len(hits))
+				}
+				return openSearchHit(hits[open-1])
+			}
+
+			if jsonOut {
+				if hits == nil {
+					hits = []output.SearchHit{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(hits)
+			}
+			if len(hits) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "No matches.")
+				return nil
+			}
+			printSearchHits(cmd, hits, tui.IsTTY())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	cmd.Flags().StringVar(&agent, "agent", "", "Only search responses of this agent")
+	cmd.Flags().StringVar(&raider, "raider", "", "Only search responses of this raider")
+	cmd.Flags().StringVar(&status, "status", "", "Only search responses with this status (success, failed, timeout, cancelled)")
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "Only search runs with this tag (repeatable; all must match)")
+	cmd.Flags().StringVar(&since, "since", "", "Only search runs started within this duration (e.g. 2d, 1w)")
+	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum number of hits (0 = unlimited)")
+	cmd.Flags().IntVar(&open, "open", 0, "Open the Nth hit in the results viewer")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output hits as JSON")
+
+	return cmd
+}
+
+func printSearchHits(cmd *cobra.Command, hits []output.SearchHit, rich bool) {
+	w := cmd.OutOrStdout()
+	mark := func(s string) string { return s }
+	if rich {
+		mark = func(s string) string { return tui.StyleWarning.Bold(true).Render(s) }
+	}
+
+	for i, h := range hits {
+		where := h.Source
+		if h.ToolID != "" {
+			where = h.ToolID
+			if h.Raider != "" && !strings.HasSuffix(h.ToolID, "@"+h.Raider) {
+				where += " [" + h.Raider + "]"
+			}
+			if h.Status != "success" {
+				where += " (" + h.Status + ")"
+			}
+		}
+		header := fmt.Sprintf("%d. %s  %s  line %d", i+1, h.StartedAt.Local().Format("2006-01-02 15:04"), where, h.Line)
+		if h.Matches > 1 {
+			header += fmt.Sprintf(" (+%d more)", h.Matches-1)
+		}
+		if rich {
+			header = tui.StyleBold.Render(header)
+		}
+		fmt.Fprintln(w, header)
+		fmt.Fprintf(w, "   %s\n", h.Highlight(mark))
+		path := h.RunDir
+		if rich {
+			path = tui.StyleMuted.Render(path)
+		}
+		fmt.Fprintf(w, "   %s\n\n", path)
+	}
+}
+
+func openSearchHit(h output.SearchHit) error {
+	m, err := output.ReadManifest(h.RunDir)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+	tab := max(h.AgentIndex, 0)
+	return tui.ViewRun(output.LoadResults(m, h.RunDir), h.RunDir, tab)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/output"
+)
+
+func TestSearchCmd(t *testing.T) {
+	base := t.TempDir()
+	runDir := setupRunDir(t, base, "review-run-111", time.Now())
+	m, err := output.ReadManifest(runDir)
+	assert.NoError(t, err)
+	m.Results = []output.ManifestResult{{ToolID: "gemini", Status: "success", OutputFile: "gemini.md"}}
+	assert.NoError(t, output.WriteManifest(runDir, m))
+	os.WriteFile(filepath.Join(runDir, "gemini.md"), []byte("A race condition in the cache."), 0o600)
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"search", "race", "condition", "-o", base, "--agent", "gemini"})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stdout.String(), "1. ")
+	assert.Contains(t, stdout.String(), "gemini")
+	assert.Contains(t, stdout.String(), "A race condition in the cache.")
+	assert.Contains(t, stdout.String(), runDir)
+
+	stdout.Reset()
+	root = newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"search", "race", "-o", base, "--json", "--status", "failed"})
+	assert.NoError(t, root.Execute())
+	var hits []output.SearchHit
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &hits))
+	assert.Empty(t, hits)
+}
+
+func TestSearchCmdInvalidSince(t *testing.T) {
+	root := newRootCmd()
+	root.SetArgs([]string{"search", "x", "-o", t.TempDir(), "--since", "soon"})
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	assert.Error(t, root.Execute())
+}
@@ -87,6 +87,8 @@ func newSummaryListCmd() *cobra.Command {
 		outputDir string
 		limit     int
 		jsonOut   bool
+		tags      []string
+		pinned    bool
 	)
 
 	cmd := &cobra.Command{
@@ -102,6 +104,16 @@ func newSummaryListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if len(tags) > 0 || pinned {
+				var filtered []output.Candidate
+				for _, r := range runs {
+					if r.Entry == nil || !output.HasTags(r.Entry.Tags, tags) || (pinned && !r.Entry.Pinned) {
+						continue
+					}
+					filtered = append(filtered, r)
+				}
+				runs = filtered
+			}
 			if len(runs) == 0 {
 				fmt.Fprintln(cmd.ErrOrStderr(), "No runs found.")
 				return nil
@@ -129,18 +141,20 @@ func newSummaryListCmd() *cobra.Command {
 			rich := tui.IsTTY()
 
 			for _, r := range runs {
-				m, err := output.ReadManifest(r.Path)
-				if err != nil {
+				// Cards come from the run index; directories without a
+				// manifest (for example a raid still in progress) are skipped.
+				e := r.Entry
+				if e == nil {
 					continue
 				}
 
 				if rich {
-					fmt.Fprintln(w, tui.Separator(r.Mtime.Format("2006-01-02 15:04")))
+					fmt.Fprintln(w, tui.Separator(r.StartedAt.Local().Format("2006-01-02 15:04")))
 				} else {
-					fmt.Fprintf(w, "─── %s ───\n", r.Mtime.Format("2006-01-02 15:04"))
+					fmt.Fprintf(w, "─── %s ───\n", r.StartedAt.Local().Format("2006-01-02 15:04"))
 				}
 
-				prompt := m.Prompt
+				prompt := e.Prompt
 				if len(prompt) > 60 {
 					prompt = prompt[:60] + "..."
 				}
@@ -150,9 +164,28 @@ func newSummaryListCmd() *cobra.Command {
 					fmt.Fprintf(w, "Prompt: %s\n", prompt)
 				}
 
-				if len(m.Results) > 0 {
-					toolSummaries := make([]string, len(m.Results))
-					for i, res := range m.Results {
+				if e.Pinned || len(e.Tags) > 0 {
+					label := strings.Join(e.Tags, ", ")
+					if e.Pinned {
+						label = strings.TrimSuffix("📌 pinned, "+label, ", ")
+					}
+					if rich {
+						fmt.Fprintf(w, "  Tags:   %s\n", label)
+					} else {
+						fmt.Fprintf(w, "Tags:   %s\n", label)
+					}
+				}
+				if e.Note != "" {
+					if rich {
+						fmt.Fprintf(w, "  Note:   %s\n", e.Note)
+					} else {
+						fmt.Fprintf(w, "Note:   %s\n", e.Note)
+					}
+				}
+
+				if len(e.Results) > 0 {
+					toolSummaries := make([]string, len(e.Results))
+					for i, res := range e.Results {
 						var icon string
 						if rich {
 							icon = tui.StatusIcon(res.Status)
@@ -189,6 +222,8 @@ func newSummaryListCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
 	cmd.Flags().IntVar(&limit, "limit", 10, "Maximum number of runs to show")
 	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON array of manifests")
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "Only list runs with this tag (repeatable; all must match)")
+	cmd.Flags().BoolVar(&pinned, "pinned", false, "Only list pinned runs")
 
 	return cmd
 }
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/adapter"
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/output"
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+func newSynthesizeCmd() *cobra.Command {
+	var (
+		withFlag  string
+		outputDir string
+		readOnly  string
+		timeout   int
+		jsonOut   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "synthesize <run>",
+		Short: "Merge all responses of a run into synthesis.md using an agent",
+		Long: "Feeds every successful response of a run (labelled by agent and raider) to one agent " +
+			"and writes synthesis.md highlighting consensus, disagreements and unique findings. " +
+			"<run> is a run directory, its name in the output directory, or \"latest\".",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if withFlag == "" {
+				return fmt.Errorf("--with <agent> is required")
+			}
+			cfg, err := config.LoadMerged(mustGetwd())
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			if timeout > 0 {
+				cfg.Defaults.Timeout = timeout
+			}
+
+			runDir, err := resolveRunDir(args[0], outputDir)
+			if err != nil {
+				return err
+			}
+			if err := output.LockRun(runDir); err != nil {
+				return err
+			}
+			defer output.UnlockRun(runDir)
+			m, err := output.ReadManifest(runDir)
+			if err != nil {
+				return fmt.Errorf("reading manifest: %w", err)
+			}
+
+			ro := config.ReadOnlyMode(cfg.Defaults.ReadOnly)
+			if m.Config.ReadOnly != "" {
+				ro = config.ReadOnlyMode(m.Config.ReadOnly)
+			}
+			if readOnly != "" {
+				if ro, err = config.ValidateReadOnlyMode(readOnly); err != nil {
+					return err
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			fmt.Fprintf(os.Stderr, "Synthesizing %s with %s...\n", runDir, withFlag)
+			if err := synthesizeRun(ctx, cfg, runDir, m, withFlag, ro); err != nil {
+				return err
+			}
+
+			if jsonOut {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(m.Synthesis)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), filepath.Join(runDir, m.Synthesis.OutputFile))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&withFlag, "with", "", "Agent ID that performs the synthesis (required)")
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory (default: from config)")
+	cmd.Flags().StringVarP(&readOnly, "read-only", "r", "", "Read-only mode: enforced, bestEffort, none (default: the run's mode)")
+	cmd.Flags().IntVar(&timeout, "timeout", 0, "Synthesis timeout in seconds")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output the synthesis manifest entry as JSON")
+
+	return cmd
+}
+
+// synthesizeRun feeds all successful responses in runDir to agentID, writes
+// synthesis.md, and records the step (including its cost) in the run's
+// manifest and summary.
+func synthesizeRun(ctx context.Context, cfg *config.Config, runDir string, m *output.Manifest, agentID string, ro config.ReadOnlyMode) error {
+	prompt, sources, err := output.BuildSynthesisPrompt(m, runDir)
+	if err != nil {
+		return err
+	}
+
+	tools, err := buildTools(cfg, []string{agentID})
+	if err != nil {
+		return err
+	}
+	tools[0].ID = output.SynthesisID
+
+	promptFile := filepath.Join(runDir, output.SynthesisID+".prompt.md")
+	if err := os.WriteFile(promptFile, []byte(prompt), 0o600); err != nil {
+		return fmt.Errorf("writing synthesis prompt: %w", err)
+	}
+
+	results := runner.New(1).Run(ctx, tools, adapter.RunParams{
+		Prompt:     prompt,
+		PromptFile: promptFile,
+		WorkDir:    mustGetwd(),
+		ReadOnly:   adapter.ReadOnlyMode(ro),
+		Timeout:    time.Duration(cfg.Defaults.Timeout) * time.Second,
+	}, runDir)
+	result := results[0]
+
+	m.Synthesis = output.BuildSynthesis(agentID, result, sources)
+	if err := output.WriteManifest(runDir, m); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := output.WriteSummary(runDir, output.BuildSummary(m, runDir)); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to write summary: %v\n", err)
+	}
+
+	if result.Status != runner.StatusSuccess {
+		msg := fmt.Sprintf("synthesis with %s %s (exit %d)", agentID, result.Status, result.ExitCode)
+		if snippet := stderrSnippet(result.Stderr); snippet != "" {
+			msg += ": " + snippet
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/output"
+)
+
+// setupConfig points HOME at a temp dir and writes cfg as the global config.
+func setupConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	dir := filepath.Join(home, "Library", "Application Support", "horde")
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	assert.NoError(t, config.Save(cfg, filepath.Join(dir, "config.json")))
+}
+
+func TestSynthesizeCmd(t *testing.T) {
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not available")
+	}
+	cfg := config.NewDefaults()
+	cfg.Tools["echo"] = config.ToolConfig{Binary: cat, Adapter: "echo", Enabled: true, Stdin: true}
+	setupConfig(t, cfg)
+
+	base := t.TempDir()
+	runDir := setupRunDir(t, base, "review-run-111", time.Now())
+	m, err := output.ReadManifest(runDir)
+	assert.NoError(t, err)
+	m.Results = []output.ManifestResult{
+		{ToolID: "claude", Status: "success", OutputFile: "claude.md"},
+		{ToolID: "gemini", Status: "success", OutputFile: "gemini.md", Expert: "security"},
+	}
+	assert.NoError(t, output.WriteManifest(runDir, m))
+	os.WriteFile(filepath.Join(runDir, "claude.md"), []byte("claude says yes"), 0o600)
+	os.WriteFile(filepath.Join(runDir, "gemini.md"), []byte("gemini says no"), 0o600)
+
+	var stdout bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"synthesize", "review-run-111", "--with", "echo", "-o", base})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stdout.String(), filepath.Join(runDir, "synthesis.md"))
+
+	// The stdin-echoing agent writes the synthesis prompt back as its answer.
+	data, err := os.ReadFile(filepath.Join(runDir, "synthesis.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "claude says yes")
+	assert.Contains(t, string(data), "agent: gemini, raider: security")
+
+	m, err = output.ReadManifest(runDir)
+	assert.NoError(t, err)
+	assert.NotNil(t, m.Synthesis)
+	assert.Equal(t, "echo", m.Synthesis.ToolID)
+	assert.Equal(t, "success", m.Synthesis.Status)
+	assert.Equal(t, []string{"claude", "gemini"}, m.Synthesis.Sources)
+
+	summary, _ := os.ReadFile(filepath.Join(runDir, "summary.md"))
+	assert.Contains(t, string(summary), "## Synthesis")
+}
+
+func TestSynthesizeCmdRequiresWith(t *testing.T) {
+	root := newRootCmd()
+	root.SetArgs([]string{"synthesize", "latest"})
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	err := root.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--with")
+}
@@ -33,10 +33,11 @@ func expandTeamCrossProduct(toolIDs []string, teamExperts []string, cfg *config.
 	return crossIDs, nil
 }
 
-func resolveTeamExperts(compositeIDs []string, expertDir string) (ids []string, contents []string, err error) {
-	cache := make(map[string]string)
+func resolveTeamExperts(compositeIDs []string, expertDir string) (ids []string, contents []string, metas []raider.Meta, err error) {
+	cache := make(map[string]*raider.Raider)
 	ids = make([]string, len(compositeIDs))
 	contents = make([]string, len(compositeIDs))
+	metas = make([]raider.Meta, len(compositeIDs))
 
 	for i, cid := range compositeIDs {
 		parts := strings.SplitN(cid, "@", 2)
@@ -45,14 +46,15 @@ func resolveTeamExperts(compositeIDs []string, expertDir string) (ids []string,
 		}
 		eid := parts[1]
 		if _, ok := cache[eid]; !ok {
-			content, loadErr := raider.Load(eid, expertDir)
+			r, loadErr := raider.Read(eid, expertDir)
 			if loadErr != nil {
-				return nil, nil, fmt.Errorf("expert %q: %w", eid, loadErr)
+				return nil, nil, nil, fmt.Errorf("expert %q: %w", eid, loadErr)
 			}
-			cache[eid] = content
+			cache[eid] = r
 		}
 		ids[i] = eid
-		contents[i] = cache[eid]
+		contents[i] = cache[eid].Body
+		metas[i] = cache[eid].Meta
 	}
-	return ids, contents, nil
+	return ids, contents, metas, nil
 }
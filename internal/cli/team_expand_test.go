@@ -71,9 +71,9 @@ func TestExpandTeamUnknownTool(t *testing.T) {
 func TestResolveTeamExperts(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "security.md"), []byte("You are a security raider."), 0o600)
-	os.WriteFile(filepath.Join(dir, "architect.md"), []byte("You are an architect."), 0o600)
+	os.WriteFile(filepath.Join(dir, "architect.md"), []byte("---\ntimeout: 900\n---\nYou are an architect."), 0o600)
 
-	ids, contents, err := resolveTeamExperts(
+	ids, contents, metas, err := resolveTeamExperts(
 		[]string{"claude@security", "gemini@architect", "claude@architect"},
 		dir,
 	)
@@ -82,12 +82,13 @@ func TestResolveTeamExperts(t *testing.T) {
 	assert.Equal(t, "You are a security raider.", contents[0])
 	assert.Equal(t, "You are an architect.", contents[1])
 	assert.Equal(t, "You are an architect.", contents[2]) // cached
+	assert.Equal(t, []int{0, 900, 900}, []int{metas[0].Timeout, metas[1].Timeout, metas[2].Timeout})
 }
 
 func TestResolveTeamExpertsMissing(t *testing.T) {
 	dir := t.TempDir()
 
-	_, _, err := resolveTeamExperts([]string{"claude@nonexistent"}, dir)
+	_, _, _, err := resolveTeamExperts([]string{"claude@nonexistent"}, dir)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "nonexistent")
 }
@@ -95,7 +96,7 @@ func TestResolveTeamExpertsMissing(t *testing.T) {
 func TestResolveTeamExpertsNoAtSign(t *testing.T) {
 	dir := t.TempDir()
 
-	ids, contents, err := resolveTeamExperts([]string{"plain-tool"}, dir)
+	ids, contents, _, err := resolveTeamExperts([]string{"plain-tool"}, dir)
 	assert.NoError(t, err)
 	assert.Equal(t, "", ids[0])
 	assert.Equal(t, "", contents[0])
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codebeauty/horde/internal/templates"
+)
+
+func newTemplatesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Manage prompt templates",
+		Long: "Prompt templates are Go text/template files that frame the prompt sent to agents. " +
+			"They are looked up in .horde/templates in the project, then in the global config directory, " +
+			"then among the built-ins, and filled with .Prompt, .Context, .GitBranch, .Files and any --var values.",
+	}
+	cmd.AddCommand(newTemplatesListCmd())
+	cmd.AddCommand(newTemplatesShowCmd())
+	cmd.AddCommand(newTemplatesCreateCmd())
+	return cmd
+}
+
+func newTemplatesListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List available prompt templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := templates.List(mustGetwd())
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, t := range list {
+				if t.Path != "" {
+					fmt.Fprintf(out, "%-20s %-9s %s\n", t.ID, t.Source, t.Path)
+				} else {
+					fmt.Fprintf(out, "%-20s %s\n", t.ID, t.Source)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newTemplatesShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Print a prompt template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := templates.Load(args[0], mustGetwd())
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), t.Text)
+			if len(t.Text) > 0 && t.Text[len(t.Text)-1] != '\n' {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			return nil
+		},
+	}
+}
+
+func newTemplatesCreateCmd() *cobra.Command {
+	var (
+		project bool
+		from    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create <id>",
+		Short: "Create a prompt template (opens $EDITOR)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+			if err := templates.ValidateID(id); err != nil {
+				return err
+			}
+			base, err := templates.Load(from, mustGetwd())
+			if err != nil {
+				return err
+			}
+
+			dir := templates.Dir()
+			if project {
+				dir = filepath.Join(mustGetwd(), templates.ProjectDir)
+			}
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				return err
+			}
+			path := filepath.Join(dir, id+".md")
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("template %q already exists: %s", id, path)
+			}
+			if err := os.WriteFile(path, []byte(base.Text), 0o600); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Created template %q: %s\n", id, path)
+			return openEditor(path)
+		},
+	}
+
+	cmd.Flags().BoolVar(&project, "project", false, "Create the template in this project's .horde/templates")
+	cmd.Flags().StringVar(&from, "from", "default", "Template to start from")
+	return cmd
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/config"
+	"github.com/codebeauty/horde/internal/gather"
+	"github.com/codebeauty/horde/internal/templates"
+)
+
+func TestTemplatesCmd(t *testing.T) {
+	setupConfig(t, config.NewDefaults())
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("EDITOR", "true")
+
+	out, err := runRoot(t, "templates", "show", "review")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "# Code Review")
+
+	_, err = runRoot(t, "templates", "create", "explain", "--project", "--from", "review")
+	assert.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(templates.ProjectDir, "explain.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, templates.Builtins["review"], string(data))
+
+	_, err = runRoot(t, "templates", "create", "explain", "--project")
+	assert.ErrorContains(t, err, `template "explain" already exists`)
+	_, err = runRoot(t, "templates", "create", "other", "--from", "missing")
+	assert.ErrorContains(t, err, `template "missing" not found`)
+
+	out, err = runRoot(t, "templates", "list")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "default              built-in\n")
+	assert.Contains(t, out, "explain              project   "+filepath.Join(dir, templates.ProjectDir, "explain.md"))
+}
+
+func TestApplyTemplate(t *testing.T) {
+	setupConfig(t, config.NewDefaults())
+	t.Chdir(t.TempDir())
+
+	gathered := &gather.Result{Context: "#### a.go", Included: []string{"a.go"}}
+	got, err := applyTemplate(nil, "Why?", nil, gathered)
+	assert.NoError(t, err)
+	assert.Contains(t, got, "## Question\n\nWhy?\n\n## Context\n\n#### a.go\n\n## Instructions")
+
+	tmpl := &templates.Template{ID: "t", Text: "{{.Prompt}} ({{.tone}}): {{range .Files}}{{.}}{{end}}"}
+	got, err = applyTemplate(tmpl, "Why?", map[string]string{"tone": "terse"}, gathered)
+	assert.NoError(t, err)
+	assert.Equal(t, "Why? (terse): a.go", got)
+}
@@ -22,13 +22,61 @@ type Config struct {
 	Tools    map[string]ToolConfig `json:"tools"`
 	Groups   map[string][]string   `json:"groups"`
 	Teams    map[string][]string   `json:"teams"`
+
+	Retention RetentionConfig `json:"retention,omitzero"`
 }
 
+// SecretPolicy decides what happens when a prompt about to be sent to
+// agents contains possible secrets.
+type SecretPolicy string
+
+const (
+	SecretsBlock  SecretPolicy = "block"  // refuse to run
+	SecretsRedact SecretPolicy = "redact" // replace secrets with placeholders
+	SecretsWarn   SecretPolicy = "warn"   // send the prompt unchanged
+)
+
 type DefaultsConfig struct {
 	Timeout     int          `json:"timeout"`
 	OutputDir   string       `json:"outputDir"`
 	ReadOnly    ReadOnlyMode `json:"readOnly"`
 	MaxParallel int          `json:"maxParallel"`
+
+	// ContextTokens caps the context gathered by --context and the git
+	// range flags. Zero derives it from the agents being run.
+	ContextTokens int `json:"contextTokens,omitempty"`
+
+	// SecretPolicy applies to secrets found in prompts. Empty means redact.
+	SecretPolicy SecretPolicy `json:"secretPolicy,omitempty"`
+}
+
+// RetentionConfig controls which runs 'horde cleanup' removes. Durations
+// use the cleanup syntax (30m, 2d, 1w) and sizes accept KB, MB and GB
+// suffixes. Empty fields disable the rule.
+type RetentionConfig struct {
+	MaxAge     string `json:"maxAge,omitempty"`     // remove runs started longer ago
+	KeepLast   int    `json:"keepLast,omitempty"`   // never remove the N newest runs
+	MaxSize    string `json:"maxSize,omitempty"`    // remove oldest runs until the output dir fits
+	KeepFailed string `json:"keepFailed,omitempty"` // age limit for runs with failed agents
+	AutoPrune  bool   `json:"autoPrune,omitempty"`  // apply the policy after every raid
+}
+
+// IsZero reports whether no retention rule is configured.
+func (r RetentionConfig) IsZero() bool {
+	return r.MaxAge == "" && r.KeepLast == 0 && r.MaxSize == "" && r.KeepFailed == ""
+}
+
+// ProtectiveRetention applies the retention rules of a project config to
+// the global ones, taking only what makes cleanup keep more runs: a larger
+// keepLast, and a keepFailed age when the global config sets none. A
+// checked-out repository must not be able to delete the user's history, so
+// maxAge, maxSize and autoPrune stay global.
+func ProtectiveRetention(global, project RetentionConfig) RetentionConfig {
+	global.KeepLast = max(global.KeepLast, project.KeepLast)
+	if global.KeepFailed == "" {
+		global.KeepFailed = project.KeepFailed
+	}
+	return global
 }
 
 type ToolConfig struct {
@@ -38,6 +86,9 @@ type ToolConfig struct {
 	Enabled    bool     `json:"enabled"`
 	Stdin      bool     `json:"stdin,omitempty"`
 	Expert     string   `json:"expert,omitempty"`
+
+	// ContextTokens overrides the adapter's estimated context budget.
+	ContextTokens int `json:"contextTokens,omitempty"`
 }
 
 func NewDefaults() *Config {
@@ -148,17 +199,53 @@ func StricterReadOnly(a, b ReadOnlyMode) ReadOnlyMode {
 	return b
 }
 
+func ValidateSecretPolicy(policy string) (SecretPolicy, error) {
+	switch SecretPolicy(policy) {
+	case SecretsBlock, SecretsRedact, SecretsWarn:
+		return SecretPolicy(policy), nil
+	case "":
+		return SecretsRedact, nil
+	default:
+		return "", fmt.Errorf("invalid secret policy %q: must be block, redact, or warn", policy)
+	}
+}
+
+var secretStrictness = map[SecretPolicy]int{
+	SecretsWarn:   0,
+	SecretsRedact: 1,
+	SecretsBlock:  2,
+}
+
+// StricterSecretPolicy returns the stricter of two policies; empty counts
+// as redact.
+func StricterSecretPolicy(a, b SecretPolicy) SecretPolicy {
+	if a == "" {
+		a = SecretsRedact
+	}
+	if b == "" {
+		b = SecretsRedact
+	}
+	if secretStrictness[a] >= secretStrictness[b] {
+		return a
+	}
+	return b
+}
+
 // ProjectDefaults holds per-project overrides loaded from .horde.json.
 type ProjectDefaults struct {
 	Timeout     *int          `json:"timeout,omitempty"`
 	OutputDir   *string       `json:"outputDir,omitempty"`
 	ReadOnly    *ReadOnlyMode `json:"readOnly,omitempty"`
 	MaxParallel *int          `json:"maxParallel,omitempty"`
+
+	ContextTokens *int          `json:"contextTokens,omitempty"`
+	SecretPolicy  *SecretPolicy `json:"secretPolicy,omitempty"`
 }
 
 // ProjectConfig represents a .horde.json file in the project root.
 type ProjectConfig struct {
-	Defaults *ProjectDefaults `json:"defaults,omitempty"`
+	Defaults  *ProjectDefaults `json:"defaults,omitempty"`
+	Retention *RetentionConfig `json:"retention,omitempty"`
 }
 
 // LoadProjectConfig reads .horde.json from dir, falling back to .panel.json.
@@ -183,9 +270,17 @@ func LoadProjectConfig(dir string) (*ProjectConfig, error) {
 }
 
 // MergeWithProject applies project-level overrides to the global config.
-// Read-only is clamped via StricterReadOnly (project can only tighten, not loosen).
+// Read-only and the secret policy are clamped via StricterReadOnly and
+// StricterSecretPolicy (project can only tighten, not loosen).
+// A project retention policy can only keep more runs: see ProtectiveRetention.
 func MergeWithProject(cfg *Config, pc *ProjectConfig) {
-	if pc == nil || pc.Defaults == nil {
+	if pc == nil {
+		return
+	}
+	if pc.Retention != nil {
+		cfg.Retention = ProtectiveRetention(cfg.Retention, *pc.Retention)
+	}
+	if pc.Defaults == nil {
 		return
 	}
 	d := pc.Defaults
@@ -201,6 +296,12 @@ func MergeWithProject(cfg *Config, pc *ProjectConfig) {
 	if d.MaxParallel != nil {
 		cfg.Defaults.MaxParallel = *d.MaxParallel
 	}
+	if d.ContextTokens != nil {
+		cfg.Defaults.ContextTokens = *d.ContextTokens
+	}
+	if d.SecretPolicy != nil {
+		cfg.Defaults.SecretPolicy = StricterSecretPolicy(cfg.Defaults.SecretPolicy, *d.SecretPolicy)
+	}
 }
 
 func Load() (*Config, error) {
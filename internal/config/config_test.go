@@ -133,25 +133,66 @@ func TestMergeWithProject(t *testing.T) {
 
 	timeout := 120
 	ro := ReadOnlyEnforced
+	tokens := 20000
+	policy := SecretsBlock
 	pc := &ProjectConfig{
 		Defaults: &ProjectDefaults{
-			Timeout:  &timeout,
-			ReadOnly: &ro,
+			Timeout:       &timeout,
+			ReadOnly:      &ro,
+			ContextTokens: &tokens,
+			SecretPolicy:  &policy,
 		},
 	}
 
 	MergeWithProject(cfg, pc)
 	assert.Equal(t, 120, cfg.Defaults.Timeout)
+	assert.Equal(t, 20000, cfg.Defaults.ContextTokens)
+	assert.Equal(t, SecretsBlock, cfg.Defaults.SecretPolicy)
 	assert.Equal(t, ReadOnlyEnforced, cfg.Defaults.ReadOnly) // enforced > bestEffort
 	assert.Equal(t, "./agents/horde", cfg.Defaults.OutputDir) // unchanged
 }
 
+func TestStricterSecretPolicy(t *testing.T) {
+	assert.Equal(t, SecretsBlock, StricterSecretPolicy(SecretsWarn, SecretsBlock))
+	assert.Equal(t, SecretsRedact, StricterSecretPolicy("", SecretsWarn), "empty counts as redact")
+	assert.Equal(t, SecretsBlock, StricterSecretPolicy(SecretsBlock, SecretsRedact))
+
+	p, err := ValidateSecretPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, SecretsRedact, p)
+	_, err = ValidateSecretPolicy("ignore")
+	assert.EqualError(t, err, `invalid secret policy "ignore": must be block, redact, or warn`)
+}
+
 func TestMergeWithProjectNil(t *testing.T) {
 	cfg := NewDefaults()
 	MergeWithProject(cfg, nil) // should not panic
 	assert.Equal(t, 540, cfg.Defaults.Timeout)
 }
 
+func TestMergeWithProjectRetention(t *testing.T) {
+	cfg := NewDefaults()
+	cfg.Retention = RetentionConfig{MaxAge: "2w", KeepLast: 3, AutoPrune: true}
+	assert.False(t, cfg.Retention.IsZero())
+
+	MergeWithProject(cfg, &ProjectConfig{Retention: &RetentionConfig{KeepLast: 5, KeepFailed: "4w"}})
+	assert.Equal(t, RetentionConfig{MaxAge: "2w", KeepLast: 5, KeepFailed: "4w", AutoPrune: true}, cfg.Retention)
+
+	MergeWithProject(cfg, &ProjectConfig{})
+	assert.Equal(t, RetentionConfig{MaxAge: "2w", KeepLast: 5, KeepFailed: "4w", AutoPrune: true}, cfg.Retention)
+	assert.True(t, RetentionConfig{AutoPrune: true}.IsZero())
+}
+
+func TestMergeWithProjectRetentionCannotDelete(t *testing.T) {
+	cfg := NewDefaults()
+	cfg.Retention = RetentionConfig{MaxAge: "2w", KeepLast: 10, KeepFailed: "8w"}
+
+	MergeWithProject(cfg, &ProjectConfig{Retention: &RetentionConfig{
+		MaxAge: "0s", KeepLast: 1, MaxSize: "1KB", KeepFailed: "1d", AutoPrune: true,
+	}})
+	assert.Equal(t, RetentionConfig{MaxAge: "2w", KeepLast: 10, KeepFailed: "8w"}, cfg.Retention)
+}
+
 func TestMergeWithProjectReadOnlyClamp(t *testing.T) {
 	cfg := NewDefaults() // defaults to bestEffort
 	ro := ReadOnlyNone
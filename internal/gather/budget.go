@@ -0,0 +1,232 @@
+package gather
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// bytesPerToken is the rough size of a token in source code and prose,
+	// used to turn token budgets into byte budgets.
+	bytesPerToken = 4
+
+	// changeWindow is the number of lines kept on each side of a changed
+	// region when a large file is truncated.
+	changeWindow = 20
+
+	// minTruncatedBytes is the smallest excerpt worth including; files that
+	// cannot get this much of the budget are dropped instead.
+	minTruncatedBytes = 1024
+)
+
+// EstimateTokens estimates the number of tokens in s.
+func EstimateTokens(s string) int {
+	return (len(s) + bytesPerToken - 1) / bytesPerToken
+}
+
+// lineRange is an inclusive, 1-based range of lines.
+type lineRange struct{ Start, End int }
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// changedLines returns, per file, the lines that diff changes on its new
+// side, parsed from a zero-context diff.
+func changedLines(diff string) map[string][]lineRange {
+	changed := make(map[string][]lineRange)
+	var file string
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			} else if _, ok := changed[file]; !ok {
+				changed[file] = nil
+			}
+		case strings.HasPrefix(line, "@@") && file != "":
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			if count == 0 {
+				// Pure deletion: keep the lines around where it happened.
+				count = 1
+			}
+			changed[file] = append(changed[file], lineRange{Start: max(start, 1), End: max(start, 1) + count - 1})
+		}
+	}
+	return changed
+}
+
+// truncateFile cuts text to at most limit bytes on line boundaries. When
+// changes are known, the lines around them are kept; otherwise the start of
+// the file is. Omitted stretches are marked in the text. It returns the
+// excerpt and a description of what was kept, or "" when not even one line
+// fits.
+func truncateFile(text string, changes []lineRange, limit int) (string, string) {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var windows []lineRange
+	for _, c := range changes {
+		w := lineRange{Start: max(c.Start-changeWindow, 1), End: min(c.End+changeWindow, len(lines))}
+		if w.Start > w.End {
+			continue
+		}
+		if n := len(windows); n > 0 && w.Start <= windows[n-1].End+1 {
+			windows[n-1].End = max(windows[n-1].End, w.End)
+			continue
+		}
+		windows = append(windows, w)
+	}
+	aroundChanges := len(windows) > 0
+	if !aroundChanges {
+		windows = []lineRange{{Start: 1, End: len(lines)}}
+	}
+
+	var b strings.Builder
+	kept, next, regions := 0, 1, 0
+	omit := func(from, to int) {
+		if from <= to {
+			fmt.Fprintf(&b, "... [lines %d-%d omitted] ...\n", from, to)
+		}
+	}
+	// Leave room for the closing omission marker.
+	limit -= len(fmt.Sprintf("... [lines %d-%d omitted] ...\n", len(lines), len(lines)))
+fill:
+	for _, w := range windows {
+		omit(next, w.Start-1)
+		regions++
+		for i := w.Start; i <= w.End; i++ {
+			if b.Len()+len(lines[i-1]) > limit {
+				next = i
+				break fill
+			}
+			b.WriteString(lines[i-1])
+			kept++
+			next = i + 1
+		}
+	}
+	if kept == 0 {
+		return "", ""
+	}
+	omit(next, len(lines))
+
+	if aroundChanges {
+		return b.String(), fmt.Sprintf("kept %d of %d lines around %d changed region(s)", kept, len(lines), regions)
+	}
+	return b.String(), fmt.Sprintf("kept first %d of %d lines", kept, len(lines))
+}
+
+// truncateLines cuts text to at most limit bytes on a line boundary.
+func truncateLines(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := strings.LastIndexByte(text[:limit], '\n')
+	if cut < 0 {
+		return ""
+	}
+	return text[:cut]
+}
+
+// truncateTail keeps the end of text, at most limit bytes, starting on a
+// line boundary and marked as cut.
+func truncateTail(text string, limit int) string {
+	const marker = "... [earlier output omitted] ...\n"
+	limit -= len(marker)
+	if limit <= 0 {
+		return ""
+	}
+	if len(text) <= limit {
+		return text
+	}
+	tail := text[len(text)-limit:]
+	cut := strings.IndexByte(tail, '\n')
+	if cut < 0 || cut == len(tail)-1 {
+		return ""
+	}
+	return marker + tail[cut+1:]
+}
+
+// truncateDiff cuts a unified diff to at most limit bytes without
+// splitting hunks: whole hunks are kept, in order, until the next one does
+// not fit. It returns the kept diff and the number of hunks kept and in
+// total.
+func truncateDiff(diff string, limit int) (string, int, int) {
+	// A unit is a hunk, with its file header when it is a file's first.
+	var units []string
+	var hunks []bool
+	var cur strings.Builder
+	curHunk, inHeader := false, false
+	flush := func() {
+		if cur.Len() > 0 {
+			units = append(units, cur.String())
+			hunks = append(hunks, curHunk)
+			cur.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			curHunk, inHeader = false, true
+		case strings.HasPrefix(line, "@@"):
+			if !inHeader {
+				flush()
+			}
+			curHunk, inHeader = true, false
+		}
+		cur.WriteString(line)
+	}
+	flush()
+
+	total := 0
+	for _, h := range hunks {
+		if h {
+			total++
+		}
+	}
+	var b strings.Builder
+	kept := 0
+	for i, u := range units {
+		if b.Len()+len(u) > limit {
+			break
+		}
+		b.WriteString(u)
+		if hunks[i] {
+			kept++
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), kept, total
+}
+
+// changeTier ranks a context file for inclusion: files named explicitly
+// first, then files with changes, then the rest.
+func changeTier(f contextFile, changed map[string][]lineRange) int {
+	if f.Explicit {
+		return 0
+	}
+	if _, ok := changed[f.Path]; ok {
+		return 1
+	}
+	return 2
+}
+
+// prioritize orders files by changeTier, keeping the pattern order within
+// each tier.
+func prioritize(files []contextFile, changed map[string][]lineRange) {
+	slices.SortStableFunc(files, func(a, b contextFile) int {
+		return changeTier(a, changed) - changeTier(b, changed)
+	})
+}
@@ -0,0 +1,88 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+// maxCommandOutput bounds the output kept from a context command; beyond it
+// only the end is kept, since that is where failures are summarized.
+const maxCommandOutput = 1 << 20
+
+// CommandOutput is the captured output of a context command.
+type CommandOutput struct {
+	Command  string
+	Output   string // stdout and stderr as interleaved, ANSI escapes removed
+	ExitCode int    // -1 when the command could not be started or timed out
+	TimedOut bool
+	Timeout  time.Duration
+	Duration time.Duration
+}
+
+// RunCommand runs command with sh -c in workDir and captures its output.
+// The command and any processes it starts are killed after timeout. A
+// failing command is not an error: its exit code is part of the output.
+func RunCommand(ctx context.Context, workDir, command string, timeout time.Duration) CommandOutput {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := &tailWriter{max: maxCommandOutput}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = workDir
+	cmd.Stdout, cmd.Stderr = out, out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = 2 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	res := CommandOutput{Command: command, Timeout: timeout, Duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.TimedOut, res.ExitCode = true, -1
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		res.ExitCode = -1
+		out.Write([]byte(err.Error()))
+	}
+	data := out.Bytes()
+	if out.truncated {
+		data = append([]byte("[earlier output discarded]\n"), data...)
+	}
+	res.Output = string(runner.StripANSI(data))
+	return res
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	// Trim lazily so that long outputs are not copied on every write.
+	if len(w.buf) > 2*w.max {
+		w.buf = append(w.buf[:0], w.buf[len(w.buf)-w.max:]...)
+		w.truncated = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns the kept output.
+func (w *tailWriter) Bytes() []byte {
+	if len(w.buf) > w.max {
+		w.truncated = true
+		return w.buf[len(w.buf)-w.max:]
+	}
+	return w.buf
+}
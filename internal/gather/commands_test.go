@@ -0,0 +1,78 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"marker.txt": "here\n"})
+
+	out := RunCommand(context.Background(), dir, `cat marker.txt; printf '\033[31mred\033[0m\n' >&2; exit 3`, 10*time.Second)
+	assert.Equal(t, 3, out.ExitCode)
+	assert.False(t, out.TimedOut)
+	assert.Equal(t, "here\nred\n", out.Output, "stdout and stderr interleaved, ANSI stripped")
+
+	out = RunCommand(context.Background(), dir, "true", 10*time.Second)
+	assert.Equal(t, 0, out.ExitCode)
+	assert.Empty(t, out.Output)
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	start := time.Now()
+	out := RunCommand(context.Background(), t.TempDir(), "echo started; sleep 30 & wait", 200*time.Millisecond)
+	assert.True(t, out.TimedOut)
+	assert.Equal(t, -1, out.ExitCode)
+	assert.Equal(t, "started\n", out.Output)
+	assert.Less(t, time.Since(start), 10*time.Second, "the background sleep is killed too")
+}
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{max: 10}
+	for i := range 10 {
+		fmt.Fprintf(w, "%d:abc\n", i)
+	}
+	assert.Equal(t, "abc\n9:abc\n", string(w.Bytes()))
+	assert.True(t, w.truncated)
+
+	w = &tailWriter{max: 10}
+	fmt.Fprint(w, "short")
+	assert.Equal(t, "short", string(w.Bytes()))
+	assert.False(t, w.truncated)
+}
+
+func TestGatherCommandOutput(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := range 100 {
+		lines = append(lines, fmt.Sprintf("line %02d", i))
+	}
+	cmds := []CommandOutput{
+		{Command: "go vet ./...", ExitCode: 0},
+		{Command: "go test ./...", Output: strings.Join(lines, "\n") + "\n", ExitCode: 1},
+		{Command: "slow", TimedOut: true, Timeout: time.Minute, ExitCode: -1},
+	}
+
+	res, err := GatherRange(nil, 12800, dir, DiffSpec{}, cmds, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Context, "### Command Output: `go vet ./...` (exit 0)\n\n<<<UNTRUSTED-CONTENT "+res.Boundary+">>>\n```\n(no output)\n```\n<<<END-UNTRUSTED-CONTENT "+res.Boundary+">>>")
+	assert.Contains(t, res.Context, "### Command Output: `go test ./...` (exit 1)\n\n<<<UNTRUSTED-CONTENT "+res.Boundary+">>>\n```\nline 00\n")
+	assert.Contains(t, res.Context, "### Command Output: `slow` (timed out after 1m0s)")
+	assert.Empty(t, res.Truncated)
+
+	res, err = GatherRange(nil, 100, dir, DiffSpec{}, cmds[1:2], nil)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Context, "`go test ./...` (exit 1) [truncated]")
+	assert.Contains(t, res.Context, "... [earlier output omitted] ...\n")
+	assert.Contains(t, res.Context, "line 99\n```")
+	assert.NotContains(t, res.Context, "line 00")
+	if assert.Len(t, res.Truncated, 1) {
+		assert.Equal(t, "command: go test ./...", res.Truncated[0].Path)
+	}
+}
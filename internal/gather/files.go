@@ -0,0 +1,238 @@
+package gather
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Dropped is a context file, or pattern, that was left out and why.
+type Dropped struct {
+	Path   string
+	Reason string
+}
+
+// contextFile is a file selected by the context patterns.
+type contextFile struct {
+	Path     string
+	Explicit bool // named directly rather than through a directory or glob
+}
+
+// expandPatterns resolves context patterns to files, relative to workDir
+// and slash-separated. A pattern is a file, a directory (taken
+// recursively), or a glob where "**" matches any number of directories.
+// Patterns starting with "!" exclude matching files and the files in
+// matching directories; a pattern without a slash matches file and
+// directory names at any depth. Directories and globs skip files
+// ignored by git and sensitive files (see isSensitive); files named
+// explicitly are always included. Patterns that match nothing and skipped
+// sensitive files are returned as dropped.
+func expandPatterns(workDir string, patterns []string) (files []contextFile, dropped []Dropped) {
+	var includes, excludes []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		switch {
+		case p == "":
+		case strings.HasPrefix(p, "!"):
+			excludes = append(excludes, cleanPattern(p[1:]))
+		default:
+			includes = append(includes, p)
+		}
+	}
+
+	var listed []string
+	listOnce := func() []string {
+		if listed == nil {
+			listed = listFiles(workDir)
+		}
+		return listed
+	}
+
+	seen := make(map[string]bool)
+	var sensitive []string
+	add := func(f string, explicit bool) {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, contextFile{Path: f, Explicit: explicit})
+		}
+	}
+	for _, p := range includes {
+		clean := cleanPattern(p)
+		var matched []string
+		explicit := false
+		if hasMeta(clean) {
+			for _, f := range listOnce() {
+				if matchPattern(clean, f) {
+					matched = append(matched, f)
+				}
+			}
+		} else {
+			full := clean
+			if !filepath.IsAbs(full) {
+				full = filepath.Join(workDir, full)
+			}
+			info, err := os.Stat(full)
+			switch {
+			case err != nil:
+				dropped = append(dropped, Dropped{Path: p, Reason: "not found"})
+				continue
+			case !info.IsDir():
+				matched, explicit = []string{clean}, true
+			case filepath.IsLocal(clean) || clean == ".":
+				for _, f := range listOnce() {
+					if clean == "." || strings.HasPrefix(f, clean+"/") {
+						matched = append(matched, f)
+					}
+				}
+			default:
+				matched = walkFiles(full, clean)
+			}
+		}
+		if len(matched) == 0 {
+			dropped = append(dropped, Dropped{Path: p, Reason: "no matching files"})
+		}
+		for _, f := range matched {
+			if !explicit && isSensitive(f) {
+				if !slices.Contains(sensitive, f) {
+					sensitive = append(sensitive, f)
+				}
+				continue
+			}
+			add(f, explicit)
+		}
+	}
+
+	files = slices.DeleteFunc(files, func(f contextFile) bool {
+		return slices.ContainsFunc(excludes, func(ex string) bool { return matchExclude(ex, f.Path) })
+	})
+	for _, f := range sensitive {
+		excluded := slices.ContainsFunc(excludes, func(ex string) bool { return matchExclude(ex, f) })
+		if !seen[f] && !excluded {
+			dropped = append(dropped, Dropped{Path: f, Reason: "sensitive file (name it explicitly to include it)"})
+		}
+	}
+	return files, dropped
+}
+
+// sensitivePatterns name files that usually hold credentials.
+var sensitivePatterns = []string{
+	".env", ".env.*", "*.env",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.keystore", "*.jks",
+	".netrc", ".npmrc", ".pypirc", ".pgpass", ".htpasswd",
+	"credentials", "credentials.json", "*.tfvars",
+}
+
+// safeEnvFiles are .env templates that by convention hold no real values.
+var safeEnvFiles = []string{".env.example", ".env.sample", ".env.template", ".env.dist"}
+
+// isSensitive reports whether the slash-separated name is a file that
+// usually holds credentials, such as .env or a private key.
+func isSensitive(name string) bool {
+	base := path.Base(name)
+	if slices.Contains(safeEnvFiles, base) {
+		return false
+	}
+	return slices.ContainsFunc(sensitivePatterns, func(p string) bool {
+		ok, _ := path.Match(p, base)
+		return ok
+	})
+}
+
+func cleanPattern(p string) string {
+	return path.Clean(filepath.ToSlash(strings.TrimSpace(p)))
+}
+
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// matchPattern reports whether the slash-separated name matches pattern.
+func matchPattern(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchExclude reports whether the exclusion pattern matches name or one of
+// its parent directories, so "!vendor" excludes everything under any vendor
+// directory.
+func matchExclude(pattern, name string) bool {
+	for p := name; p != "." && p != "/"; p = path.Dir(p) {
+		if matchPattern(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pat[0], segs[0])
+	return ok && matchSegments(pat[1:], segs[1:])
+}
+
+// listFiles returns the files below workDir, slash-separated and sorted.
+// Inside a git repository these are the tracked and untracked files not
+// excluded by .gitignore; elsewhere every file except those in .git.
+func listFiles(workDir string) []string {
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = workDir
+	if out, err := cmd.Output(); err == nil {
+		var files []string
+		for _, f := range strings.Split(string(out), "\x00") {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+		slices.Sort(files)
+		return slices.Compact(files)
+	}
+	return walkFiles(workDir, ".")
+}
+
+// walkFiles lists the regular files below dir, naming them relative to
+// prefix.
+func walkFiles(dir, prefix string) []string {
+	var files []string
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		files = append(files, path.Join(prefix, filepath.ToSlash(rel)))
+		return nil
+	})
+	return files
+}
@@ -1,7 +1,10 @@
 package gather
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -9,67 +12,310 @@ import (
 	"unicode/utf8"
 )
 
-const defaultMaxKB = 50
+// defaultMaxTokens is the context budget when none is given (about 50 KB).
+const defaultMaxTokens = 12800
 
-// Gather collects context from the specified file paths and git diffs.
-// Files are read first (user content gets priority), then git diffs fill
-// remaining budget up to maxKB kilobytes.
-func Gather(patterns []string, maxKB int, workDir string) (string, error) {
-	if maxKB <= 0 {
-		maxKB = defaultMaxKB
+// Result is the context gathered from files and git diffs.
+type Result struct {
+	Context      string
+	BudgetTokens int
+	UsedTokens   int         // estimated tokens of Context
+	Included     []string    // files and symbols whose contents are in Context
+	Truncated    []Truncated // files and command and git sections cut to fit the budget
+	Dropped      []Dropped   // files and patterns that contributed nothing
+	Boundary     string      // token delimiting untrusted content in Context
+	Suspects     []Suspect   // passages that look like prompt injection
+}
+
+// Truncated is a file or git section that was cut to fit the budget.
+type Truncated struct {
+	Path   string
+	Reason string
+}
+
+// DiffSpec selects the git changes gathered after the files.
+type DiffSpec struct {
+	// Range is a commit range such as "main...HEAD" or "abc123^!". The log
+	// messages of its commits are gathered along with the diff. Empty means
+	// the staged and unstaged working tree changes.
+	Range string
+	// Stat prefixes the diff with a diffstat.
+	Stat bool
+	// ContextLines is the number of context lines around each change
+	// (git diff -U). Zero uses git's default.
+	ContextLines int
+}
+
+// Gather collects context from the specified files, directories and globs
+// and from git diffs, within a budget of maxTokens estimated tokens.
+func Gather(patterns []string, maxTokens int, workDir string) (string, error) {
+	res, err := GatherRange(patterns, maxTokens, workDir, DiffSpec{}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.Context, nil
+}
+
+// section is a block of command or git output for the context.
+type section struct {
+	name              string // how the section is reported when cut; defaults to title
+	title, lang, body string
+	diff              bool // a unified diff, cut on hunk boundaries
+	tail              bool // keep the end when cut, where failures are reported
+}
+
+// GatherRange is like Gather, but reports what was included, truncated and
+// dropped, and adds Go declarations (see FindSymbols), the output of
+// context commands (see RunCommand) and the git changes selected by diff.
+//
+// Symbols come first, then files ordered by relevance: files named
+// explicitly, then files the git changes touch, then the rest of the
+// directories and globs. Symbols that do not fit are dropped; files larger
+// than the remaining budget are cut down to the lines around their changes,
+// or to their start. Up to half of the budget is held back for command
+// output, cut to its last lines, and for the git log and diff, cut on line
+// and hunk boundaries.
+func GatherRange(patterns []string, maxTokens int, workDir string, diff DiffSpec, cmds []CommandOutput, symbols []Snippet) (*Result, error) {
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	maxBytes := maxTokens * bytesPerToken
+	res := &Result{BudgetTokens: maxTokens, Boundary: newBoundary()}
+
+	sections := append(commandSections(cmds), gitSections(workDir, diff)...)
+	sectionBytes := 0
+	for _, s := range sections {
+		sectionBytes += len(s.body)
+	}
+
+	files, dropped := expandPatterns(workDir, patterns)
+	res.Dropped = dropped
+	var changed map[string][]lineRange
+	if len(files) > 0 {
+		changed = changedLines(diffFor(workDir, diff.Range, "-U0", "--no-color", "--no-ext-diff", "--relative", "--src-prefix=a/", "--dst-prefix=b/"))
+		prioritize(files, changed)
 	}
-	maxBytes := maxKB * 1024
 
 	var parts []string
+	// add appends gathered content to parts, fenced and delimited as
+	// untrusted, and checks it for prompt injection.
+	add := func(heading, lang, source, body string) {
+		res.Suspects = append(res.Suspects, DetectInjection(source, body)...)
+		parts = append(parts, heading+"\n\n"+untrusted(res.Boundary, "```"+lang+"\n"+body+"\n```"))
+	}
 	totalBytes := 0
+	fileBudget := maxBytes - min(sectionBytes, maxBytes/2)
+
+	for _, sn := range symbols {
+		if len(sn.Source) > fileBudget-totalBytes {
+			res.Dropped = append(res.Dropped, Dropped{Path: sn.Label(), Reason: fmt.Sprintf("context budget exhausted (%s)", formatKB(len(sn.Source)))})
+			continue
+		}
+		title := sn.Label()
+		if sn.Via != "" {
+			title += ", " + sn.Via
+		}
+		add("#### "+title, "go", sn.Label(), sn.Source)
+		res.Included = append(res.Included, sn.Label())
+		totalBytes += len(sn.Source)
+	}
 
-	for _, p := range patterns {
-		if totalBytes >= maxBytes {
-			break
+	for _, cf := range files {
+		f := cf.Path
+		fullPath := f
+		if !filepath.IsAbs(f) {
+			fullPath = filepath.Join(workDir, f)
 		}
-		fullPath := filepath.Join(workDir, p)
 		info, err := os.Stat(fullPath)
 		if err != nil || !info.Mode().IsRegular() {
-			continue
-		}
-		if int(info.Size()) > maxBytes-totalBytes {
+			res.Dropped = append(res.Dropped, Dropped{Path: f, Reason: "not a regular file"})
 			continue
 		}
 		data, err := os.ReadFile(fullPath)
 		if err != nil {
+			res.Dropped = append(res.Dropped, Dropped{Path: f, Reason: "unreadable"})
 			continue
 		}
-		parts = append(parts, fmt.Sprintf("#### %s\n\n```\n%s\n```", p, string(data)))
-		totalBytes += len(data)
+		if isBinary(data) {
+			res.Dropped = append(res.Dropped, Dropped{Path: f, Reason: "binary"})
+			continue
+		}
+		text, title := string(data), f
+		if avail := fileBudget - totalBytes; len(text) > avail {
+			if avail < minTruncatedBytes {
+				res.Dropped = append(res.Dropped, Dropped{Path: f, Reason: fmt.Sprintf("context budget exhausted (%s)", formatKB(len(data)))})
+				continue
+			}
+			var reason string
+			if text, reason = truncateFile(text, changed[f], avail); text == "" {
+				res.Dropped = append(res.Dropped, Dropped{Path: f, Reason: fmt.Sprintf("too large (%s)", formatKB(len(data)))})
+				continue
+			}
+			title += " [truncated]"
+			res.Truncated = append(res.Truncated, Truncated{Path: f, Reason: reason})
+		}
+		add("#### "+title, "", f, text)
+		res.Included = append(res.Included, f)
+		totalBytes += len(text)
+	}
+
+	listing := len(res.Included) > 0 || len(res.Dropped) > 0
+
+	for _, s := range sections {
+		avail := maxBytes - totalBytes
+		body, title := s.body, s.title
+		name := s.name
+		if name == "" {
+			name = s.title
+		}
+		if len(body) > avail {
+			var reason string
+			switch {
+			case s.diff:
+				var kept, total int
+				body, kept, total = truncateDiff(body, avail)
+				reason = fmt.Sprintf("kept %d of %d hunks", kept, total)
+			case s.tail:
+				body = truncateTail(body, avail)
+				reason = fmt.Sprintf("kept last %s of %s", formatKB(len(body)), formatKB(len(s.body)))
+			default:
+				body = truncateLines(body, avail)
+				reason = fmt.Sprintf("kept %s of %s", formatKB(len(body)), formatKB(len(s.body)))
+			}
+			if body == "" {
+				res.Dropped = append(res.Dropped, Dropped{Path: name, Reason: "context budget exhausted"})
+				continue
+			}
+			title += " [truncated]"
+			res.Truncated = append(res.Truncated, Truncated{Path: name, Reason: reason})
+		}
+		add("### "+title, s.lang, name, body)
+		totalBytes += len(body)
+	}
+
+	wrapped := len(parts) > 0
+	if listing {
+		parts = append([]string{"### Files Referenced\n\n" + fileListing(res)}, parts...)
+	}
+	if wrapped {
+		parts = append([]string{untrustedPreamble(res.Boundary, res.Suspects)}, parts...)
+	}
+	res.Context = strings.Join(parts, "\n\n")
+	res.UsedTokens = EstimateTokens(res.Context)
+	return res, nil
+}
+
+// commandSections returns a section per context command, titled with the
+// command and how it exited.
+func commandSections(cmds []CommandOutput) []section {
+	var sections []section
+	for _, c := range cmds {
+		status := fmt.Sprintf("exit %d", c.ExitCode)
+		if c.TimedOut {
+			status = fmt.Sprintf("timed out after %s", c.Timeout)
+		}
+		body := strings.TrimRight(c.Output, "\n")
+		if body == "" {
+			body = "(no output)"
+		}
+		sections = append(sections, section{
+			name:  "command: " + c.Command,
+			title: fmt.Sprintf("Command Output: `%s` (%s)", c.Command, status),
+			body:  body,
+			tail:  true,
+		})
+	}
+	return sections
+}
+
+// gitSections returns the git log, diffstat and diff selected by diff.
+func gitSections(workDir string, diff DiffSpec) []section {
+	var diffArgs []string
+	if diff.ContextLines > 0 {
+		diffArgs = append(diffArgs, fmt.Sprintf("-U%d", diff.ContextLines))
+	}
+	var sections []section
+	add := func(s section) {
+		if s.body != "" {
+			sections = append(sections, s)
+		}
 	}
+	if diff.Range == "" {
+		if diff.Stat {
+			add(section{title: "Diffstat", body: gitDiff(workDir, "--stat")})
+		}
+		add(section{title: "Recent Changes (Git Diff)", lang: "diff", body: gitDiff(workDir, diffArgs...), diff: true})
+		return sections
+	}
+	add(section{title: fmt.Sprintf("Commits in %s", diff.Range), body: gitLog(workDir, diff.Range)})
+	if diff.Stat {
+		add(section{title: fmt.Sprintf("Diffstat for %s", diff.Range), body: diffFor(workDir, diff.Range, "--stat")})
+	}
+	add(section{title: fmt.Sprintf("Changes in %s (Git Diff)", diff.Range), lang: "diff", body: diffFor(workDir, diff.Range, diffArgs...), diff: true})
+	return sections
+}
+
+// diffFor returns the diff of diffRange, or of the working tree changes
+// when it is empty.
+func diffFor(workDir, diffRange string, args ...string) string {
+	if diffRange == "" {
+		return gitDiff(workDir, args...)
+	}
+	return runGit(workDir, append(append([]string{"diff"}, args...), rangeRevs(workDir, diffRange)...)...)
+}
 
-	if len(parts) > 0 {
-		parts = append([]string{"### Files Referenced\n"}, parts...)
+// rangeRevs returns the revisions to pass to git diff for diffRange. Git
+// reads "A^!" of a root commit as A alone and would compare it with the
+// working tree, so a root commit is compared with the empty tree instead.
+func rangeRevs(workDir, diffRange string) []string {
+	if rev, ok := strings.CutSuffix(diffRange, "^!"); ok && isRootCommit(workDir, rev) {
+		return []string{EmptyTree(workDir), rev}
 	}
+	return []string{diffRange}
+}
 
-	if totalBytes < maxBytes {
-		diff := gitDiff(workDir)
-		if diff != "" {
-			diffBytes := len(diff)
-			if totalBytes+diffBytes <= maxBytes {
-				parts = append(parts, "### Recent Changes (Git Diff)\n\n```diff\n"+diff+"\n```")
+// fileListing lists the included and dropped files for the prompt.
+func fileListing(res *Result) string {
+	var b strings.Builder
+	if len(res.Included) > 0 {
+		truncated := make(map[string]bool)
+		for _, t := range res.Truncated {
+			truncated[t.Path] = true
+		}
+		fmt.Fprintf(&b, "Included (%d):\n", len(res.Included))
+		for _, f := range res.Included {
+			if truncated[f] {
+				fmt.Fprintf(&b, "- %s (truncated)\n", f)
 			} else {
-				remaining := maxBytes - totalBytes
-				truncated := diff[:remaining]
-				for !utf8.ValidString(truncated) && len(truncated) > 0 {
-					truncated = truncated[:len(truncated)-1]
-				}
-				parts = append(parts, "### Recent Changes (Git Diff) [truncated]\n\n```diff\n"+truncated+"\n```")
+				fmt.Fprintf(&b, "- %s\n", f)
 			}
 		}
 	}
+	if len(res.Dropped) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Dropped (%d):\n", len(res.Dropped))
+		for _, d := range res.Dropped {
+			fmt.Fprintf(&b, "- %s: %s\n", d.Path, d.Reason)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
 
-	return strings.Join(parts, "\n\n"), nil
+// isBinary reports whether data looks like something other than text.
+func isBinary(data []byte) bool {
+	head := data[:min(len(data), 8000)]
+	return bytes.IndexByte(head, 0) >= 0 || !utf8.Valid(data)
 }
 
-func gitDiff(workDir string) string {
-	staged := runGit(workDir, "diff", "--staged")
-	unstaged := runGit(workDir, "diff")
+func formatKB(n int) string {
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
+
+func gitDiff(workDir string, args ...string) string {
+	staged := runGit(workDir, append([]string{"diff", "--staged"}, args...)...)
+	unstaged := runGit(workDir, append([]string{"diff"}, args...)...)
 
 	var parts []string
 	if staged != "" {
@@ -84,6 +330,47 @@ func gitDiff(workDir string) string {
 	return strings.Join(parts, "\n")
 }
 
+// gitLog returns the messages of the commits in diffRange, newest first.
+// For a symmetric range (base...head) only the commits on head are listed,
+// matching what a pull request would contain.
+func gitLog(workDir, diffRange string) string {
+	logRange := strings.Replace(diffRange, "...", "..", 1)
+	return runGit(workDir, "log", "--no-color", "--date=short", "--format=%h %ad %an%n%w(0,4,4)%B", logRange)
+}
+
+// VerifyRef checks that ref names a commit in the repository containing
+// workDir.
+func VerifyRef(workDir, ref string) error {
+	if runGit(workDir, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}") == "" {
+		return fmt.Errorf("unknown git revision %q", ref)
+	}
+	return nil
+}
+
+// VerifyRange checks that both ends of a diff range like "A..B", "A...B"
+// or "A^!" name commits. An empty end stands for HEAD, as in git, and the
+// empty tree is accepted as the start of a range.
+func VerifyRange(workDir, rng string) error {
+	if rev, ok := strings.CutSuffix(rng, "^!"); ok {
+		return VerifyRef(workDir, rev)
+	}
+	refs := []string{rng}
+	if from, to, ok := strings.Cut(rng, "..."); ok {
+		refs = []string{from, to}
+	} else if from, to, ok := strings.Cut(rng, ".."); ok {
+		refs = []string{from, to}
+	}
+	for _, ref := range refs {
+		if ref == "" {
+			continue
+		}
+		if err := VerifyRef(workDir, ref); err != nil && ref != EmptyTree(workDir) {
+			return err
+		}
+	}
+	return nil
+}
+
 func runGit(workDir string, args ...string) string {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = workDir
@@ -94,23 +381,111 @@ func runGit(workDir string, args ...string) string {
 	return strings.TrimSpace(string(out))
 }
 
-// BuildPrompt wraps a user prompt with optional context.
-func BuildPrompt(prompt, context string) string {
-	var b strings.Builder
-	b.WriteString("# Second Opinion Request\n\n")
-	b.WriteString("## Question\n\n")
-	b.WriteString(prompt)
-	if context != "" {
-		b.WriteString("\n\n## Context\n\n")
-		b.WriteString(context)
-	}
-	b.WriteString("\n\n## Instructions\n\n")
-	b.WriteString("You are providing an independent second opinion. Be critical and thorough.\n")
-	b.WriteString("- Analyze the question in the context provided\n")
-	b.WriteString("- Identify risks, tradeoffs, and blind spots\n")
-	b.WriteString("- Suggest alternatives if you see better approaches\n")
-	b.WriteString("- Be direct and opinionated — don't hedge\n")
-	b.WriteString("- Structure your response with clear headings\n")
-	b.WriteString("- Keep your response focused and actionable\n")
-	return b.String()
+// isRootCommit reports whether rev names a commit without parents.
+func isRootCommit(workDir, rev string) bool {
+	return VerifyRef(workDir, rev) == nil && runGit(workDir, "rev-parse", "--verify", "--quiet", rev+"^") == ""
+}
+
+// PushUpdate is a ref update git passes to a pre-push hook on stdin.
+type PushUpdate struct {
+	LocalRef, LocalSHA, RemoteRef, RemoteSHA string
+}
+
+// ParsePushUpdates reads the "<local ref> <local sha> <remote ref> <remote
+// sha>" lines git writes to the standard input of a pre-push hook.
+func ParsePushUpdates(r io.Reader) ([]PushUpdate, error) {
+	var updates []PushUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected pre-push input %q", scanner.Text())
+		}
+		updates = append(updates, PushUpdate{LocalRef: fields[0], LocalSHA: fields[1], RemoteRef: fields[2], RemoteSHA: fields[3]})
+	}
+	return updates, scanner.Err()
+}
+
+// Deletes reports whether the update deletes the remote ref.
+func (u PushUpdate) Deletes() bool {
+	return isZeroSHA(u.LocalSHA)
+}
+
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// PushRange returns the commits an update pushed to remote sends, as a diff
+// range. A new remote ref is compared with its merge base with the remote's
+// default branch or, failing that, with the parent of the oldest commit not
+// on any remote-tracking branch. ok is false when the update deletes a ref.
+func PushRange(workDir, remote string, u PushUpdate) (rng string, ok bool) {
+	if u.Deletes() {
+		return "", false
+	}
+	if !isZeroSHA(u.RemoteSHA) && VerifyRef(workDir, u.RemoteSHA) == nil {
+		return u.RemoteSHA + ".." + u.LocalSHA, true
+	}
+	if base := runGit(workDir, "merge-base", u.LocalSHA, "refs/remotes/"+remote+"/HEAD"); base != "" {
+		return base + ".." + u.LocalSHA, true
+	}
+	newCommits := runGit(workDir, "rev-list", "--topo-order", "--reverse", u.LocalSHA, "--not", "--remotes")
+	oldest, _, _ := strings.Cut(newCommits, "\n")
+	if oldest == "" {
+		return u.LocalSHA + ".." + u.LocalSHA, true // everything is on the remote already
+	}
+	if parent := runGit(workDir, "rev-parse", "--verify", "--quiet", oldest+"^"); parent != "" {
+		return parent + ".." + u.LocalSHA, true
+	}
+	return EmptyTree(workDir) + ".." + u.LocalSHA, true
+}
+
+// EmptyTree returns the ID of the empty tree in the repository containing
+// workDir. Diffing a commit against it shows all its files as added, which
+// is what a root commit introduces.
+func EmptyTree(workDir string) string {
+	return runGit(workDir, "hash-object", "-t", "tree", os.DevNull)
+}
+
+// HasChanges reports whether diffRange changes any files.
+func HasChanges(workDir, diffRange string) bool {
+	return diffFor(workDir, diffRange, "--name-only") != ""
+}
+
+// HooksDir returns the directory git runs hooks from for the repository
+// containing workDir, honouring core.hooksPath.
+func HooksDir(workDir string) (string, error) {
+	dir := runGit(workDir, "rev-parse", "--git-path", "hooks")
+	if dir == "" {
+		return "", fmt.Errorf("not a git repository: %s", workDir)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+	return dir, nil
+}
+
+// RepoState describes the git checkout a raid was started from.
+type RepoState struct {
+	Head   string
+	Branch string
+	Dirty  bool
+}
+
+// GitState returns the HEAD commit, current branch and dirty flag of the
+// repository containing workDir. ok is false outside a git repository.
+func GitState(workDir string) (state RepoState, ok bool) {
+	head := runGit(workDir, "rev-parse", "HEAD")
+	if head == "" {
+		return RepoState{}, false
+	}
+	state.Head = head
+	if branch := runGit(workDir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "HEAD" {
+		state.Branch = branch
+	}
+	state.Dirty = runGit(workDir, "status", "--porcelain") != ""
+	return state, true
 }
@@ -1,7 +1,9 @@
 package gather
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -14,7 +16,7 @@ func TestGatherFiles(t *testing.T) {
 	os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello world"), 0o600)
 	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("x", 200*1024)), 0o600)
 
-	result, err := Gather([]string{"hello.txt"}, 50, dir)
+	result, err := Gather([]string{"hello.txt"}, 12800, dir)
 	assert.NoError(t, err)
 	assert.Contains(t, result, "hello world")
 	assert.Contains(t, result, "#### hello.txt")
@@ -24,48 +26,523 @@ func TestGatherFilesOverBudget(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("x", 200*1024)), 0o600)
 
-	result, err := Gather([]string{"big.txt"}, 50, dir)
+	result, err := Gather([]string{"big.txt"}, 12800, dir)
 	assert.NoError(t, err)
-	assert.Empty(t, result) // file too large, skipped; no git repo so no diff
+	assert.NotContains(t, result, "xxx") // file too large, skipped; no git repo so no diff
+	assert.Contains(t, result, "- big.txt: too large (200.0 KB)")
 }
 
 func TestGatherMissingFile(t *testing.T) {
 	dir := t.TempDir()
-	result, err := Gather([]string{"nonexistent.txt"}, 50, dir)
+	result, err := Gather([]string{"nonexistent.txt"}, 12800, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "### Files Referenced\n\nDropped (1):\n- nonexistent.txt: not found", result)
+
+	result, err = Gather(nil, 12800, dir)
 	assert.NoError(t, err)
 	assert.Empty(t, result)
 }
 
-func TestBuildPrompt(t *testing.T) {
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0o700))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+}
+
+func TestGatherDirectoriesAndGlobs(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"internal/core/a.go":      "package core // a",
+		"internal/core/a_test.go": "package core // test",
+		"internal/core/sub/b.go":  "package sub // b",
+		"internal/core/logo.png":  "\x89PNG\x00\x00",
+		"internal/other/c.go":     "package other // c",
+		"internal/other/c.md":     "# notes",
+		".git/config":             "[core]",
+	})
+
+	res, err := GatherRange([]string{"internal/core"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"internal/core/a.go", "internal/core/a_test.go", "internal/core/sub/b.go"}, res.Included)
+	assert.Equal(t, []Dropped{{Path: "internal/core/logo.png", Reason: "binary"}}, res.Dropped)
+	assert.Contains(t, res.Context, "Included (3):\n- internal/core/a.go\n")
+	assert.Contains(t, res.Context, "Dropped (1):\n- internal/core/logo.png: binary")
+	assert.Contains(t, res.Context, "#### internal/core/sub/b.go")
+
+	res, err = GatherRange([]string{"internal/**/*.go", "!*_test.go", "internal/other/c.go", "nothing/**"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"internal/core/a.go", "internal/core/sub/b.go", "internal/other/c.go"}, res.Included)
+	assert.Equal(t, []Dropped{{Path: "nothing/**", Reason: "no matching files"}}, res.Dropped)
+
+	res, err = GatherRange([]string{"internal", "!internal/other/**"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, res.Included, "internal/other/c.go")
+	assert.Contains(t, res.Included, "internal/core/a.go")
+
+	// A name without a slash excludes directories of that name at any depth.
+	res, err = GatherRange([]string{"internal", "!sub", "!other/"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"internal/core/a.go", "internal/core/a_test.go"}, res.Included)
+}
+
+func TestGatherSkipsSensitiveFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app/main.go":       "package main",
+		"app/.env":          "DB_PASS=hunter2hunter2",
+		"app/.env.example":  "DB_PASS=",
+		"app/certs/tls.pem": "-----BEGIN CERTIFICATE-----",
+		"app/id_rsa":        "key",
+		"app/id_rsa.pub":    "ssh-rsa AAAA",
+	})
+
+	res, err := GatherRange([]string{"app", "!*.pem"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/.env.example", "app/id_rsa.pub", "app/main.go"}, res.Included)
+	assert.Equal(t, []Dropped{
+		{Path: "app/.env", Reason: "sensitive file (name it explicitly to include it)"},
+		{Path: "app/id_rsa", Reason: "sensitive file (name it explicitly to include it)"},
+	}, res.Dropped)
+
+	res, err = GatherRange([]string{"app/*", "app/.env"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Included, "app/.env", "named explicitly")
+	assert.Equal(t, []Dropped{{Path: "app/id_rsa", Reason: "sensitive file (name it explicitly to include it)"}}, res.Dropped)
+}
+
+func TestGatherRespectsGitignore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	out, err := exec.Command("git", "init", "-q", dir).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	writeTree(t, dir, map[string]string{
+		".gitignore":       "build/\n*.log\n",
+		"src/main.go":      "package main",
+		"src/debug.log":    "noise",
+		"build/gen.go":     "package gen",
+		"src/vendor/v.txt": "vendored",
+	})
+
+	res, err := GatherRange([]string{"."}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", "src/main.go", "src/vendor/v.txt"}, res.Included)
+
+	// Files named explicitly are included even when ignored.
+	res, err = GatherRange([]string{"src/debug.log"}, 12800, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"src/debug.log"}, res.Included)
+}
+
+func TestGatherBudget(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt": strings.Repeat("a", 700),
+		"b.txt": strings.Repeat("b", 700),
+		"c.txt": "small",
+	})
+	res, err := GatherRange([]string{"a.txt", "b.txt", "c.txt"}, 300, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "c.txt"}, res.Included)
+	assert.Equal(t, []Dropped{{Path: "b.txt", Reason: "context budget exhausted (0.7 KB)"}}, res.Dropped)
+	assert.Equal(t, 300, res.BudgetTokens)
+	assert.Equal(t, EstimateTokens(res.Context), res.UsedTokens)
+}
+
+func TestMatchPattern(t *testing.T) {
 	tests := []struct {
-		name    string
-		prompt  string
-		context string
-		wantAll []string
+		pattern, name string
+		want          bool
 	}{
-		{
-			"with context",
-			"How should I refactor this?",
-			"some code here",
-			[]string{"## Question", "How should I refactor this?", "## Context", "some code here", "## Instructions"},
-		},
-		{
-			"without context",
-			"What is the best approach?",
-			"",
-			[]string{"## Question", "What is the best approach?", "## Instructions"},
-		},
+		{"internal/**/*.go", "internal/a.go", true},
+		{"internal/**/*.go", "internal/x/y/a.go", true},
+		{"internal/**/*.go", "cmd/a.go", false},
+		{"*_test.go", "deep/dir/x_test.go", true},
+		{"**/testdata/**", "pkg/testdata/in.txt", true},
+		{"docs/*.md", "docs/sub/a.md", false},
 	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchPattern(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
 
+func TestMatchExclude(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"vendor", "vendor/github.com/x/y.go", true},
+		{"node_modules", "web/node_modules/pkg/index.js", true},
+		{"vendor", "internal/vendored.go", false},
+		{"*_test.go", "pkg/a_test.go", true},
+		{"internal/other", "internal/other/c.go", true},
+		{"internal/other", "cmd/internal/other/c.go", false},
+		{"internal/**/*.go", "internal/x/a.go", true},
+	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := BuildPrompt(tt.prompt, tt.context)
-			for _, want := range tt.wantAll {
-				assert.Contains(t, got, want)
-			}
-			if tt.context == "" {
-				assert.NotContains(t, got, "## Context")
+		assert.Equal(t, tt.want, matchExclude(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
+
+func TestGitStateOutsideRepo(t *testing.T) {
+	_, ok := GitState(t.TempDir())
+	assert.False(t, ok)
+}
+
+func TestGitState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	git("init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	state, ok := GitState(dir)
+	assert.True(t, ok)
+	assert.Len(t, state.Head, 40)
+	assert.Equal(t, "main", state.Branch)
+	assert.False(t, state.Dirty)
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0o600)
+	state, _ = GitState(dir)
+	assert.True(t, state.Dirty)
+}
+
+func TestGatherRangeAndPushRange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := t.TempDir()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "--bare", remote)
+	git("init", "-q", "-b", "main")
+	git("remote", "add", "origin", remote)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	first := git("rev-parse", "HEAD")
+
+	// The first push of a new history reviews all of it.
+	rng, ok := PushRange(dir, "origin", PushUpdate{"refs/heads/main", first, "refs/heads/main", zeroSHA})
+	assert.True(t, ok)
+	assert.Equal(t, EmptyTree(dir)+".."+first, rng)
+	assert.NoError(t, VerifyRange(dir, rng))
+	assert.True(t, HasChanges(dir, rng))
+
+	git("push", "-q", "-u", "origin", "main")
+	rng, ok = PushRange(dir, "origin", PushUpdate{"refs/heads/main", first, "refs/heads/main", first})
+	assert.True(t, ok)
+	assert.False(t, HasChanges(dir, rng))
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0o600)
+	git("commit", "-q", "-am", "change")
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("uncommitted"), 0o600)
+	head := git("rev-parse", "HEAD")
+	rng, ok = PushRange(dir, "origin", PushUpdate{"refs/heads/main", head, "refs/heads/main", first})
+	assert.True(t, ok)
+	assert.Equal(t, first+".."+head, rng)
+	assert.True(t, HasChanges(dir, rng))
+
+	res, err := GatherRange(nil, 12800, dir, DiffSpec{Range: rng}, nil, nil)
+	assert.NoError(t, err)
+	result := res.Context
+	assert.Contains(t, result, "### Changes in "+rng+" (Git Diff)")
+	assert.Contains(t, result, "+two")
+	assert.Contains(t, result, "### Commits in "+rng)
+	assert.Contains(t, result, "    change")
+	assert.NotContains(t, result, "uncommitted")
+
+	hooks, err := HooksDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), hooks)
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0o700)
+	hooks, err = HooksDir(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), filepath.Clean(hooks))
+}
+
+func TestGatherRootCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("committed\n"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	root := git("rev-parse", "HEAD")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("uncommitted\n"), 0o600)
+
+	// A root commit is what it adds, not how the working tree differs.
+	rng := root + "^!"
+	assert.Equal(t, []string{EmptyTree(dir), root}, rangeRevs(dir, rng))
+	assert.True(t, HasChanges(dir, rng))
+	res, err := GatherRange(nil, 12800, dir, DiffSpec{Range: rng, Stat: true}, nil, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Context, "+committed")
+	assert.Contains(t, res.Context, "a.txt | 1 +")
+	assert.NotContains(t, res.Context, "uncommitted")
+
+	git("commit", "-q", "-am", "change")
+	assert.Equal(t, []string{"HEAD^!"}, rangeRevs(dir, "HEAD^!"))
+}
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+func TestPushRangeNewBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := t.TempDir()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "--bare", remote)
+	git("init", "-q", "-b", "main")
+	git("remote", "add", "origin", remote)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	git("push", "-q", "origin", "main")
+	base := git("rev-parse", "HEAD")
+
+	git("checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0o600)
+	git("commit", "-q", "-am", "one")
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("three\n"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "two")
+	head := git("rev-parse", "HEAD")
+	push := PushUpdate{"refs/heads/feature", head, "refs/heads/feature", zeroSHA}
+
+	// Without a known default branch, the commits not on the remote.
+	rng, ok := PushRange(dir, "origin", push)
+	assert.True(t, ok)
+	assert.Equal(t, base+".."+head, rng)
+
+	// With one, the merge base with it.
+	git("remote", "set-head", "origin", "main")
+	rng, ok = PushRange(dir, "origin", push)
+	assert.True(t, ok)
+	assert.Equal(t, base+".."+head, rng)
+	assert.Contains(t, runGit(dir, "diff", "--name-only", rng), "b.txt")
+
+	// A new branch at a commit the remote has changes nothing.
+	rng, ok = PushRange(dir, "other", PushUpdate{"refs/heads/copy", base, "refs/heads/copy", zeroSHA})
+	assert.True(t, ok)
+	assert.False(t, HasChanges(dir, rng))
+
+	_, ok = PushRange(dir, "origin", PushUpdate{"(delete)", zeroSHA, "refs/heads/feature", head})
+	assert.False(t, ok, "deleting a ref pushes nothing")
+}
+
+func TestParsePushUpdates(t *testing.T) {
+	updates, err := ParsePushUpdates(strings.NewReader("refs/heads/a 1111 refs/heads/a 0000\n\n(delete) 0000 refs/heads/b 2222\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []PushUpdate{
+		{"refs/heads/a", "1111", "refs/heads/a", "0000"},
+		{"(delete)", "0000", "refs/heads/b", "2222"},
+	}, updates)
+	assert.False(t, updates[0].Deletes())
+	assert.True(t, updates[1].Deletes())
+
+	_, err = ParsePushUpdates(strings.NewReader("refs/heads/a 1111\n"))
+	assert.Error(t, err)
+}
+
+func TestGatherDiffSpec(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	lines := func(n int, changed int) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if i == changed {
+				b.WriteString("changed\n")
+			} else {
+				fmt.Fprintf(&b, "line %d\n", i)
 			}
-		})
+		}
+		return b.String()
+	}
+	git("init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte(lines(20, 0)), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	git("checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte(lines(20, 10)), 0o600)
+	git("commit", "-q", "-am", "Change line ten", "-m", "Explains why.")
+	sha := git("rev-parse", "--short", "HEAD")
+	git("checkout", "-q", "main")
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("main only\n"), 0o600)
+	git("add", ".")
+	git("commit", "-q", "-m", "Main moves on")
+	git("checkout", "-q", "feature")
+
+	assert.NoError(t, VerifyRef(dir, "main"))
+	assert.Error(t, VerifyRef(dir, "no-such-branch"))
+	assert.NoError(t, VerifyRange(dir, "main...HEAD"))
+	assert.NoError(t, VerifyRange(dir, "..main"))
+	assert.NoError(t, VerifyRange(dir, sha+"^!"))
+	assert.EqualError(t, VerifyRange(dir, "main..nope"), `unknown git revision "nope"`)
+	assert.EqualError(t, VerifyRange(dir, "nope"), `unknown git revision "nope"`)
+
+	res, err := GatherRange(nil, 12800, dir, DiffSpec{Range: "main...HEAD", Stat: true, ContextLines: 1}, nil, nil)
+	assert.NoError(t, err)
+	ctx := res.Context
+	assert.Contains(t, ctx, "### Commits in main...HEAD\n\n<<<UNTRUSTED-CONTENT "+res.Boundary+">>>\n```\n"+sha+" ")
+	assert.Contains(t, ctx, "    Change line ten\n    \n    Explains why.")
+	assert.NotContains(t, ctx, "Main moves on", "symmetric ranges list only the branch's commits")
+	assert.Contains(t, ctx, "### Diffstat for main...HEAD")
+	assert.Contains(t, ctx, "a.txt | 2 +-")
+	assert.Contains(t, ctx, "@@ -9,3 +9,3 @@")
+	assert.NotContains(t, ctx, "b.txt", "three-dot diffs start at the merge base")
+	assert.Less(t, strings.Index(ctx, "### Commits"), strings.Index(ctx, "### Changes in"))
+
+	res, err = GatherRange(nil, 12800, dir, DiffSpec{Range: sha + "^!"}, nil, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Context, "@@ -7,7 +7,7 @@")
+	assert.Contains(t, res.Context, "Change line ten")
+
+	res, err = GatherRange(nil, 30, dir, DiffSpec{Range: "main...HEAD"}, nil, nil)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(res.Context), 30*bytesPerToken+150+len(untrustedPreamble(res.Boundary, nil)))
+	assert.Contains(t, res.Dropped, Dropped{Path: "Changes in main...HEAD (Git Diff)", Reason: "context budget exhausted"})
+}
+
+func numbered(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %03d\n", i)
+	}
+	return b.String()
+}
+
+func TestTruncateFile(t *testing.T) {
+	text := numbered(200) // 9 bytes per line
+
+	got, reason := truncateFile(text, nil, 500)
+	assert.Equal(t, "kept first 52 of 200 lines", reason)
+	assert.True(t, strings.HasPrefix(got, "line 001\n"))
+	assert.True(t, strings.HasSuffix(got, "line 052\n... [lines 53-200 omitted] ...\n"))
+	assert.LessOrEqual(t, len(got), 500)
+
+	got, reason = truncateFile(text, []lineRange{{50, 50}, {150, 151}}, 2000)
+	assert.Equal(t, "kept 83 of 200 lines around 2 changed region(s)", reason)
+	assert.True(t, strings.HasPrefix(got, "... [lines 1-29 omitted] ...\nline 030\n"))
+	assert.Contains(t, got, "line 070\n... [lines 71-129 omitted] ...\nline 130\n")
+	assert.True(t, strings.HasSuffix(got, "line 171\n... [lines 172-200 omitted] ...\n"))
+
+	// Overlapping windows merge into one region.
+	_, reason = truncateFile(text, []lineRange{{100, 100}, {120, 120}}, 2000)
+	assert.Equal(t, "kept 61 of 200 lines around 1 changed region(s)", reason)
+
+	got, _ = truncateFile(strings.Repeat("x", 5000), nil, 1024)
+	assert.Empty(t, got, "a single line too long to fit")
+}
+
+func TestTruncateDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n" +
+		"@@ -1,2 +1,2 @@\n-one\n+ONE\n" +
+		"@@ -10,2 +10,2 @@\n-ten\n+TEN\n" +
+		"diff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n" +
+		"@@ -5 +5 @@\n-five\n+FIVE"
+
+	got, kept, total := truncateDiff(diff, len(diff))
+	assert.Equal(t, diff, got)
+	assert.Equal(t, 3, kept)
+	assert.Equal(t, 3, total)
+
+	got, kept, total = truncateDiff(diff, 100)
+	assert.Equal(t, "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-one\n+ONE", got)
+	assert.Equal(t, 1, kept)
+	assert.Equal(t, 3, total)
+}
+
+func TestChangedLines(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -3 +3,2 @@\n@@ -20,3 +21,0 @@\n" +
+		"diff --git a/gone.go b/gone.go\n--- a/gone.go\n+++ /dev/null\n@@ -1,4 +0,0 @@\n"
+	assert.Equal(t, map[string][]lineRange{"a.go": {{3, 4}, {21, 21}}}, changedLines(diff))
+}
+
+func TestGatherPrioritizesAndTruncates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	writeTree(t, dir, map[string]string{
+		"pkg/a.go":   numbered(20),
+		"pkg/big.go": numbered(1000),
+		"notes.txt":  "notes",
+	})
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	os.WriteFile(filepath.Join(dir, "pkg/big.go"), []byte(strings.Replace(numbered(1000), "line 500\n", "CHANGED\n", 1)), 0o600)
+
+	res, err := GatherRange([]string{"pkg", "notes.txt"}, 2000, dir, DiffSpec{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt", "pkg/big.go", "pkg/a.go"}, res.Included, "explicit, then changed, then related")
+	if assert.Len(t, res.Truncated, 1) {
+		assert.Equal(t, "pkg/big.go", res.Truncated[0].Path)
+		assert.Contains(t, res.Truncated[0].Reason, "around 1 changed region(s)")
 	}
+	assert.Contains(t, res.Context, "- pkg/big.go (truncated)")
+	assert.Contains(t, res.Context, "#### pkg/big.go [truncated]")
+	assert.Contains(t, res.Context, "line 499\nCHANGED\nline 501")
+	assert.Contains(t, res.Context, "... [lines 1-479 omitted] ...")
+	assert.Contains(t, res.Context, "### Recent Changes (Git Diff)\n", "the diff keeps its share of the budget")
+	assert.LessOrEqual(t, res.UsedTokens, 2000+200)
 }
@@ -0,0 +1,446 @@
+package gather
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Snippet is the source of a Go declaration selected for the context.
+type Snippet struct {
+	Symbol    string // package-qualified name, e.g. runner.Runner.execTool
+	File      string // slash-separated, relative to the working directory
+	StartLine int
+	EndLine   int
+	Source    string
+	Via       string // how it relates to a requested symbol; "" when requested
+}
+
+// Label names the snippet and where it comes from.
+func (s Snippet) Label() string {
+	return fmt.Sprintf("%s (%s:%d-%d)", s.Symbol, s.File, s.StartLine, s.EndLine)
+}
+
+// FindSymbols extracts the declarations named by symbols from the Go module
+// containing workDir. A symbol is a package and a name, as in
+// "internal/runner.Runner.execTool" or "gather.GatherRange": the package is
+// a directory relative to the module root, an import path, or a package name
+// that is unique in the module.
+//
+// With depth > 0 the functions and types that each declaration refers to,
+// and the declarations referring to it, are added too, up to depth steps
+// away. References are resolved by name within the module, without type
+// checking, so a method call matches when only one method of that name
+// exists in the package and the module packages it imports.
+func FindSymbols(workDir string, symbols []string, depth int) ([]Snippet, error) {
+	m, err := loadModule(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []*goDecl
+	via := make(map[*goDecl]string)
+	for _, s := range symbols {
+		d, err := m.lookup(s)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := via[d]; !ok {
+			via[d] = ""
+			order = append(order, d)
+		}
+	}
+
+	if depth > 0 {
+		refs, refBy := m.references()
+		frontier := order
+		for range depth {
+			var next []*goDecl
+			visit := func(d *goDecl, how string) {
+				if _, ok := via[d]; !ok {
+					via[d] = how
+					next = append(next, d)
+				}
+			}
+			for _, d := range frontier {
+				for _, t := range refs[d] {
+					if t.kind == funcDecl {
+						visit(t, "called by "+d.qualified())
+					} else {
+						visit(t, "used by "+d.qualified())
+					}
+				}
+				for _, c := range refBy[d] {
+					if d.kind == funcDecl {
+						visit(c, "calls "+d.qualified())
+					} else {
+						visit(c, "uses "+d.qualified())
+					}
+				}
+			}
+			order = append(order, next...)
+			frontier = next
+		}
+	}
+
+	snippets := make([]Snippet, 0, len(order))
+	for _, d := range order {
+		snippets = append(snippets, m.snippet(workDir, d, via[d]))
+	}
+	return snippets, nil
+}
+
+type declKind int
+
+const (
+	funcDecl declKind = iota
+	typeDecl
+	valueDecl
+)
+
+// goModule is the parsed non-test Go source of a module.
+type goModule struct {
+	root string // absolute directory holding go.mod
+	path string // module path
+	fset *token.FileSet
+	pkgs []*goPackage
+}
+
+type goPackage struct {
+	dir        string // slash-separated, relative to the module root; "." for the root
+	importPath string
+	name       string
+	files      []*goFile
+	decls      map[string]*goDecl   // by name, or Type.method for methods
+	methods    map[string][]*goDecl // by method name
+}
+
+type goFile struct {
+	path    string // slash-separated, relative to the module root
+	src     []byte
+	ast     *ast.File
+	imports map[string]*goPackage // module packages by the name they are imported as
+}
+
+type goDecl struct {
+	pkg        *goPackage
+	file       *goFile
+	key        string
+	kind       declKind
+	node       ast.Node
+	start, end token.Pos
+}
+
+func (d *goDecl) qualified() string {
+	return d.pkg.name + "." + d.key
+}
+
+var moduleLine = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+// loadModule finds the module containing workDir and parses its packages,
+// skipping tests, vendor and testdata directories and files that fail to
+// parse.
+func loadModule(workDir string) (*goModule, error) {
+	root, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	var gomod []byte
+	for {
+		if gomod, err = os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return nil, fmt.Errorf("no go.mod found in %s or its parents", workDir)
+		}
+		root = parent
+	}
+	match := moduleLine.FindSubmatch(gomod)
+	if match == nil {
+		return nil, fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+	}
+	m := &goModule{root: root, path: string(match[1]), fset: token.NewFileSet()}
+
+	byDir := make(map[string]*goPackage)
+	for _, f := range listFiles(root) {
+		if !isPackageSource(f) {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(f)))
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(m.fset, f, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		dir := path.Dir(f)
+		pkg := byDir[dir]
+		if pkg == nil {
+			importPath := m.path
+			if dir != "." {
+				importPath += "/" + dir
+			}
+			pkg = &goPackage{dir: dir, importPath: importPath, name: file.Name.Name,
+				decls: make(map[string]*goDecl), methods: make(map[string][]*goDecl)}
+			byDir[dir] = pkg
+			m.pkgs = append(m.pkgs, pkg)
+		}
+		if file.Name.Name != pkg.name {
+			continue
+		}
+		gf := &goFile{path: f, src: src, ast: file}
+		pkg.files = append(pkg.files, gf)
+		pkg.index(gf)
+	}
+
+	byImport := make(map[string]*goPackage, len(m.pkgs))
+	for _, p := range m.pkgs {
+		byImport[p.importPath] = p
+	}
+	for _, p := range m.pkgs {
+		for _, f := range p.files {
+			f.imports = make(map[string]*goPackage)
+			for _, imp := range f.ast.Imports {
+				target := byImport[strings.Trim(imp.Path.Value, `"`)]
+				if target == nil {
+					continue
+				}
+				name := target.name
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				f.imports[name] = target
+			}
+		}
+	}
+	return m, nil
+}
+
+func isPackageSource(f string) bool {
+	if !strings.HasSuffix(f, ".go") || strings.HasSuffix(f, "_test.go") {
+		return false
+	}
+	for _, seg := range strings.Split(path.Dir(f), "/") {
+		if seg == "vendor" || seg == "testdata" || (seg != "." && (strings.HasPrefix(seg, ".") || strings.HasPrefix(seg, "_"))) {
+			return false
+		}
+	}
+	return true
+}
+
+// index records the top-level declarations of f.
+func (p *goPackage) index(f *goFile) {
+	add := func(d *goDecl) {
+		if d.key == "_" || d.key == "init" || p.decls[d.key] != nil {
+			return
+		}
+		p.decls[d.key] = d
+	}
+	for _, decl := range f.ast.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			d := &goDecl{pkg: p, file: f, key: decl.Name.Name, kind: funcDecl, node: decl,
+				start: docStart(decl.Doc, decl.Pos()), end: decl.End()}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				d.key = receiverType(decl.Recv.List[0].Type) + "." + d.key
+				p.methods[decl.Name.Name] = append(p.methods[decl.Name.Name], d)
+			}
+			add(d)
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				// A spec in a group stands alone; otherwise the whole
+				// declaration, with its doc comment, is taken.
+				start, end := docStart(decl.Doc, decl.Pos()), decl.End()
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if decl.Lparen.IsValid() {
+						start, end = docStart(spec.Doc, spec.Pos()), spec.End()
+					}
+					add(&goDecl{pkg: p, file: f, key: spec.Name.Name, kind: typeDecl, node: spec, start: start, end: end})
+				case *ast.ValueSpec:
+					if decl.Lparen.IsValid() {
+						start, end = docStart(spec.Doc, spec.Pos()), spec.End()
+					}
+					for _, name := range spec.Names {
+						add(&goDecl{pkg: p, file: f, key: name.Name, kind: valueDecl, node: spec, start: start, end: end})
+					}
+				}
+			}
+		}
+	}
+}
+
+func docStart(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+// receiverType returns the type name of a method receiver.
+func receiverType(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(e.X)
+	case *ast.IndexExpr:
+		return receiverType(e.X)
+	case *ast.IndexListExpr:
+		return receiverType(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// lookup resolves a symbol as described in FindSymbols.
+func (m *goModule) lookup(symbol string) (*goDecl, error) {
+	dir, last := "", symbol
+	if i := strings.LastIndex(symbol, "/"); i >= 0 {
+		dir, last = symbol[:i+1], symbol[i+1:]
+	}
+	pkgName, name, ok := strings.Cut(last, ".")
+	if !ok || pkgName == "" || name == "" {
+		return nil, fmt.Errorf("invalid symbol %q: want <package>.<name> or <package>.<Type>.<method>", symbol)
+	}
+	pkgPath := dir + pkgName
+
+	var pkg *goPackage
+	for _, p := range m.pkgs {
+		if p.importPath == pkgPath || p.dir == path.Clean(pkgPath) {
+			pkg = p
+			break
+		}
+	}
+	if pkg == nil && dir == "" {
+		var named []string
+		for _, p := range m.pkgs {
+			if p.name == pkgName {
+				pkg = p
+				named = append(named, p.dir)
+			}
+		}
+		if len(named) > 1 {
+			slices.Sort(named)
+			return nil, fmt.Errorf("package name %q is ambiguous in %s (use a directory: %s)", pkgName, m.path, strings.Join(named, ", "))
+		}
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("package %q not found in module %s", pkgPath, m.path)
+	}
+	d := pkg.decls[name]
+	if d == nil {
+		return nil, fmt.Errorf("symbol %q not found in package %s", name, pkg.dir)
+	}
+	return d, nil
+}
+
+// references returns, for every function and type in the module, the
+// functions and types it refers to and those referring to it.
+func (m *goModule) references() (refs, refBy map[*goDecl][]*goDecl) {
+	refs = make(map[*goDecl][]*goDecl)
+	refBy = make(map[*goDecl][]*goDecl)
+	for _, p := range m.pkgs {
+		for _, f := range p.files {
+			for _, d := range declsOf(p, f) {
+				for _, t := range d.refers() {
+					refs[d] = append(refs[d], t)
+					refBy[t] = append(refBy[t], d)
+				}
+			}
+		}
+	}
+	return refs, refBy
+}
+
+// declsOf returns the declarations indexed from f, in source order.
+func declsOf(p *goPackage, f *goFile) []*goDecl {
+	var decls []*goDecl
+	for _, d := range p.decls {
+		if d.file == f {
+			decls = append(decls, d)
+		}
+	}
+	slices.SortFunc(decls, func(a, b *goDecl) int { return int(a.start - b.start) })
+	return decls
+}
+
+// refers returns the functions and types of the module that d names, in
+// order of first use.
+func (d *goDecl) refers() []*goDecl {
+	var out []*goDecl
+	seen := map[*goDecl]bool{d: true}
+	add := func(t *goDecl) {
+		if t != nil && t.kind != valueDecl && !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok {
+				if p := d.file.imports[x.Name]; p != nil {
+					add(p.decls[n.Sel.Name])
+					return false
+				}
+			}
+			add(d.method(n.Sel.Name))
+			// Sel names a field or method, not a top-level declaration.
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Ident:
+			add(d.pkg.decls[n.Name])
+		}
+		return true
+	}
+	ast.Inspect(d.node, visit)
+	return out
+}
+
+// method returns the method that a call of name in d most likely refers
+// to: the only method of that name in d's package or the module packages
+// its file imports.
+func (d *goDecl) method(name string) *goDecl {
+	candidates := d.pkg.methods[name]
+	for _, p := range d.file.imports {
+		if p != d.pkg {
+			candidates = append(slices.Clip(candidates), p.methods[name]...)
+		}
+	}
+	if len(candidates) != 1 {
+		return nil
+	}
+	return candidates[0]
+}
+
+// snippet returns the source of d, naming its file relative to workDir.
+func (m *goModule) snippet(workDir string, d *goDecl, via string) Snippet {
+	start, end := m.fset.Position(d.start), m.fset.Position(d.end)
+	file := d.file.path
+	if abs, err := filepath.Abs(workDir); err == nil {
+		if rel, err := filepath.Rel(abs, filepath.Join(m.root, filepath.FromSlash(file))); err == nil {
+			file = filepath.ToSlash(rel)
+		}
+	}
+	return Snippet{
+		Symbol:    d.qualified(),
+		File:      file,
+		StartLine: start.Line,
+		EndLine:   end.Line,
+		Source:    string(d.file.src[start.Offset:end.Offset]),
+		Via:       via,
+	}
+}
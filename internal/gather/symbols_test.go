@@ -0,0 +1,139 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.22\n",
+		"main.go": `package main
+
+import "example.com/shop/internal/cart"
+
+func main() {
+	cart.New().Add(Item())
+}
+
+func Item() string { return "apple" }
+`,
+		"internal/cart/cart.go": `package cart
+
+// Cart holds items.
+type Cart struct {
+	items []Line
+}
+
+type (
+	// Line is an entry in a cart.
+	Line struct{ Name string }
+	unused int
+)
+
+// New returns an empty cart.
+func New() *Cart { return &Cart{} }
+
+// Add puts an item in the cart.
+func (c *Cart) Add(name string) {
+	c.items = append(c.items, line(name))
+}
+
+func line(name string) Line { return Line{Name: name} }
+`,
+		"internal/cart/cart_test.go":  "package cart\n\nfunc helper() {}\n",
+		"internal/other/cart/cart.go": "package cart\n\nfunc New() {}\n",
+	})
+	return dir
+}
+
+func TestFindSymbols(t *testing.T) {
+	dir := writeModule(t)
+
+	got, err := FindSymbols(dir, []string{"internal/cart.Cart.Add", "example.com/shop/internal/cart.Line", "main.Item"}, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, Snippet{
+			Symbol:    "cart.Cart.Add",
+			File:      "internal/cart/cart.go",
+			StartLine: 17,
+			EndLine:   20,
+			Source:    "// Add puts an item in the cart.\nfunc (c *Cart) Add(name string) {\n\tc.items = append(c.items, line(name))\n}",
+		}, got[0])
+		assert.Equal(t, "// Line is an entry in a cart.\n\tLine struct{ Name string }", got[1].Source, "a spec in a group stands alone")
+		assert.Equal(t, "main.Item (main.go:9-9)", got[2].Label())
+	}
+
+	got, err = FindSymbols(dir+"/internal", []string{"internal/cart.New"}, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "cart/cart.go", got[0].File, "named relative to the working directory")
+	}
+
+	for symbol, want := range map[string]string{
+		"cart.New":              `package name "cart" is ambiguous in example.com/shop (use a directory: internal/cart, internal/other/cart)`,
+		"internal/cart.Missing": `symbol "Missing" not found in package internal/cart`,
+		"internal/cart.helper":  `symbol "helper" not found in package internal/cart`,
+		"nowhere.Func":          `package "nowhere" not found in module example.com/shop`,
+		"internal/cart":         `invalid symbol "internal/cart": want <package>.<name> or <package>.<Type>.<method>`,
+	} {
+		_, err := FindSymbols(dir, []string{symbol}, 0)
+		assert.EqualError(t, err, want, symbol)
+	}
+
+	_, err = FindSymbols(t.TempDir(), []string{"main.Item"}, 0)
+	assert.ErrorContains(t, err, "no go.mod found")
+}
+
+func TestFindSymbolsDepth(t *testing.T) {
+	dir := writeModule(t)
+
+	got, err := FindSymbols(dir, []string{"internal/cart.Cart.Add"}, 1)
+	assert.NoError(t, err)
+	var labels []string
+	for _, s := range got {
+		labels = append(labels, s.Symbol+": "+s.Via)
+	}
+	assert.Equal(t, []string{
+		"cart.Cart.Add: ",
+		"cart.Cart: used by cart.Cart.Add",
+		"cart.line: called by cart.Cart.Add",
+		"main.main: calls cart.Cart.Add",
+	}, labels)
+
+	got, err = FindSymbols(dir, []string{"main.main"}, 2)
+	assert.NoError(t, err)
+	labels = nil
+	for _, s := range got {
+		labels = append(labels, s.Symbol+": "+s.Via)
+	}
+	assert.Equal(t, []string{
+		"main.main: ",
+		"cart.Cart.Add: called by main.main",
+		"cart.New: called by main.main",
+		"main.Item: called by main.main",
+		"cart.Cart: used by cart.Cart.Add",
+		"cart.line: called by cart.Cart.Add",
+	}, labels)
+}
+
+func TestGatherSymbols(t *testing.T) {
+	dir := writeModule(t)
+	symbols, err := FindSymbols(dir, []string{"internal/cart.New", "internal/cart.Cart"}, 0)
+	assert.NoError(t, err)
+
+	res, err := GatherRange(nil, 12800, dir, DiffSpec{}, nil, symbols)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cart.New (internal/cart/cart.go:14-15)", "cart.Cart (internal/cart/cart.go:3-6)"}, res.Included)
+	assert.Contains(t, res.Context, "#### cart.New (internal/cart/cart.go:14-15)\n\n<<<UNTRUSTED-CONTENT "+res.Boundary+">>>\n```go\n// New returns an empty cart.\nfunc New() *Cart { return &Cart{} }\n```")
+
+	symbols[0].Via = "called by main.main"
+	symbols[1].Source = string(make([]byte, 60000))
+	res, err = GatherRange(nil, 12800, dir, DiffSpec{}, nil, symbols)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Context, "#### cart.New (internal/cart/cart.go:14-15), called by main.main\n")
+	assert.Equal(t, []Dropped{{Path: "cart.Cart (internal/cart/cart.go:3-6)", Reason: "context budget exhausted (58.6 KB)"}}, res.Dropped)
+}
@@ -0,0 +1,316 @@
+package output
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codebeauty/horde/internal/secrets"
+)
+
+// maxImportSize bounds the uncompressed size of an imported archive.
+const maxImportSize = 1 << 30
+
+// ExportOptions controls what ExportRun writes to an archive.
+type ExportOptions struct {
+	Redact   bool // replace secrets in text files with placeholders
+	NoStderr bool // leave out agent stderr files
+}
+
+// ExportRun writes runDir as a gzipped tarball to w. All entries live under
+// a single directory named after the run. It returns the number of secrets
+// redacted.
+func ExportRun(runDir string, w io.Writer, opts ExportOptions) (int, error) {
+	name := filepath.Base(runDir)
+	skip := make(map[string]bool)
+	var manifest []byte
+	if opts.NoStderr {
+		if m, err := ReadManifest(runDir); err == nil {
+			for i := range m.Results {
+				skip[m.Results[i].StderrFile] = true
+				m.Results[i].StderrFile = ""
+			}
+			if m.Synthesis != nil {
+				skip[m.Synthesis.StderrFile] = true
+				m.Synthesis.StderrFile = ""
+			}
+			data, err := json.MarshalIndent(m, "", "  ")
+			if err != nil {
+				return 0, err
+			}
+			manifest = append(data, '\n')
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	redacted := 0
+
+	err := filepath.WalkDir(runDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(runDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     path.Join(name, rel) + "/",
+				Mode:     0o700,
+				ModTime:  info.ModTime(),
+			})
+		}
+		if !info.Mode().IsRegular() || rel == LockFile {
+			return nil // symlinks, special files and locks are not exported
+		}
+		if opts.NoStderr && (skip[rel] || strings.HasSuffix(rel, ".stderr")) {
+			return nil
+		}
+
+		var data []byte
+		if rel == "run.json" && manifest != nil {
+			data = manifest
+		} else if data, err = os.ReadFile(p); err != nil {
+			return err
+		}
+		if opts.Redact && utf8.Valid(data) {
+			text, n := secrets.Redact(string(data))
+			data = []byte(text)
+			redacted += n
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path.Join(name, rel),
+			Mode:     0o600,
+			Size:     int64(len(data)),
+			ModTime:  info.ModTime(),
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return redacted, gz.Close()
+}
+
+// ExportRunFile writes the archive of runDir to dest atomically.
+func ExportRunFile(runDir, dest string, opts ExportOptions) (int, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".horde-export-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := ExportRun(runDir, tmp, opts)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), dest)
+}
+
+// ImportRun extracts a run archive written by ExportRun into baseDir and
+// returns the new run directory. The archive must hold a single directory
+// with a valid run.json; entries escaping it, links and other special files
+// are rejected. If a run of the same name exists, a numeric suffix is added.
+func ImportRun(r io.Reader, baseDir string) (string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return "", fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(baseDir, 0o700); err != nil {
+		return "", fmt.Errorf("creating output dir: %w", err)
+	}
+	tmp, err := os.MkdirTemp(baseDir, ".horde-import-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	name, err := extractRun(tar.NewReader(gz), tmp)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := ReadManifest(tmp)
+	if err != nil {
+		return "", fmt.Errorf("invalid run archive: %w", err)
+	}
+	if err := validateImportedManifest(m); err != nil {
+		return "", fmt.Errorf("invalid run archive: %w", err)
+	}
+
+	_ = UnlockRun(tmp) // an imported run is never in progress
+
+	dest, err := reserveDir(baseDir, name)
+	if err != nil {
+		return "", err
+	}
+	if err := moveContents(tmp, dest); err != nil {
+		os.RemoveAll(dest)
+		return "", err
+	}
+	_ = IndexRun(dest, m)
+	return dest, nil
+}
+
+// reserveDir creates an empty directory named name in baseDir, or name with
+// a numeric suffix if that exists, and returns its path. Creating it is
+// what claims the name, so concurrent imports never pick the same one.
+func reserveDir(baseDir, name string) (string, error) {
+	dest := filepath.Join(baseDir, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(dest, 0o700)
+		if err == nil {
+			return dest, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		dest = filepath.Join(baseDir, fmt.Sprintf("%s-%d", name, i))
+	}
+}
+
+// moveContents moves the entries of src into the empty directory dest.
+// run.json goes last, so the run is not listed before it is complete.
+func moveContents(src, dest string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries)+1)
+	for _, e := range entries {
+		if e.Name() != "run.json" {
+			names = append(names, e.Name())
+		}
+	}
+	names = append(names, "run.json") // validated, so always present
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(src, name), filepath.Join(dest, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractRun writes the entries of tr below dir and returns the name of the
+// archive's top-level directory.
+func extractRun(tr *tar.Reader, dir string) (string, error) {
+	var top string
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading archive: %w", err)
+		}
+
+		clean := path.Clean(strings.TrimSuffix(hdr.Name, "/"))
+		first, rel, _ := strings.Cut(clean, "/")
+		if !filepath.IsLocal(clean) || strings.Contains(hdr.Name, `\`) || first == "." || first == "" {
+			return "", fmt.Errorf("unsafe path in archive: %q", hdr.Name)
+		}
+		if top == "" {
+			top = first
+		} else if first != top {
+			return "", fmt.Errorf("archive holds more than one run (%q and %q)", top, first)
+		}
+		if rel == "" {
+			if hdr.Typeflag != tar.TypeDir {
+				return "", fmt.Errorf("unexpected entry %q: runs are directories", hdr.Name)
+			}
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			total += hdr.Size
+			if total > maxImportSize {
+				return "", fmt.Errorf("archive exceeds %d bytes", maxImportSize)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return "", err
+			}
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+			if err != nil {
+				return "", err
+			}
+			_, err = io.CopyN(f, tr, hdr.Size)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return "", fmt.Errorf("extracting %s: %w", rel, err)
+			}
+			_ = os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+		default:
+			return "", fmt.Errorf("unsupported entry %q in archive", hdr.Name)
+		}
+	}
+	if top == "" {
+		return "", fmt.Errorf("archive is empty")
+	}
+	return top, nil
+}
+
+// validateImportedManifest checks that a manifest from an archive is one
+// horde can read and that it only references files inside the run.
+func validateImportedManifest(m *Manifest) error {
+	if m.Version > ManifestVersion {
+		return fmt.Errorf("run.json version %d is newer than supported (%d)", m.Version, ManifestVersion)
+	}
+	if m.StartedAt.IsZero() {
+		return fmt.Errorf("run.json has no startedAt")
+	}
+	files := []string{m.FindingsFile}
+	for _, r := range m.Results {
+		files = append(files, r.OutputFile, r.StderrFile)
+	}
+	if s := m.Synthesis; s != nil {
+		files = append(files, s.OutputFile, s.StderrFile)
+	}
+	for _, a := range m.Attachments {
+		files = append(files, a.File)
+	}
+	for _, f := range files {
+		if f != "" && !filepath.IsLocal(f) {
+			return fmt.Errorf("run.json references a file outside the run: %q", f)
+		}
+	}
+	return nil
+}
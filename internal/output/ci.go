@@ -0,0 +1,162 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JUnitFile is the JUnit XML report written by raids in CI mode.
+const JUnitFile = "junit.xml"
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// BuildJUnit renders a run as a JUnit XML report with one test case per
+// agent. Agents that did not succeed are failures carrying their diagnosis
+// and the tail of their stderr.
+func BuildJUnit(m *Manifest, runDir string) ([]byte, error) {
+	suite := junitSuite{
+		Name:      "horde",
+		Tests:     len(m.Results),
+		Time:      junitSeconds(m.Duration),
+		Timestamp: m.StartedAt.UTC().Format(time.RFC3339),
+	}
+	for _, r := range m.Results {
+		class := "horde." + agentName(r.ToolID)
+		if r.Expert != "" {
+			class += "." + r.Expert
+		}
+		tc := junitCase{Name: r.ToolID, ClassName: class, Time: junitSeconds(r.Duration)}
+		if r.Status != "success" {
+			suite.Failures++
+			diag, stderr := reportStderr(runDir, r.ToolID, r.StderrFile, r.ExitCode)
+			msg := fmt.Sprintf("%s (exit %d)", r.Status, r.ExitCode)
+			if diag != nil {
+				msg += ": " + diag.Message
+			}
+			tc.Failure = &junitFailure{Message: msg, Type: r.Status, Body: stderr}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	data, err := xml.MarshalIndent(junitSuites{Suites: []junitSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func junitSeconds(d string) string {
+	dur, err := time.ParseDuration(d)
+	if err != nil {
+		return "0"
+	}
+	return fmt.Sprintf("%.3f", dur.Seconds())
+}
+
+// GitHubAnnotations returns GitHub Actions workflow commands annotating a
+// run: an error per failed agent and one annotation per merged finding,
+// levelled by severity.
+func GitHubAnnotations(m *Manifest, runDir string, findings *FindingsReport) []string {
+	var out []string
+	for _, r := range m.Results {
+		if r.Status == "success" {
+			continue
+		}
+		msg := fmt.Sprintf("%s %s (exit %d)", r.ToolID, r.Status, r.ExitCode)
+		if diag, _ := reportStderr(runDir, r.ToolID, r.StderrFile, r.ExitCode); diag != nil {
+			msg += ": " + diag.Message
+			if diag.Suggestion != "" {
+				msg += "\n" + diag.Suggestion
+			}
+		}
+		out = append(out, workflowCommand("error", map[string]string{"title": "horde: " + r.ToolID + " " + r.Status}, msg))
+	}
+	if findings == nil {
+		return out
+	}
+	for _, f := range findings.Findings {
+		props := map[string]string{"title": fmt.Sprintf("[%s] %s", f.Severity, f.Title)}
+		if f.File != "" {
+			props["file"] = f.File
+			if f.StartLine > 0 {
+				props["line"] = fmt.Sprint(f.StartLine)
+			}
+			if f.EndLine > f.StartLine {
+				props["endLine"] = fmt.Sprint(f.EndLine)
+			}
+		}
+		msg := f.Rationale
+		if f.SuggestedFix != "" {
+			msg += "\nSuggested fix: " + f.SuggestedFix
+		}
+		names := make([]string, len(f.Reporters))
+		for i, r := range f.Reporters {
+			names[i] = r.ToolID
+		}
+		msg = strings.TrimSpace(msg + "\nReported by: " + strings.Join(names, ", "))
+		out = append(out, workflowCommand(annotationLevel(f.Severity), props, msg))
+	}
+	return out
+}
+
+func annotationLevel(severity string) string {
+	switch {
+	case SeverityAtLeast(severity, "high"):
+		return "error"
+	case SeverityAtLeast(severity, "medium"):
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// workflowCommand formats a GitHub Actions workflow command, escaping its
+// properties and message.
+func workflowCommand(level string, props map[string]string, msg string) string {
+	var b strings.Builder
+	b.WriteString("::" + level)
+	sep := " "
+	for _, k := range []string{"file", "line", "endLine", "title"} {
+		if v, ok := props[k]; ok {
+			b.WriteString(sep + k + "=" + escapeProperty(v))
+			sep = ","
+		}
+	}
+	b.WriteString("::" + escapeData(msg))
+	return b.String()
+}
+
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeData(s string) string     { return dataEscaper.Replace(s) }
+func escapeProperty(s string) string { return propertyEscaper.Replace(s) }
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ciManifest(t *testing.T) (*Manifest, string) {
+	t.Helper()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "codex.stderr"), []byte("Error: 401 Unauthorized\n"), 0o600))
+	return &Manifest{
+		StartedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Duration:  "12.5s",
+		Results: []ManifestResult{
+			{ToolID: "claude@security", Expert: "security", Status: "success", Duration: "10s"},
+			{ToolID: "codex", Status: "failed", Duration: "1.25s", ExitCode: 1, StderrFile: "codex.stderr"},
+		},
+	}, dir
+}
+
+func TestBuildJUnit(t *testing.T) {
+	m, dir := ciManifest(t)
+	data, err := BuildJUnit(m, dir)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "<?xml"))
+
+	var doc junitSuites
+	assert.NoError(t, xml.Unmarshal(data, &doc))
+	if assert.Len(t, doc.Suites, 1) {
+		s := doc.Suites[0]
+		assert.Equal(t, 2, s.Tests)
+		assert.Equal(t, 1, s.Failures)
+		assert.Equal(t, "12.500", s.Time)
+		assert.Equal(t, "horde.claude.security", s.Cases[0].ClassName)
+		assert.Nil(t, s.Cases[0].Failure)
+		if assert.NotNil(t, s.Cases[1].Failure) {
+			assert.Equal(t, "failed", s.Cases[1].Failure.Type)
+			assert.Contains(t, s.Cases[1].Failure.Message, "failed (exit 1)")
+			assert.Contains(t, s.Cases[1].Failure.Body, "401 Unauthorized")
+		}
+	}
+}
+
+func TestGitHubAnnotations(t *testing.T) {
+	m, dir := ciManifest(t)
+	report := &FindingsReport{Findings: []MergedFinding{
+		{Finding: Finding{Severity: "high", Title: "SQL injection, login", File: "auth/login.go", StartLine: 42, EndLine: 48,
+			Rationale: "100% user input\nconcatenated"}, Reporters: []Reporter{{ToolID: "claude@security"}}},
+		{Finding: Finding{Severity: "low", Title: "Typo"}, Reporters: []Reporter{{ToolID: "codex"}}},
+	}}
+
+	lines := GitHubAnnotations(m, dir, report)
+	if assert.Len(t, lines, 3) {
+		assert.True(t, strings.HasPrefix(lines[0], "::error title=horde%3A codex failed::codex failed (exit 1)"), lines[0])
+		assert.Equal(t, "::error file=auth/login.go,line=42,endLine=48,title=[high] SQL injection%2C login::"+
+			"100%25 user input%0Aconcatenated%0AReported by: claude@security", lines[1])
+		assert.Equal(t, "::notice title=[low] Typo::Reported by: codex", lines[2])
+	}
+
+	assert.Len(t, GitHubAnnotations(m, dir, nil), 1)
+}
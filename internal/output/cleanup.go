@@ -1,7 +1,9 @@
 package output
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,9 +13,52 @@ import (
 )
 
 type Candidate struct {
-	Name  string
-	Path  string
-	Mtime time.Time
+	Name      string
+	Path      string
+	Mtime     time.Time
+	StartedAt time.Time   // from the run manifest; zero for directories without one
+	Entry     *IndexEntry // index entry of the run; nil for directories without a manifest
+	Marked    bool        // carries a run marker (MarkerFile)
+}
+
+// IsRun reports whether the directory was created by horde: it has a run
+// manifest or a run marker. Other directories are never removed by cleanup.
+func (c Candidate) IsRun() bool {
+	return c.Entry != nil || c.Marked
+}
+
+// Pinned reports whether the run is pinned and must be kept by cleanup.
+func (c Candidate) Pinned() bool {
+	return c.Entry != nil && c.Entry.Pinned
+}
+
+// KeepReason re-reads the run in dir and returns why cleanup must keep it,
+// or "" if it may be removed. Candidates are selected from the run index,
+// which can be stale, so this is checked against run.json and the run lock
+// right before a run is deleted.
+func KeepReason(dir string) string {
+	if _, locked := ActiveLock(dir); locked {
+		return "still in progress"
+	}
+	m, err := ReadManifest(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "" // a marked run that never got a manifest
+	case err != nil:
+		return fmt.Sprintf("cannot read run.json: %v", err)
+	case m.Pinned:
+		return "pinned"
+	}
+	return ""
+}
+
+// sortTime orders runs by when they started, falling back to the directory
+// mtime for directories without a manifest.
+func (c Candidate) sortTime() time.Time {
+	if !c.StartedAt.IsZero() {
+		return c.StartedAt
+	}
+	return c.Mtime
 }
 
 // ParseDuration parses a human-friendly duration string (ms, s, m, h, d, w).
@@ -56,6 +101,9 @@ func ParseDuration(input string) (time.Duration, error) {
 	return time.Duration(n * float64(24 * time.Hour)), nil
 }
 
+// scanDirs lists the directories in baseDir. Runs are described from the
+// run index, so only directories the index does not know yet are stat'ed and
+// have their manifest read; those are then added to the index.
 func scanDirs(baseDir string) ([]Candidate, error) {
 	entries, err := os.ReadDir(baseDir)
 	if err != nil {
@@ -65,24 +113,54 @@ func scanDirs(baseDir string) ([]Candidate, error) {
 		return nil, fmt.Errorf("reading output dir: %w", err)
 	}
 
+	index, _, err := ReadIndex(baseDir)
+	if err != nil {
+		index = nil // missing or unreadable: runs are read from disk below
+	}
+
 	var dirs []Candidate
+	var added []IndexEntry
 	for _, entry := range entries {
 		if entry.Type()&os.ModeSymlink != 0 || !entry.IsDir() {
 			continue
 		}
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue // horde's own temporary directories
+		}
+		path := filepath.Join(baseDir, name)
+
+		e, ok := index[name]
+		if !ok {
+			if e, ok = indexDir(baseDir, name); ok {
+				added = append(added, e)
+			}
+		}
+		if ok {
+			dirs = append(dirs, Candidate{Name: name, Path: path, Mtime: e.Mtime, StartedAt: e.StartedAt, Entry: &e})
+			continue
+		}
+
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
-		dirs = append(dirs, Candidate{
-			Name:  entry.Name(),
-			Path:  filepath.Join(baseDir, entry.Name()),
-			Mtime: info.ModTime(),
-		})
+		dirs = append(dirs, Candidate{Name: name, Path: path, Mtime: info.ModTime(), Marked: HasMarker(path)})
+	}
+
+	// Add what the index missed on a best-effort basis (the output directory
+	// may be read-only). The index is only ever appended to here: rewriting
+	// it would race with raids and annotations appending at the same time.
+	// Entries of removed runs stay until 'horde index rebuild'.
+	if len(added) > 0 {
+		_ = appendIndex(baseDir, added...)
 	}
 	return dirs, nil
 }
 
+// ScanCandidates returns the runs in baseDir started before cutoff (last
+// modified, for runs without a manifest). Pinned runs, runs still being
+// written and directories not created by horde are never candidates.
 func ScanCandidates(baseDir string, cutoff time.Time) ([]Candidate, error) {
 	dirs, err := scanDirs(baseDir)
 	if err != nil {
@@ -91,7 +169,13 @@ func ScanCandidates(baseDir string, cutoff time.Time) ([]Candidate, error) {
 
 	var candidates []Candidate
 	for _, d := range dirs {
-		if d.Mtime.Before(cutoff) {
+		if d.Pinned() || !d.IsRun() {
+			continue
+		}
+		if _, locked := ActiveLock(d.Path); locked {
+			continue
+		}
+		if d.sortTime().Before(cutoff) {
 			candidates = append(candidates, d)
 		}
 	}
@@ -105,7 +189,7 @@ func ScanRuns(baseDir string) ([]Candidate, error) {
 	}
 
 	sort.Slice(runs, func(i, j int) bool {
-		return runs[i].Mtime.After(runs[j].Mtime)
+		return runs[i].sortTime().After(runs[j].sortTime())
 	})
 	return runs, nil
 }
@@ -45,6 +45,8 @@ func TestScanCandidates(t *testing.T) {
 		newDir := filepath.Join(base, "new-run")
 		assert.NoError(t, os.Mkdir(oldDir, 0o700))
 		assert.NoError(t, os.Mkdir(newDir, 0o700))
+		assert.NoError(t, WriteMarker(oldDir))
+		assert.NoError(t, WriteMarker(newDir))
 
 		// Set old dir mtime to 48 hours ago
 		past := time.Now().Add(-48 * time.Hour)
@@ -58,6 +60,30 @@ func TestScanCandidates(t *testing.T) {
 		assert.Equal(t, oldDir, candidates[0].Path)
 	})
 
+	t.Run("skips foreign and locked directories", func(t *testing.T) {
+		base := t.TempDir()
+		past := time.Now().Add(-48 * time.Hour)
+		for _, name := range []string{"foreign", "active", "crashed"} {
+			dir := filepath.Join(base, name)
+			assert.NoError(t, os.Mkdir(dir, 0o700))
+			if name != "foreign" {
+				assert.NoError(t, WriteMarker(dir))
+				assert.NoError(t, LockRun(dir))
+			}
+			assert.NoError(t, os.Chtimes(dir, past, past))
+		}
+		// A lock left behind by a process that no longer exists is stale.
+		assert.NoError(t, os.WriteFile(filepath.Join(base, "crashed", LockFile),
+			[]byte(`{"pid":2147483646,"host":"`+hostname(t)+`"}`), 0o600))
+		assert.NoError(t, os.Chtimes(filepath.Join(base, "crashed"), past, past))
+
+		candidates, err := ScanCandidates(base, time.Now())
+		assert.NoError(t, err)
+		if assert.Len(t, candidates, 1) {
+			assert.Equal(t, "crashed", candidates[0].Name)
+		}
+	})
+
 	t.Run("non-existent base dir returns nil", func(t *testing.T) {
 		candidates, err := ScanCandidates("/tmp/horde-does-not-exist-"+t.Name(), time.Now())
 		assert.NoError(t, err)
@@ -127,3 +153,10 @@ func TestScanRuns(t *testing.T) {
 		assert.Nil(t, runs)
 	})
 }
+
+func hostname(t *testing.T) string {
+	t.Helper()
+	h, err := os.Hostname()
+	assert.NoError(t, err)
+	return h
+}
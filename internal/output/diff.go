@@ -0,0 +1,381 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RunDiff compares two runs agent by agent.
+type RunDiff struct {
+	RunA     string        `json:"runA"`
+	RunB     string        `json:"runB"`
+	Agents   []AgentDiff   `json:"agents"`
+	Findings *FindingsDiff `json:"findings,omitempty"`
+}
+
+// AgentDiff pairs the results of one agent (tool ID and raider) across two
+// runs. A or B is nil when the agent only took part in one of them.
+type AgentDiff struct {
+	ToolID        string      `json:"toolId"`
+	Raider        string      `json:"raider,omitempty"`
+	A             *AgentState `json:"a,omitempty"`
+	B             *AgentState `json:"b,omitempty"`
+	DurationDelta string      `json:"durationDelta,omitempty"`
+	CostDelta     float64     `json:"costDelta,omitempty"`
+	Changed       bool        `json:"changed"` // response text differs
+	Diff          string      `json:"diff,omitempty"`
+
+	textA, textB string
+}
+
+// AgentState is one side of an AgentDiff.
+type AgentState struct {
+	Status   string  `json:"status"`
+	Duration string  `json:"duration"`
+	CostUSD  float64 `json:"costUsd,omitempty"`
+}
+
+// FindingsDiff classifies findings of run B relative to run A.
+type FindingsDiff struct {
+	Disappeared []Finding `json:"disappeared"`
+	Persisted   []Finding `json:"persisted"`
+	New         []Finding `json:"new"`
+}
+
+// DiffRuns aligns the agents of two runs by tool ID and raider and computes
+// status, duration and cost deltas plus a unified diff of each response.
+func DiffRuns(a *Manifest, dirA string, b *Manifest, dirB string) *RunDiff {
+	d := &RunDiff{RunA: filepath.Base(dirA), RunB: filepath.Base(dirB)}
+
+	index := make(map[string]int)
+	key := func(r ManifestResult) string { return r.ToolID + "\x00" + r.Expert }
+	for _, r := range a.Results {
+		index[key(r)] = len(d.Agents)
+		d.Agents = append(d.Agents, AgentDiff{ToolID: r.ToolID, Raider: r.Expert, A: agentState(r), textA: readOutput(dirA, r)})
+	}
+	for _, r := range b.Results {
+		i, ok := index[key(r)]
+		if !ok {
+			i = len(d.Agents)
+			d.Agents = append(d.Agents, AgentDiff{ToolID: r.ToolID, Raider: r.Expert})
+		}
+		d.Agents[i].B = agentState(r)
+		d.Agents[i].textB = readOutput(dirB, r)
+	}
+
+	for i := range d.Agents {
+		ad := &d.Agents[i]
+		if ad.A != nil && ad.B != nil {
+			da, errA := time.ParseDuration(ad.A.Duration)
+			db, errB := time.ParseDuration(ad.B.Duration)
+			if errA == nil && errB == nil {
+				ad.DurationDelta = formatDelta(db - da)
+			}
+			ad.CostDelta = ad.B.CostUSD - ad.A.CostUSD
+		}
+		ad.Changed = ad.textA != ad.textB
+		if ad.Changed {
+			name := ad.ToolID + ".md"
+			ad.Diff = UnifiedDiff(ad.textA, ad.textB, d.RunA+"/"+name, d.RunB+"/"+name, 3)
+		}
+	}
+
+	fa, okA := runFindings(a, dirA)
+	fb, okB := runFindings(b, dirB)
+	if okA || okB {
+		d.Findings = DiffFindings(fa, fb)
+	}
+	return d
+}
+
+// Text returns the response texts of both sides, for alternative renderings
+// such as side-by-side diffs.
+func (ad AgentDiff) Text() (a, b string) {
+	return ad.textA, ad.textB
+}
+
+func agentState(r ManifestResult) *AgentState {
+	s := &AgentState{Status: r.Status, Duration: r.Duration}
+	if r.Cost != nil {
+		s.CostUSD = r.Cost.TotalUSD
+	}
+	return s
+}
+
+func readOutput(dir string, r ManifestResult) string {
+	data, err := os.ReadFile(filepath.Join(dir, r.OutputFile))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func formatDelta(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
+
+// runFindings returns the merged findings of a run, from findings.json when
+// present and otherwise by parsing the responses. ok is false when the run
+// has no structured findings at all.
+func runFindings(m *Manifest, dir string) ([]Finding, bool) {
+	var merged []MergedFinding
+	if report, err := ReadFindings(dir); err == nil {
+		merged = report.Findings
+	} else {
+		cp := *m
+		cp.Results = append([]ManifestResult(nil), m.Results...)
+		report := CollectFindings(&cp, dir)
+		if report.Agents == 0 {
+			return nil, false
+		}
+		merged = report.Findings
+	}
+	findings := make([]Finding, len(merged))
+	for i, f := range merged {
+		findings[i] = f.Finding
+	}
+	return findings, true
+}
+
+// DiffFindings matches findings across runs. Line numbers are ignored
+// because code moves between runs; a finding persists when the same file
+// has a finding with a similar title.
+func DiffFindings(a, b []Finding) *FindingsDiff {
+	d := &FindingsDiff{Disappeared: []Finding{}, Persisted: []Finding{}, New: []Finding{}}
+	matched := make([]bool, len(a))
+	for _, fb := range b {
+		found := false
+		for i, fa := range a {
+			if !matched[i] && sameFindingAcrossRuns(fa, fb) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if found {
+			d.Persisted = append(d.Persisted, fb)
+		} else {
+			d.New = append(d.New, fb)
+		}
+	}
+	for i, fa := range a {
+		if !matched[i] {
+			d.Disappeared = append(d.Disappeared, fa)
+		}
+	}
+	return d
+}
+
+func sameFindingAcrossRuns(a, b Finding) bool {
+	if a.File != b.File {
+		return false
+	}
+	return FindingFingerprint(a) == FindingFingerprint(b) || titleSimilarity(a.Title, b.Title) >= 0.5
+}
+
+// maxDiffCells bounds the LCS table; larger inputs are diffed as a full
+// replacement.
+const maxDiffCells = 16 << 20
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+// diffLines computes a line-level edit script using a longest common
+// subsequence.
+func diffLines(a, b []string) []diffOp {
+	// Trim common prefix and suffix to keep the table small.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []diffOp
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(ma), len(mb)
+
+	if (n+1)*(m+1) > maxDiffCells {
+		for _, l := range ma {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range mb {
+			ops = append(ops, diffOp{'+', l})
+		}
+	} else {
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i++
+				j++
+			case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			}
+		}
+	}
+
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// UnifiedDiff renders a unified diff of two texts with the given number of
+// context lines. It returns "" when the texts are equal.
+func UnifiedDiff(a, b, nameA, nameB string, context int) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	// Group changes into hunks separated by more than 2*context equal lines.
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		hunkStart := max(0, start-context)
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i
+				continue
+			}
+			if i-end > 2*context {
+				break
+			}
+		}
+		hunkEnd := min(len(ops), end+context+1)
+
+		lineA, lineB := 1, 1
+		for _, op := range ops[:hunkStart] {
+			if op.kind != '+' {
+				lineA++
+			}
+			if op.kind != '-' {
+				lineB++
+			}
+		}
+		countA, countB := 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lineA, countA), hunkRange(lineB, countB))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// SideBySideDiff renders two texts in two columns of the given width,
+// marking changed lines with |, removed lines with < and added lines with >.
+func SideBySideDiff(a, b string, width int) string {
+	if width < 10 {
+		width = 10
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	row := func(left, mark, right string) {
+		fmt.Fprintf(&out, "%-*s %s %s\n", width, clip(left, width), mark, clip(right, width))
+	}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			row(ops[i].line, " ", ops[i].line)
+			i++
+			continue
+		}
+		var del, add []string
+		for i < len(ops) && ops[i].kind == '-' {
+			del = append(del, ops[i].line)
+			i++
+		}
+		for i < len(ops) && ops[i].kind == '+' {
+			add = append(add, ops[i].line)
+			i++
+		}
+		for k := 0; k < max(len(del), len(add)); k++ {
+			switch {
+			case k < len(del) && k < len(add):
+				row(del[k], "|", add[k])
+			case k < len(del):
+				row(del[k], "<", "")
+			default:
+				row("", ">", add[k])
+			}
+		}
+	}
+	return out.String()
+}
+
+func clip(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,6 +1,6 @@\n one\n two\n-three\n+THREE\n four\n five\n six\n" +
+		"@@ -8,3 +8,4 @@\n eight\n nine\n ten\n+eleven\n"
+	assert.Equal(t, want, UnifiedDiff(a, b, "a", "b", 3))
+	assert.Equal(t, "", UnifiedDiff(a, a, "a", "b", 3))
+}
+
+func TestUnifiedDiffFromEmpty(t *testing.T) {
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n", UnifiedDiff("", "x\ny\n", "a", "b", 3))
+}
+
+func TestSideBySideDiff(t *testing.T) {
+	out := SideBySideDiff("same\nold\ngone\n", "same\nnew\n", 10)
+	assert.Equal(t, "same         same\nold        | new\ngone       < \n", out)
+}
+
+func TestDiffFindings(t *testing.T) {
+	a := []Finding{
+		{Severity: "high", Title: "SQL injection in login query", File: "auth/login.go", StartLine: 42},
+		{Severity: "low", Title: "Missing docs"},
+	}
+	b := []Finding{
+		{Severity: "high", Title: "SQL injection in the login query", File: "auth/login.go", StartLine: 60},
+		{Severity: "medium", Title: "Race on session map", File: "auth/session.go"},
+	}
+	d := DiffFindings(a, b)
+	assert.Len(t, d.Persisted, 1)
+	assert.Equal(t, 60, d.Persisted[0].StartLine)
+	assert.Equal(t, "Race on session map", d.New[0].Title)
+	assert.Equal(t, "Missing docs", d.Disappeared[0].Title)
+}
+
+func TestDiffRuns(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(dirA, "claude@security.md"), []byte(sampleResponse), 0o600)
+	os.WriteFile(filepath.Join(dirB, "claude@security.md"), []byte("All fixed.\n\n```json\n{\"findings\": []}\n```\n"), 0o600)
+	os.WriteFile(filepath.Join(dirA, "gemini.md"), []byte("same"), 0o600)
+	os.WriteFile(filepath.Join(dirB, "gemini.md"), []byte("same"), 0o600)
+	os.WriteFile(filepath.Join(dirB, "codex.md"), []byte("new agent"), 0o600)
+
+	a := &Manifest{Results: []ManifestResult{
+		{ToolID: "claude@security", Expert: "security", Status: "success", Duration: "30s", OutputFile: "claude@security.md",
+			Cost: &runner.Cost{TotalUSD: 0.30}},
+		{ToolID: "gemini", Status: "failed", Duration: "2s", OutputFile: "gemini.md"},
+	}}
+	b := &Manifest{Results: []ManifestResult{
+		{ToolID: "gemini", Status: "success", Duration: "12.5s", OutputFile: "gemini.md"},
+		{ToolID: "claude@security", Expert: "security", Status: "success", Duration: "20s", OutputFile: "claude@security.md",
+			Cost: &runner.Cost{TotalUSD: 0.10}},
+		{ToolID: "codex", Status: "success", Duration: "5s", OutputFile: "codex.md"},
+	}}
+
+	d := DiffRuns(a, dirA, b, dirB)
+	assert.Len(t, d.Agents, 3)
+
+	claude := d.Agents[0]
+	assert.Equal(t, "claude@security", claude.ToolID)
+	assert.Equal(t, "security", claude.Raider)
+	assert.Equal(t, "-10s", claude.DurationDelta)
+	assert.InDelta(t, -0.20, claude.CostDelta, 0.001)
+	assert.True(t, claude.Changed)
+	assert.Contains(t, claude.Diff, "+All fixed.")
+
+	gemini := d.Agents[1]
+	assert.Equal(t, "failed", gemini.A.Status)
+	assert.Equal(t, "success", gemini.B.Status)
+	assert.Equal(t, "+10.5s", gemini.DurationDelta)
+	assert.False(t, gemini.Changed)
+
+	codex := d.Agents[2]
+	assert.Nil(t, codex.A)
+	assert.NotNil(t, codex.B)
+
+	assert.NotNil(t, d.Findings)
+	assert.Len(t, d.Findings.Disappeared, 2)
+	assert.Empty(t, d.Findings.New)
+	assert.Empty(t, d.Findings.Persisted)
+	assert.Zero(t, a.Results[0].Findings) // the manifest is not modified
+}
@@ -0,0 +1,314 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FindingsFile is the name of the merged findings file in a run directory.
+const FindingsFile = "findings.json"
+
+// FindingsInstructions is appended to the prompt when structured findings
+// are enabled. Agents answer normally and end with a fenced JSON block.
+const FindingsInstructions = `## Structured Findings
+
+After your answer, end your response with a fenced ` + "```json" + ` block listing your findings in exactly this shape:
+
+` + "```json" + `
+{"findings": [{"severity": "high", "title": "SQL injection in login query", "file": "internal/auth/login.go", "startLine": 42, "endLine": 48, "rationale": "User input is concatenated into the query.", "suggestedFix": "Use a parameterized query."}]}
+` + "```" + `
+
+- severity is one of: critical, high, medium, low, info
+- file is relative to the repository root; omit file and lines for general findings
+- use {"findings": []} if you have none
+- the JSON block must be the last thing in your response
+`
+
+// Severities in descending order of importance.
+var Severities = []string{"critical", "high", "medium", "low", "info"}
+
+var severityRank = map[string]int{"critical": 4, "high": 3, "medium": 2, "low": 1, "info": 0}
+
+// SeverityAtLeast reports whether severity is at or above min.
+func SeverityAtLeast(severity, min string) bool {
+	return severityRank[severity] >= severityRank[min]
+}
+
+// ValidSeverity reports whether s is a known severity.
+func ValidSeverity(s string) bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+type Finding struct {
+	Severity     string `json:"severity"`
+	Title        string `json:"title"`
+	File         string `json:"file,omitempty"`
+	StartLine    int    `json:"startLine,omitempty"`
+	EndLine      int    `json:"endLine,omitempty"`
+	Rationale    string `json:"rationale,omitempty"`
+	SuggestedFix string `json:"suggestedFix,omitempty"`
+}
+
+// Reporter identifies an agent (and its raider) that reported a finding.
+type Reporter struct {
+	ToolID string `json:"toolId"`
+	Raider string `json:"raider,omitempty"`
+}
+
+// MergedFinding is a finding reported by one or more agents.
+type MergedFinding struct {
+	Finding
+	Reporters []Reporter `json:"reporters"`
+}
+
+// FindingsReport is the content of findings.json.
+type FindingsReport struct {
+	Agents   int               `json:"agents"` // agents whose findings block parsed
+	Findings []MergedFinding   `json:"findings"`
+	Errors   map[string]string `json:"errors,omitempty"` // tool ID -> parse error
+}
+
+var jsonFenceRe = regexp.MustCompile("(?s)```json[ \t]*\n(.*?)\n[ \t]*```")
+
+// ParseFindings extracts and validates the findings block from an agent
+// response. The last fenced json block containing a "findings" key wins.
+func ParseFindings(text string) ([]Finding, error) {
+	matches := jsonFenceRe.FindAllStringSubmatch(text, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		body := matches[i][1]
+		if !strings.Contains(body, `"findings"`) {
+			continue
+		}
+		var block struct {
+			Findings *[]Finding `json:"findings"`
+		}
+		if err := json.Unmarshal([]byte(body), &block); err != nil {
+			return nil, fmt.Errorf("invalid findings JSON: %w", err)
+		}
+		if block.Findings == nil {
+			return nil, fmt.Errorf("findings block has no \"findings\" array")
+		}
+		findings := *block.Findings
+		for j := range findings {
+			if err := normalizeFinding(&findings[j]); err != nil {
+				return nil, fmt.Errorf("finding %d: %w", j+1, err)
+			}
+		}
+		return findings, nil
+	}
+	return nil, fmt.Errorf("no findings block found")
+}
+
+func normalizeFinding(f *Finding) error {
+	f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+	f.Title = strings.TrimSpace(f.Title)
+	f.File = strings.TrimPrefix(strings.TrimSpace(f.File), "./")
+	if !ValidSeverity(f.Severity) {
+		return fmt.Errorf("invalid severity %q (want one of %s)", f.Severity, strings.Join(Severities, ", "))
+	}
+	if f.Title == "" {
+		return fmt.Errorf("missing title")
+	}
+	if f.StartLine < 0 || f.EndLine < 0 {
+		return fmt.Errorf("negative line number")
+	}
+	if f.EndLine == 0 {
+		f.EndLine = f.StartLine
+	}
+	if f.StartLine == 0 {
+		f.StartLine = f.EndLine
+	}
+	if f.EndLine < f.StartLine {
+		return fmt.Errorf("endLine %d before startLine %d", f.EndLine, f.StartLine)
+	}
+	return nil
+}
+
+// MergeFindings merges per-agent findings. Two findings are the same when
+// they refer to the same file, their line ranges overlap (or neither has
+// lines), and their titles are similar.
+func MergeFindings(byReporter map[Reporter][]Finding) []MergedFinding {
+	reporters := make([]Reporter, 0, len(byReporter))
+	for r := range byReporter {
+		reporters = append(reporters, r)
+	}
+	sort.Slice(reporters, func(i, j int) bool {
+		if reporters[i].ToolID != reporters[j].ToolID {
+			return reporters[i].ToolID < reporters[j].ToolID
+		}
+		return reporters[i].Raider < reporters[j].Raider
+	})
+
+	var merged []MergedFinding
+	for _, rep := range reporters {
+		for _, f := range byReporter[rep] {
+			idx := -1
+			for i := range merged {
+				if SameFinding(merged[i].Finding, f) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				merged = append(merged, MergedFinding{Finding: f, Reporters: []Reporter{rep}})
+				continue
+			}
+			m := &merged[idx]
+			if severityRank[f.Severity] > severityRank[m.Severity] {
+				m.Severity = f.Severity
+			}
+			if f.StartLine > 0 && (m.StartLine == 0 || f.StartLine < m.StartLine) {
+				m.StartLine = f.StartLine
+			}
+			if f.EndLine > m.EndLine {
+				m.EndLine = f.EndLine
+			}
+			if m.SuggestedFix == "" {
+				m.SuggestedFix = f.SuggestedFix
+			}
+			if m.Rationale == "" {
+				m.Rationale = f.Rationale
+			}
+			if !hasReporter(m.Reporters, rep) {
+				m.Reporters = append(m.Reporters, rep)
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] > severityRank[b.Severity]
+		}
+		if len(a.Reporters) != len(b.Reporters) {
+			return len(a.Reporters) > len(b.Reporters)
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	})
+	return merged
+}
+
+// SameFinding reports whether a and b describe the same issue.
+func SameFinding(a, b Finding) bool {
+	if a.File != b.File {
+		return false
+	}
+	if a.StartLine > 0 && b.StartLine > 0 {
+		if a.StartLine > b.EndLine || b.StartLine > a.EndLine {
+			return false
+		}
+	} else if a.StartLine != b.StartLine {
+		return false
+	}
+	return titleSimilarity(a.Title, b.Title) >= 0.5
+}
+
+var wordRe = regexp.MustCompile(`[a-z0-9]+`)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "in": true, "of": true, "on": true,
+	"to": true, "for": true, "and": true, "is": true, "with": true, "at": true,
+}
+
+// titleSimilarity is the Jaccard similarity of the titles' word sets.
+func titleSimilarity(a, b string) float64 {
+	wa, wb := titleWords(a), titleWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	inter := 0
+	for w := range wa {
+		if wb[w] {
+			inter++
+		}
+	}
+	union := len(wa) + len(wb) - inter
+	return float64(inter) / float64(union)
+}
+
+func titleWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range wordRe.FindAllString(strings.ToLower(s), -1) {
+		if !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+func hasReporter(reps []Reporter, r Reporter) bool {
+	for _, x := range reps {
+		if x == r {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectFindings parses the findings block of every successful response in
+// a run, records per-agent counts and errors on the manifest results, and
+// returns the merged report.
+func CollectFindings(m *Manifest, runDir string) *FindingsReport {
+	report := &FindingsReport{Errors: make(map[string]string)}
+	byReporter := make(map[Reporter][]Finding)
+	for i := range m.Results {
+		r := &m.Results[i]
+		if r.Status != "success" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(runDir, r.OutputFile))
+		if err != nil {
+			r.FindingsError = err.Error()
+			report.Errors[r.ToolID] = r.FindingsError
+			continue
+		}
+		findings, err := ParseFindings(string(data))
+		if err != nil {
+			r.FindingsError = err.Error()
+			report.Errors[r.ToolID] = r.FindingsError
+			continue
+		}
+		r.Findings = len(findings)
+		report.Agents++
+		rep := Reporter{ToolID: r.ToolID, Raider: r.Expert}
+		byReporter[rep] = append(byReporter[rep], findings...)
+	}
+	report.Findings = MergeFindings(byReporter)
+	if report.Findings == nil {
+		report.Findings = []MergedFinding{}
+	}
+	if len(report.Errors) == 0 {
+		report.Errors = nil
+	}
+	return report
+}
+
+func WriteFindings(dir string, report *FindingsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return AtomicWrite(filepath.Join(dir, FindingsFile), data, 0o600)
+}
+
+func ReadFindings(dir string) (*FindingsReport, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FindingsFile))
+	if err != nil {
+		return nil, err
+	}
+	var report FindingsReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", FindingsFile, err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleResponse = "## Review\n\nThe login query is unsafe.\n\n```go\nq := \"SELECT \" + user\n```\n\n```json\n" +
+	`{"findings": [{"severity": "HIGH", "title": "SQL injection in login query", "file": "./auth/login.go", "startLine": 42, "endLine": 48, "rationale": "concatenation", "suggestedFix": "parameterize"}, {"severity": "low", "title": "Missing docs", "rationale": "no comments"}]}` +
+	"\n```\n"
+
+func TestParseFindings(t *testing.T) {
+	findings, err := ParseFindings(sampleResponse)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 2)
+	assert.Equal(t, "high", findings[0].Severity)
+	assert.Equal(t, "auth/login.go", findings[0].File)
+	assert.Equal(t, 42, findings[0].StartLine)
+	assert.Equal(t, 48, findings[0].EndLine)
+	assert.Equal(t, "parameterize", findings[0].SuggestedFix)
+	assert.Equal(t, "", findings[1].File)
+}
+
+func TestParseFindingsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"no block", "just prose"},
+		{"invalid json", "```json\n{\"findings\": [\n```"},
+		{"bad severity", "```json\n{\"findings\": [{\"severity\": \"urgent\", \"title\": \"x\"}]}\n```"},
+		{"missing title", "```json\n{\"findings\": [{\"severity\": \"low\"}]}\n```"},
+		{"inverted lines", "```json\n{\"findings\": [{\"severity\": \"low\", \"title\": \"x\", \"startLine\": 9, \"endLine\": 3}]}\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFindings(tt.text)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseFindingsEmpty(t *testing.T) {
+	findings, err := ParseFindings("All good.\n\n```json\n{\"findings\": []}\n```")
+	assert.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestParseFindingsSingleLine(t *testing.T) {
+	findings, err := ParseFindings("```json\n{\"findings\": [{\"severity\": \"medium\", \"title\": \"x\", \"file\": \"a.go\", \"startLine\": 7}]}\n```")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, findings[0].EndLine)
+}
+
+func TestMergeFindings(t *testing.T) {
+	sqlA := Finding{Severity: "high", Title: "SQL injection in login query", File: "auth/login.go", StartLine: 42, EndLine: 48}
+	sqlB := Finding{Severity: "critical", Title: "Login query vulnerable to SQL injection", File: "auth/login.go", StartLine: 45, EndLine: 45}
+	sqlC := Finding{Severity: "medium", Title: "SQL injection", File: "auth/login.go", StartLine: 44, EndLine: 50}
+	otherFile := Finding{Severity: "high", Title: "SQL injection in login query", File: "auth/logout.go", StartLine: 42, EndLine: 48}
+	farLines := Finding{Severity: "high", Title: "SQL injection in login query", File: "auth/login.go", StartLine: 200, EndLine: 210}
+	docs := Finding{Severity: "low", Title: "Missing package docs"}
+
+	merged := MergeFindings(map[Reporter][]Finding{
+		{ToolID: "claude", Raider: "security"}: {sqlA, docs},
+		{ToolID: "gemini"}:                     {sqlB, otherFile},
+		{ToolID: "codex"}:                      {sqlC, farLines},
+	})
+
+	assert.Len(t, merged, 4)
+	top := merged[0]
+	assert.Equal(t, "critical", top.Severity, "merged severity is the maximum")
+	assert.Equal(t, "auth/login.go", top.File)
+	assert.Equal(t, 42, top.StartLine)
+	assert.Equal(t, 50, top.EndLine)
+	assert.ElementsMatch(t, []Reporter{{ToolID: "claude", Raider: "security"}, {ToolID: "gemini"}, {ToolID: "codex"}}, top.Reporters)
+	assert.Equal(t, "low", merged[len(merged)-1].Severity)
+}
+
+func TestSameFinding(t *testing.T) {
+	a := Finding{Title: "Race condition in cache", File: "cache.go", StartLine: 10, EndLine: 20}
+	assert.True(t, SameFinding(a, Finding{Title: "Cache race condition", File: "cache.go", StartLine: 15, EndLine: 15}))
+	assert.False(t, SameFinding(a, Finding{Title: "Cache race condition", File: "cache.go", StartLine: 21, EndLine: 30}))
+	assert.False(t, SameFinding(a, Finding{Title: "Unbounded memory growth", File: "cache.go", StartLine: 10, EndLine: 20}))
+	assert.False(t, SameFinding(a, Finding{Title: "Race condition in cache", File: "cache.go"}))
+}
+
+func TestCollectFindings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude.md"), []byte(sampleResponse), 0o600)
+	os.WriteFile(filepath.Join(dir, "gemini.md"), []byte(sampleResponse), 0o600)
+	os.WriteFile(filepath.Join(dir, "codex.md"), []byte("no block here"), 0o600)
+
+	m := &Manifest{
+		Prompt: "review",
+		Config: ManifestConfig{ReadOnly: "bestEffort"},
+		Results: []ManifestResult{
+			{ToolID: "claude", Status: "success", OutputFile: "claude.md", Expert: "security"},
+			{ToolID: "gemini", Status: "success", OutputFile: "gemini.md"},
+			{ToolID: "codex", Status: "success", OutputFile: "codex.md"},
+			{ToolID: "amp", Status: "failed", OutputFile: "amp.md"},
+		},
+	}
+
+	report := CollectFindings(m, dir)
+	assert.Equal(t, 2, report.Agents)
+	assert.Len(t, report.Findings, 2)
+	assert.Len(t, report.Findings[0].Reporters, 2)
+	assert.Contains(t, report.Errors, "codex")
+	assert.NotContains(t, report.Errors, "amp")
+	assert.Equal(t, 2, m.Results[0].Findings)
+	assert.NotEmpty(t, m.Results[2].FindingsError)
+
+	assert.NoError(t, WriteFindings(dir, report))
+	read, err := ReadFindings(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Findings, read.Findings)
+
+	m.FindingsFile = FindingsFile
+	summary := BuildSummary(m, dir)
+	assert.Contains(t, summary, "## Findings")
+	assert.Contains(t, summary, "**[high]** SQL injection in login query — auth/login.go:42-48 (2/3 agents: claude, gemini)")
+	assert.Contains(t, summary, "- Findings: 2")
+	assert.Contains(t, summary, "- Findings: invalid (no findings block found)")
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	assert.True(t, SeverityAtLeast("critical", "high"))
+	assert.True(t, SeverityAtLeast("high", "high"))
+	assert.False(t, SeverityAtLeast("medium", "high"))
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ResponseFormats are the formats WriteResponses accepts.
+var ResponseFormats = []string{"text", "markdown", "json", "jsonl"}
+
+// ValidateResponseFormat checks that format is one of ResponseFormats.
+func ValidateResponseFormat(format string) error {
+	if slices.Contains(ResponseFormats, format) {
+		return nil
+	}
+	return fmt.Errorf("invalid format %q: must be %s", format, strings.Join(ResponseFormats, ", "))
+}
+
+// ResultResponse is a manifest result together with the agent's response.
+type ResultResponse struct {
+	ManifestResult
+	Response string `json:"response"`
+}
+
+// runResponses is the JSON form of a run: its manifest with each result
+// carrying the response body.
+type runResponses struct {
+	*Manifest
+	Results []ResultResponse `json:"results"`
+}
+
+// responseLine is one JSONL record: a result with the run it belongs to.
+type responseLine struct {
+	Run       string    `json:"run"`
+	Prompt    string    `json:"prompt"`
+	StartedAt time.Time `json:"startedAt"`
+	ResultResponse
+}
+
+// LoadResponses reads the responses of a run. When agents is non-empty only
+// results whose tool ID or agent name is listed are returned, in manifest
+// order; naming an agent the run does not have is an error.
+func LoadResponses(m *Manifest, runDir string, agents []string) ([]ResultResponse, error) {
+	found := make(map[string]bool)
+	var out []ResultResponse
+	for _, r := range m.Results {
+		if len(agents) > 0 {
+			match := false
+			for _, a := range agents {
+				if a == r.ToolID || a == agentName(r.ToolID) {
+					found[a] = true
+					match = true
+				}
+			}
+			if !match {
+				continue
+			}
+		}
+		out = append(out, ResultResponse{ManifestResult: r, Response: readOutput(runDir, r)})
+	}
+	for _, a := range agents {
+		if !found[a] {
+			ids := make([]string, len(m.Results))
+			for i, r := range m.Results {
+				ids[i] = r.ToolID
+			}
+			return nil, fmt.Errorf("no agent %q in run (agents: %s)", a, strings.Join(ids, ", "))
+		}
+	}
+	return out, nil
+}
+
+// WriteResponses writes the responses of a run to w in the given format:
+// plain text with a header per agent, markdown with a section per agent,
+// the manifest as JSON with each response inlined, or one JSON object per
+// agent (JSONL).
+func WriteResponses(w io.Writer, format string, m *Manifest, runDir string, results []ResultResponse) error {
+	switch format {
+	case "text":
+		for i, r := range results {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s (%s, %s) <==\n", responseLabel(r.ManifestResult), r.Status, r.Duration)
+			writeBody(w, r)
+		}
+		return nil
+	case "markdown":
+		for i, r := range results {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "## %s\n\n", responseLabel(r.ManifestResult))
+			fmt.Fprintf(w, "_%s · %s_\n\n", r.Status, r.Duration)
+			writeBody(w, r)
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(runResponses{Manifest: m, Results: results})
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for _, r := range results {
+			line := responseLine{Run: filepath.Base(runDir), Prompt: m.Prompt, StartedAt: m.StartedAt, ResultResponse: r}
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ValidateResponseFormat(format)
+	}
+}
+
+func responseLabel(r ManifestResult) string {
+	if r.Expert != "" && !strings.HasSuffix(r.ToolID, "@"+r.Expert) {
+		return fmt.Sprintf("%s (raider: %s)", r.ToolID, r.Expert)
+	}
+	if name, raider, ok := strings.Cut(r.ToolID, "@"); ok {
+		return fmt.Sprintf("%s (raider: %s)", name, raider)
+	}
+	return r.ToolID
+}
+
+func writeBody(w io.Writer, r ResultResponse) {
+	body := strings.TrimRight(r.Response, "\n")
+	if body == "" {
+		fmt.Fprintf(w, "(no response: %s, exit %d)\n", r.Status, r.ExitCode)
+		return
+	}
+	fmt.Fprintln(w, body)
+}
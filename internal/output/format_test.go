@@ -0,0 +1,116 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupFormatRun(t *testing.T) (*Manifest, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "review-1")
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	m := &Manifest{
+		Prompt:    "review this",
+		StartedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Results: []ManifestResult{
+			{ToolID: "claude@security", Expert: "security", Status: "success", Duration: "2s", OutputFile: "claude@security.md"},
+			{ToolID: "gemini", Status: "failed", Duration: "1s", ExitCode: 1, OutputFile: "gemini.md"},
+		},
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "claude@security.md"), []byte("No issues found.\n"), 0o600))
+	return m, dir
+}
+
+func TestLoadResponsesFilters(t *testing.T) {
+	m, dir := setupFormatRun(t)
+
+	all, err := LoadResponses(m, dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, "No issues found.\n", all[0].Response)
+
+	some, err := LoadResponses(m, dir, []string{"claude"})
+	assert.NoError(t, err)
+	if assert.Len(t, some, 1) {
+		assert.Equal(t, "claude@security", some[0].ToolID)
+	}
+
+	_, err = LoadResponses(m, dir, []string{"codex"})
+	assert.ErrorContains(t, err, `no agent "codex"`)
+}
+
+func TestWriteResponsesText(t *testing.T) {
+	m, dir := setupFormatRun(t)
+	results, _ := LoadResponses(m, dir, nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteResponses(&buf, "text", m, dir, results))
+	assert.Equal(t, "==> claude (raider: security) (success, 2s) <==\nNo issues found.\n\n"+
+		"==> gemini (failed, 1s) <==\n(no response: failed, exit 1)\n", buf.String())
+}
+
+func TestWriteResponsesMarkdown(t *testing.T) {
+	m, dir := setupFormatRun(t)
+	results, _ := LoadResponses(m, dir, nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteResponses(&buf, "markdown", m, dir, results))
+	out := buf.String()
+	assert.Contains(t, out, "## claude (raider: security)\n\n_success · 2s_\n\nNo issues found.\n")
+	assert.Contains(t, out, "## gemini\n")
+}
+
+func TestWriteResponsesJSON(t *testing.T) {
+	m, dir := setupFormatRun(t)
+	results, _ := LoadResponses(m, dir, nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteResponses(&buf, "json", m, dir, results))
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "review this", got["prompt"])
+	res := got["results"].([]any)
+	assert.Len(t, res, 2)
+	first := res[0].(map[string]any)
+	assert.Equal(t, "claude@security", first["toolId"])
+	assert.Equal(t, "No issues found.\n", first["response"])
+}
+
+func TestWriteResponsesJSONL(t *testing.T) {
+	m, dir := setupFormatRun(t)
+	results, _ := LoadResponses(m, dir, nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteResponses(&buf, "jsonl", m, dir, results))
+	scanner := bufio.NewScanner(&buf)
+	var lines []map[string]any
+	for scanner.Scan() {
+		var line map[string]any
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "review-1", lines[0]["run"])
+		assert.Equal(t, "review this", lines[0]["prompt"])
+		assert.Equal(t, "gemini", lines[1]["toolId"])
+		assert.Equal(t, "", lines[1]["response"])
+	}
+}
+
+func TestValidateResponseFormat(t *testing.T) {
+	for _, f := range ResponseFormats {
+		assert.NoError(t, ValidateResponseFormat(f))
+	}
+	err := ValidateResponseFormat("xml")
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "text, markdown, json, jsonl"))
+	}
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// IndexFile is the append-only run index kept in the output directory. Each
+// line is an IndexEntry; replaying the lines in order yields the current set
+// of runs, so concurrent raids can record themselves without rewriting it.
+const IndexFile = ".horde-index.jsonl"
+
+const (
+	indexOpAdd    = "add"
+	indexOpRemove = "remove"
+
+	maxIndexPrompt = 200
+)
+
+// IndexEntry records a run (or its removal) in the run index. It carries
+// enough of the manifest to list and filter runs without reading run.json.
+type IndexEntry struct {
+	Op        string        `json:"op"`
+	Name      string        `json:"name"`
+	StartedAt time.Time     `json:"startedAt,omitempty"`
+	Mtime     time.Time     `json:"mtime,omitempty"`
+	Prompt    string        `json:"prompt,omitempty"` // truncated
+	Results   []IndexResult `json:"results,omitempty"`
+	Tags      []string      `json:"tags,omitempty"`
+	Note      string        `json:"note,omitempty"`
+	Pinned    bool          `json:"pinned,omitempty"`
+}
+
+type IndexResult struct {
+	ToolID   string `json:"toolId"`
+	Expert   string `json:"expert,omitempty"`
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+}
+
+func newIndexEntry(name string, m *Manifest, mtime time.Time) IndexEntry {
+	prompt := m.Prompt
+	if len(prompt) > maxIndexPrompt {
+		prompt = prompt[:maxIndexPrompt]
+		for !utf8.ValidString(prompt) {
+			prompt = prompt[:len(prompt)-1]
+		}
+	}
+	e := IndexEntry{
+		Op:        indexOpAdd,
+		Name:      name,
+		StartedAt: m.StartedAt,
+		Mtime:     mtime,
+		Prompt:    prompt,
+		Tags:      m.Tags,
+		Note:      m.Note,
+		Pinned:    m.Pinned,
+	}
+	for _, r := range m.Results {
+		e.Results = append(e.Results, IndexResult{ToolID: r.ToolID, Expert: r.Expert, Status: r.Status, Duration: r.Duration})
+	}
+	return e
+}
+
+// IndexRun records the run in runDir (with manifest m) in the index of its
+// output directory, replacing any previous entry for it.
+func IndexRun(runDir string, m *Manifest) error {
+	mtime := time.Now()
+	if info, err := os.Stat(runDir); err == nil {
+		mtime = info.ModTime()
+	}
+	return appendIndex(filepath.Dir(runDir), newIndexEntry(filepath.Base(runDir), m, mtime))
+}
+
+// UnindexRun records the removal of runDir in the index.
+func UnindexRun(runDir string) error {
+	return appendIndex(filepath.Dir(runDir), IndexEntry{Op: indexOpRemove, Name: filepath.Base(runDir)})
+}
+
+func appendIndex(baseDir string, entries ...IndexEntry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	f, err := os.OpenFile(filepath.Join(baseDir, IndexFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	// A single write keeps concurrent appenders from interleaving lines.
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadIndex replays the index of baseDir and returns the live entries by run
+// name, plus the number of lines replayed. Malformed lines (for example a
+// write cut short by a crash) are skipped.
+func ReadIndex(baseDir string) (map[string]IndexEntry, int, error) {
+	f, err := os.Open(filepath.Join(baseDir, IndexFile))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	entries := make(map[string]IndexEntry)
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines++
+		var e IndexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Name == "" {
+			continue
+		}
+		switch e.Op {
+		case indexOpAdd:
+			entries[e.Name] = e
+		case indexOpRemove:
+			delete(entries, e.Name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading %s: %w", IndexFile, err)
+	}
+	return entries, lines, nil
+}
+
+// RebuildIndex reconstructs the index of baseDir from the run manifests on
+// disk and returns the number of runs indexed.
+func RebuildIndex(baseDir string) (int, error) {
+	dirs, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("reading output dir: %w", err)
+	}
+	var entries []IndexEntry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if e, ok := indexDir(baseDir, d.Name()); ok {
+			entries = append(entries, e)
+		}
+	}
+	return len(entries), writeIndex(baseDir, entries)
+}
+
+// indexDir builds the index entry of a run directory from its manifest.
+func indexDir(baseDir, name string) (IndexEntry, bool) {
+	dir := filepath.Join(baseDir, name)
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return IndexEntry{}, false
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return IndexEntry{}, false
+	}
+	return newIndexEntry(name, m, info.ModTime()), true
+}
+
+// writeIndex replaces the index with exactly entries.
+func writeIndex(baseDir string, entries []IndexEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StartedAt.Before(entries[j].StartedAt)
+	})
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return AtomicWrite(filepath.Join(baseDir, IndexFile), buf.Bytes(), 0o600)
+}
@@ -0,0 +1,156 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteManifestIndexesRun(t *testing.T) {
+	base := t.TempDir()
+	dir := writeTestRun(t, base, "run-a", testRun{})
+
+	entries, lines, err := ReadIndex(base)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lines)
+	e := entries["run-a"]
+	assert.Equal(t, "prompt of run-a", e.Prompt)
+	assert.Equal(t, []IndexResult{{ToolID: "claude", Status: "success", Duration: "1s"}}, e.Results)
+
+	// Rewriting the manifest replaces the entry; removal deletes it.
+	m, _ := ReadManifest(dir)
+	m.Prompt = "updated"
+	assert.NoError(t, WriteManifest(dir, m))
+	assert.NoError(t, UnindexRun(filepath.Join(base, "other")))
+	entries, lines, _ = ReadIndex(base)
+	assert.Equal(t, 3, lines)
+	assert.Equal(t, "updated", entries["run-a"].Prompt)
+
+	assert.NoError(t, UnindexRun(dir))
+	entries, _, _ = ReadIndex(base)
+	assert.Empty(t, entries)
+}
+
+func TestReadIndexSkipsMalformedLines(t *testing.T) {
+	base := t.TempDir()
+	writeTestRun(t, base, "run-a", testRun{})
+	f, _ := os.OpenFile(filepath.Join(base, IndexFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"op":"add","name":"trunc`)
+	f.Close()
+
+	entries, _, err := ReadIndex(base)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestIndexTruncatesPrompt(t *testing.T) {
+	e := newIndexEntry("x", &Manifest{Prompt: strings.Repeat("é", 150)}, time.Now())
+	assert.LessOrEqual(t, len(e.Prompt), maxIndexPrompt)
+	assert.True(t, strings.HasPrefix(strings.Repeat("é", 150), e.Prompt))
+}
+
+func TestScanRunsUsesIndex(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	writeTestRun(t, base, "started-first", testRun{StartedAt: now.Add(-2 * time.Hour)})
+	writeTestRun(t, base, "started-last", testRun{StartedAt: now})
+	newer := filepath.Join(base, "started-first")
+	// Directory mtime no longer decides the order; StartedAt does.
+	assert.NoError(t, os.Chtimes(newer, now.Add(time.Hour), now.Add(time.Hour)))
+
+	runs, err := ScanRuns(base)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "started-last", runs[0].Name)
+	assert.NotNil(t, runs[0].Entry)
+	assert.Equal(t, "prompt of started-last", runs[0].Entry.Prompt)
+}
+
+func TestScanRunsHealsIndex(t *testing.T) {
+	base := t.TempDir()
+	writeTestRun(t, base, "kept", testRun{})
+	gone := writeTestRun(t, base, "gone", testRun{})
+	assert.NoError(t, os.RemoveAll(gone))
+
+	// A run written without going through WriteManifest (e.g. copied in).
+	copied := filepath.Join(base, "copied")
+	assert.NoError(t, os.MkdirAll(copied, 0o700))
+	data, _ := os.ReadFile(filepath.Join(base, "kept", "run.json"))
+	assert.NoError(t, os.WriteFile(filepath.Join(copied, "run.json"), data, 0o600))
+
+	// A directory without a manifest is listed but not indexed.
+	assert.NoError(t, os.MkdirAll(filepath.Join(base, "in-progress"), 0o700))
+
+	runs, err := ScanRuns(base)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 3)
+
+	entries, lines, err := ReadIndex(base)
+	assert.NoError(t, err)
+	assert.Contains(t, entries, "kept")
+	assert.Contains(t, entries, "copied")
+	assert.Equal(t, 3, lines, "scans only append to the index")
+
+	// A second scan finds everything in the index and writes nothing.
+	_, err = ScanRuns(base)
+	assert.NoError(t, err)
+	_, lines, _ = ReadIndex(base)
+	assert.Equal(t, 3, lines)
+}
+
+func TestRebuildIndex(t *testing.T) {
+	base := t.TempDir()
+	writeTestRun(t, base, "run-a", testRun{})
+	writeTestRun(t, base, "run-b", testRun{})
+	assert.NoError(t, os.WriteFile(filepath.Join(base, IndexFile), []byte("garbage\n"), 0o600))
+
+	n, err := RebuildIndex(base)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	entries, lines, _ := ReadIndex(base)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, 2, lines)
+
+	n, err = RebuildIndex(filepath.Join(base, "missing"))
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestScanCandidatesSkipsPinned(t *testing.T) {
+	base := t.TempDir()
+	dir := writeTestRun(t, base, "run-pinned", testRun{})
+	writeTestRun(t, base, "run-plain", testRun{})
+
+	m, err := ReadManifest(dir)
+	assert.NoError(t, err)
+	m.Pinned = true
+	m.Tags = []string{"keep"}
+	assert.NoError(t, WriteManifest(dir, m))
+
+	entries, _, err := ReadIndex(base)
+	assert.NoError(t, err)
+	assert.True(t, entries["run-pinned"].Pinned)
+	assert.Equal(t, []string{"keep"}, entries["run-pinned"].Tags)
+
+	candidates, err := ScanCandidates(base, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, "run-plain", candidates[0].Name)
+}
+
+func TestWriteManifestReportsIndexFailure(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "run-a")
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	assert.NoError(t, os.Mkdir(filepath.Join(base, IndexFile), 0o700))
+
+	err := WriteManifest(dir, &Manifest{Prompt: "p"})
+	assert.ErrorContains(t, err, "run.json written, but updating the run index failed")
+	m, err := ReadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "p", m.Prompt)
+}
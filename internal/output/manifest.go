@@ -1,25 +1,50 @@
 package output
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/codebeauty/horde/internal/runner"
+	"github.com/codebeauty/horde/internal/secrets"
 )
 
+// ManifestVersion is the schema version written by BuildManifest.
+// Version 1 manifests (no provenance fields) are still readable.
+const ManifestVersion = 2
+
 type Manifest struct {
-	Version     int              `json:"version"`
-	Prompt      string           `json:"prompt"`
-	StartedAt   time.Time        `json:"startedAt"`
-	CompletedAt time.Time        `json:"completedAt"`
-	Duration    string           `json:"duration"`
-	Platform    string           `json:"platform"`
-	Config      ManifestConfig   `json:"config"`
-	Results     []ManifestResult `json:"results"`
+	Version      int              `json:"version"`
+	HordeVersion string           `json:"hordeVersion,omitempty"`
+	Prompt       string           `json:"prompt"`
+	PromptSHA256 string           `json:"promptSha256,omitempty"`
+	StartedAt    time.Time        `json:"startedAt"`
+	CompletedAt  time.Time        `json:"completedAt"`
+	Duration     string           `json:"duration"`
+	Platform     string           `json:"platform"`
+	Config       ManifestConfig   `json:"config"`
+	Git          *GitInfo         `json:"git,omitempty"`
+	Context      []string         `json:"contextSources,omitempty"`
+	Attachments  []Attachment     `json:"attachments,omitempty"`
+	Results      []ManifestResult `json:"results"`
+
+	ContextReport *ContextReport `json:"contextReport,omitempty"` // how --context fitted its budget
+	Secrets       *SecretsReport `json:"secrets,omitempty"`       // possible secrets found in the prompt
+
+	Synthesis    *ManifestSynthesis `json:"synthesis,omitempty"`
+	FindingsFile string             `json:"findingsFile,omitempty"`
+
+	Tags   []string `json:"tags,omitempty"`
+	Note   string   `json:"note,omitempty"`
+	Pinned bool     `json:"pinned,omitempty"` // never removed by cleanup
 }
 
 type ManifestConfig struct {
@@ -28,15 +53,82 @@ type ManifestConfig struct {
 	MaxParallel int    `json:"maxParallel"`
 }
 
+// ContextReport records how the gathered context was fitted into its token
+// budget: which files were included, and what was truncated or dropped. It
+// also lists passages that look like prompt injection.
+type ContextReport struct {
+	BudgetTokens int              `json:"budgetTokens"`
+	UsedTokens   int              `json:"usedTokens"`
+	Included     []string         `json:"included,omitempty"`
+	Truncated    []ContextItem    `json:"truncated,omitempty"`
+	Dropped      []ContextItem    `json:"dropped,omitempty"`
+	Suspicious   []SuspiciousItem `json:"suspicious,omitempty"`
+}
+
+// ContextItem is a context file or git section and what happened to it.
+type ContextItem struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// SuspiciousItem is a line of gathered context that reads like instructions
+// to the agents, and the heuristic that flagged it.
+type SuspiciousItem struct {
+	Source  string `json:"source"`
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Excerpt string `json:"excerpt"`
+}
+
+// SecretsReport records the possible secrets found in the prompt before it
+// was sent, and what the secret policy did about them. Match positions
+// refer to the prompt before redaction.
+type SecretsReport struct {
+	Policy   string          `json:"policy"`
+	Redacted int             `json:"redacted,omitempty"`
+	Matches  []secrets.Match `json:"matches"`
+}
+
+// Attachment is a file sent along with the prompt and its copy in the run
+// directory.
+type Attachment struct {
+	Source string `json:"source"` // path given to --attach
+	File   string `json:"file"`   // relative to the run directory
+	Bytes  int64  `json:"bytes"`
+}
+
+// GitInfo records the state of the working tree the raid was run from.
+type GitInfo struct {
+	Head   string `json:"head"`
+	Branch string `json:"branch,omitempty"`
+	Dirty  bool   `json:"dirty"`
+}
+
 type ManifestResult struct {
-	ToolID     string      `json:"toolId"`
-	Status     string      `json:"status"`
-	Duration   string      `json:"duration"`
-	ExitCode   int         `json:"exitCode"`
-	OutputFile string      `json:"outputFile"`
-	StderrFile string      `json:"stderrFile"`
-	Cost       *runner.Cost `json:"cost,omitempty"`
-	Expert     string       `json:"expert,omitempty"`
+	ToolID       string              `json:"toolId"`
+	Status       string              `json:"status"`
+	Duration     string              `json:"duration"`
+	ExitCode     int                 `json:"exitCode"`
+	OutputFile   string              `json:"outputFile"`
+	StderrFile   string              `json:"stderrFile"`
+	Cost         *runner.Cost        `json:"cost,omitempty"`
+	Expert       string              `json:"expert,omitempty"`
+	ExpertSHA256 string              `json:"expertSha256,omitempty"`
+	Adapter      string              `json:"adapter,omitempty"`
+	Model        string              `json:"model,omitempty"`
+	AgentVersion string              `json:"agentVersion,omitempty"`
+	Invocation   *ManifestInvocation `json:"invocation,omitempty"`
+
+	Findings      int    `json:"findings,omitempty"`      // structured findings parsed from the response
+	FindingsError string `json:"findingsError,omitempty"` // why the findings block could not be parsed
+}
+
+// ManifestInvocation is a redacted record of the command line an agent was
+// started with. The prompt itself is never stored, only its size.
+type ManifestInvocation struct {
+	Binary     string   `json:"binary"`
+	Args       []string `json:"args"`
+	StdinBytes int      `json:"stdinBytes,omitempty"`
 }
 
 func ReadManifest(dir string) (*Manifest, error) {
@@ -48,16 +140,29 @@ func ReadManifest(dir string) (*Manifest, error) {
 	if err := json.Unmarshal(data, &m); err != nil {
 		return nil, fmt.Errorf("parsing run.json: %w", err)
 	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
 	return &m, nil
 }
 
+// WriteManifest writes run.json and records the run in the index of its
+// output directory. If only the index could not be updated, run.json is
+// still written and the returned error says so; the index then needs
+// 'horde index rebuild' to pick up the change.
 func WriteManifest(dir string, m *Manifest) error {
 	data, err := json.MarshalIndent(m, "", "  ")
 	if err != nil {
 		return err
 	}
 	data = append(data, '\n')
-	return AtomicWrite(filepath.Join(dir, "run.json"), data, 0o600)
+	if err := AtomicWrite(filepath.Join(dir, "run.json"), data, 0o600); err != nil {
+		return err
+	}
+	if err := IndexRun(dir, m); err != nil {
+		return fmt.Errorf("run.json written, but updating the run index failed: %w", err)
+	}
+	return nil
 }
 
 func BuildManifest(prompt string, startedAt time.Time, results []runner.Result, cfg ManifestConfig) *Manifest {
@@ -76,17 +181,121 @@ func BuildManifest(prompt string, startedAt time.Time, results []runner.Result,
 			cost := r.Cost
 			mr.Cost = &cost
 		}
+		if r.Invocation.Binary != "" {
+			mr.Invocation = &ManifestInvocation{
+				Binary:     r.Invocation.Binary,
+				Args:       RedactArgs(r.Invocation.Args),
+				StdinBytes: len(r.Invocation.Stdin),
+			}
+		}
 		mResults[i] = mr
 	}
 
 	return &Manifest{
-		Version:     1,
-		Prompt:      prompt,
-		StartedAt:   startedAt,
-		CompletedAt: completedAt,
-		Duration:    completedAt.Sub(startedAt).Round(time.Millisecond).String(),
-		Platform:    runtime.GOOS + "/" + runtime.GOARCH,
-		Config:      cfg,
-		Results:     mResults,
+		Version:      ManifestVersion,
+		Prompt:       prompt,
+		PromptSHA256: SHA256Hex(prompt),
+		StartedAt:    startedAt,
+		CompletedAt:  completedAt,
+		Duration:     completedAt.Sub(startedAt).Round(time.Millisecond).String(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		Config:       cfg,
+		Results:      mResults,
+	}
+}
+
+// LoadResults reconstructs runner results (output, stderr, status, timing
+// and cost) from a run directory, for viewing a past run in the TUI.
+func LoadResults(m *Manifest, runDir string) []runner.Result {
+	results := make([]runner.Result, len(m.Results))
+	for i, r := range m.Results {
+		res := runner.Result{
+			ToolID:   r.ToolID,
+			Status:   runner.Status(r.Status),
+			ExitCode: r.ExitCode,
+		}
+		res.Duration, _ = time.ParseDuration(r.Duration)
+		if r.Cost != nil {
+			res.Cost = *r.Cost
+		}
+		res.Stdout, _ = os.ReadFile(filepath.Join(runDir, r.OutputFile))
+		if r.StderrFile != "" {
+			res.Stderr, _ = os.ReadFile(filepath.Join(runDir, r.StderrFile))
+		}
+		results[i] = res
+	}
+	return results
+}
+
+// NormalizeTags trims, validates and de-duplicates tags, keeping their order.
+// Tags may not be empty or contain whitespace or commas.
+func NormalizeTags(tags []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || strings.ContainsAny(t, " \t\n,") {
+			return nil, fmt.Errorf("invalid tag %q: tags must be non-empty and contain no spaces or commas", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// HasTags reports whether have contains every tag in want.
+func HasTags(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// SHA256Hex returns the hex-encoded SHA-256 digest of s.
+func SHA256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+var sensitiveFlagRe = regexp.MustCompile(`(?i)^--?[a-z0-9_-]*(key|token|secret|password|passwd|auth)[a-z0-9_-]*$`)
+
+const maxArgLen = 200
+
+// RedactArgs returns a copy of args that is safe to persist: values of
+// credential-looking flags are masked, and prompt-sized arguments (long or
+// multi-line) are replaced with their byte count.
+func RedactArgs(args []string) []string {
+	out := make([]string, len(args))
+	maskNext := false
+	for i, a := range args {
+		switch {
+		case maskNext:
+			out[i] = "<redacted>"
+			maskNext = false
+		case strings.HasPrefix(a, "-") && strings.Contains(a, "="):
+			name, _, _ := strings.Cut(a, "=")
+			if sensitiveFlagRe.MatchString(name) {
+				out[i] = name + "=<redacted>"
+			} else {
+				out[i] = redactLong(a)
+			}
+		case sensitiveFlagRe.MatchString(a):
+			out[i] = a
+			maskNext = true
+		default:
+			out[i] = redactLong(a)
+		}
+	}
+	return out
+}
+
+func redactLong(a string) string {
+	if len(a) > maxArgLen || strings.Contains(a, "\n") {
+		return fmt.Sprintf("<prompt: %d bytes>", len(a))
 	}
+	return a
 }
@@ -4,11 +4,52 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/codebeauty/horde/internal/adapter"
+	"github.com/codebeauty/horde/internal/runner"
 	"github.com/stretchr/testify/assert"
 )
 
+// testRun describes a run for writeTestRun. A zero Prompt, StartedAt or
+// Results becomes "prompt of <name>", now, and one successful claude result.
+type testRun struct {
+	Prompt    string
+	StartedAt time.Time
+	Results   []ManifestResult
+	Files     map[string]string // agent outputs and other files in the run
+}
+
+// writeTestRun writes run name into base: its files, then run.json, which
+// also indexes it. The directory mtime is set to when the run started.
+func writeTestRun(t *testing.T, base, name string, r testRun) string {
+	t.Helper()
+	if r.Prompt == "" {
+		r.Prompt = "prompt of " + name
+	}
+	if r.StartedAt.IsZero() {
+		r.StartedAt = time.Now()
+	}
+	if r.Results == nil {
+		r.Results = []ManifestResult{{ToolID: "claude", Status: "success", Duration: "1s"}}
+	}
+	dir := filepath.Join(base, name)
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	for file, text := range r.Files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(text), 0o600))
+	}
+	assert.NoError(t, WriteManifest(dir, &Manifest{
+		Version:   ManifestVersion,
+		Prompt:    r.Prompt,
+		StartedAt: r.StartedAt,
+		Results:   r.Results,
+	}))
+	assert.NoError(t, os.Chtimes(dir, r.StartedAt, r.StartedAt))
+	return dir
+}
+
 func TestReadManifest(t *testing.T) {
 	t.Run("reads valid manifest", func(t *testing.T) {
 		dir := t.TempDir()
@@ -80,3 +121,93 @@ func TestManifestResultExpertField(t *testing.T) {
 	_, hasExpert := gemini["expert"]
 	assert.False(t, hasExpert, "empty expert should be omitted from JSON")
 }
+
+func TestReadManifestV1(t *testing.T) {
+	dir := t.TempDir()
+	data := `{
+		"version": 1,
+		"prompt": "old run",
+		"startedAt": "2026-02-23T00:06:34Z",
+		"completedAt": "2026-02-23T00:07:01Z",
+		"duration": "26.467s",
+		"platform": "darwin/arm64",
+		"config": {"readOnly": "bestEffort", "timeout": 540, "maxParallel": 4},
+		"results": [{"toolId": "claude", "status": "success", "duration": "26s", "exitCode": 0, "outputFile": "claude.md", "stderrFile": "claude.stderr", "expert": "security"}]
+	}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "run.json"), []byte(data), 0o600))
+
+	m, err := ReadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, m.Version)
+	assert.Nil(t, m.Git)
+	assert.Empty(t, m.HordeVersion)
+	assert.Equal(t, "security", m.Results[0].Expert)
+	assert.Nil(t, m.Results[0].Invocation)
+}
+
+func TestBuildManifestProvenance(t *testing.T) {
+	results := []runner.Result{{
+		ToolID: "claude",
+		Status: runner.StatusSuccess,
+		Invocation: adapter.Invocation{
+			Binary: "/usr/local/bin/claude",
+			Args:   []string{"-p", "--api-key", "sk-secret", "Read the file at /tmp/prompt.md and follow the instructions within it."},
+			Stdin:  "hello",
+		},
+	}}
+
+	m := BuildManifest("review this", time.Now(), results, ManifestConfig{})
+	assert.Equal(t, ManifestVersion, m.Version)
+	assert.Equal(t, SHA256Hex("review this"), m.PromptSHA256)
+	assert.Len(t, m.PromptSHA256, 64)
+
+	inv := m.Results[0].Invocation
+	assert.NotNil(t, inv)
+	assert.Equal(t, "/usr/local/bin/claude", inv.Binary)
+	assert.Equal(t, 5, inv.StdinBytes)
+	assert.Equal(t, "<redacted>", inv.Args[2])
+	assert.NotContains(t, strings.Join(inv.Args, " "), "sk-secret")
+
+	// Round-trips through disk
+	dir := t.TempDir()
+	assert.NoError(t, WriteManifest(dir, m))
+	read, err := ReadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, m.PromptSHA256, read.PromptSHA256)
+	assert.Equal(t, inv.Args, read.Results[0].Invocation.Args)
+}
+
+func TestRedactArgs(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	got := RedactArgs([]string{
+		"--model", "opus",
+		"--token=abc123",
+		"--auth-token", "abc123",
+		"line one\nline two",
+		long,
+	})
+	assert.Equal(t, []string{
+		"--model", "opus",
+		"--token=<redacted>",
+		"--auth-token", "<redacted>",
+		"<prompt: 17 bytes>",
+		"<prompt: 300 bytes>",
+	}, got)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" release ", "perf", "release"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"release", "perf"}, tags)
+
+	for _, bad := range []string{"", "two words", "a,b"} {
+		_, err := NormalizeTags([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	assert.True(t, HasTags([]string{"a", "b"}, nil))
+	assert.True(t, HasTags([]string{"a", "b"}, []string{"b", "a"}))
+	assert.False(t, HasTags([]string{"a"}, []string{"a", "b"}))
+}
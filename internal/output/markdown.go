@@ -0,0 +1,314 @@
+package output
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// RenderMarkdown converts agent markdown to HTML for the run report. It covers
+// the subset agents actually produce (headings, paragraphs, lists, fenced code,
+// blockquotes, tables, emphasis, links) and escapes everything else. Images
+// are rendered as links so the report never fetches remote content.
+func RenderMarkdown(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	renderBlocks(&b, lines)
+	return b.String()
+}
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdFenceRe    = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+-]*)")
+	mdHRRe       = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))+\s*$`)
+	mdListRe     = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdTableSepRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdCodeSpanRe = regexp.MustCompile("`([^`]+)`")
+	mdImageRe    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBoldRe     = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdItalicRe   = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	mdStrikeRe   = regexp.MustCompile(`~~(.+?)~~`)
+	mdSafeLinkRe = regexp.MustCompile(`^(https?://|mailto:|#|/|\./|\.\./|[\w.-]+(/|$))`)
+)
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case mdFenceRe.MatchString(line):
+			m := mdFenceRe.FindStringSubmatch(line)
+			fence := m[1]
+			i++
+			var code []string
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // closing fence
+			if m[2] != "" {
+				fmt.Fprintf(b, "<pre><code class=\"language-%s\">", html.EscapeString(m[2]))
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case mdHeadingRe.MatchString(line):
+			m := mdHeadingRe.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, renderInline(m[2]), level)
+			i++
+
+		case mdHRRe.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(q, " "))
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quote)
+			b.WriteString("</blockquote>\n")
+
+		case mdListRe.MatchString(line):
+			i = renderList(b, lines, i)
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && mdTableSepRe.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			i = renderTable(b, lines, i)
+
+		default:
+			var para []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !startsBlock(lines, i) {
+				para = append(para, strings.TrimSpace(lines[i]))
+				i++
+			}
+			if len(para) == 0 { // a block start we could not render; emit it as text
+				para = append(para, trimmed)
+				i++
+			}
+			fmt.Fprintf(b, "<p>%s</p>\n", renderInline(strings.Join(para, "\n")))
+		}
+	}
+}
+
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	return mdFenceRe.MatchString(line) || mdHeadingRe.MatchString(line) || mdHRRe.MatchString(line) ||
+		strings.HasPrefix(trimmed, ">") || mdListRe.MatchString(line) ||
+		(strings.Contains(line, "|") && i+1 < len(lines) && mdTableSepRe.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"))
+}
+
+// renderList renders the list starting at lines[i] and returns the index of
+// the first line after it. Item bodies (continuation lines and nested lists,
+// indented past the marker) are rendered recursively.
+func renderList(b *strings.Builder, lines []string, i int) int {
+	m := mdListRe.FindStringSubmatch(lines[i])
+	indent := len(m[1])
+	ordered := isOrderedMarker(m[2])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + ">\n")
+
+	for i < len(lines) {
+		m := mdListRe.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != indent || isOrderedMarker(m[2]) != ordered {
+			break
+		}
+		body := []string{m[3]}
+		i++
+		for i < len(lines) {
+			l := lines[i]
+			if strings.TrimSpace(l) == "" {
+				// A blank line continues the item only if indented content follows.
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) > indent {
+					body = append(body, "")
+					i++
+					continue
+				}
+				break
+			}
+			if leadingSpaces(l) <= indent {
+				break
+			}
+			body = append(body, dedent(l, indent+2))
+			i++
+		}
+
+		b.WriteString("<li>")
+		if len(body) == 1 || !needsBlocks(body) {
+			b.WriteString(renderInline(strings.Join(trimAll(body), "\n")))
+		} else {
+			var inner strings.Builder
+			renderBlocks(&inner, body)
+			b.WriteString(unwrapFirstParagraph(inner.String()))
+		}
+		b.WriteString("</li>\n")
+
+		// Skip blank lines between items of the same list.
+		j := i
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		if j > i && j < len(lines) {
+			if m := mdListRe.FindStringSubmatch(lines[j]); m != nil && len(m[1]) == indent && isOrderedMarker(m[2]) == ordered {
+				i = j
+			}
+		}
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func isOrderedMarker(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+func needsBlocks(body []string) bool {
+	for i := 1; i < len(body); i++ {
+		if strings.TrimSpace(body[i]) == "" || startsBlock(body, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func unwrapFirstParagraph(s string) string {
+	if strings.HasPrefix(s, "<p>") {
+		if end := strings.Index(s, "</p>\n"); end >= 0 {
+			return s[3:end] + "\n" + s[end+5:]
+		}
+	}
+	return s
+}
+
+func leadingSpaces(s string) int {
+	n := 0
+	for _, c := range s {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func dedent(s string, n int) string {
+	for n > 0 && len(s) > 0 && (s[0] == ' ' || s[0] == '\t') {
+		s = s[1:]
+		n--
+	}
+	return s
+}
+
+func trimAll(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimSpace(l)
+	}
+	return out
+}
+
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitRow(lines[i])
+	aligns := splitRow(lines[i+1])
+	i += 2
+
+	align := func(col int) string {
+		if col >= len(aligns) {
+			return ""
+		}
+		a := aligns[col]
+		switch {
+		case strings.HasPrefix(a, ":") && strings.HasSuffix(a, ":"):
+			return ` style="text-align:center"`
+		case strings.HasSuffix(a, ":"):
+			return ` style="text-align:right"`
+		}
+		return ""
+	}
+
+	b.WriteString("<table>\n<thead><tr>")
+	for c, h := range header {
+		fmt.Fprintf(b, "<th%s>%s</th>", align(c), renderInline(h))
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+		b.WriteString("<tr>")
+		for c, cell := range splitRow(lines[i]) {
+			fmt.Fprintf(b, "<td%s>%s</td>", align(c), renderInline(cell))
+		}
+		b.WriteString("</tr>\n")
+		i++
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+// renderInline escapes text and applies inline markup. Code spans are
+// rendered verbatim; everything else gets emphasis and link handling.
+func renderInline(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range mdCodeSpanRe.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(renderEmphasis(s[last:loc[0]]))
+		b.WriteString("<code>" + html.EscapeString(s[loc[2]:loc[3]]) + "</code>")
+		last = loc[1]
+	}
+	b.WriteString(renderEmphasis(s[last:]))
+	return strings.ReplaceAll(b.String(), "\n", "<br>\n")
+}
+
+func renderEmphasis(s string) string {
+	s = html.EscapeString(s)
+	s = mdImageRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdImageRe.FindStringSubmatch(m)
+		return linkHTML(parts[2], "image: "+parts[1])
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdLinkRe.FindStringSubmatch(m)
+		return linkHTML(parts[2], parts[1])
+	})
+	s = mdBoldRe.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdItalicRe.ReplaceAllString(s, "<em>$1</em>")
+	s = mdStrikeRe.ReplaceAllString(s, "<del>$1</del>")
+	return s
+}
+
+// linkHTML renders an already-escaped link. URLs with unknown schemes (for
+// example javascript:) are shown as plain text.
+func linkHTML(url, text string) string {
+	if !mdSafeLinkRe.MatchString(url) {
+		return text
+	}
+	return fmt.Sprintf(`<a href="%s" rel="noopener noreferrer">%s</a>`, url, text)
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdownBlocks(t *testing.T) {
+	src := "# Title\n\nSome **bold** and *italic* text\nwith `a < b` code.\n\n" +
+		"```go\nif a < b {}\n```\n\n" +
+		"- one\n- two\n  - nested\n\n1. first\n2. second\n\n" +
+		"> quoted\n\n---\n\n" +
+		"| Col | Num |\n|-----|----:|\n| a | 1 |\n"
+	out := RenderMarkdown(src)
+
+	assert.Contains(t, out, "<h1>Title</h1>")
+	assert.Contains(t, out, "<strong>bold</strong>")
+	assert.Contains(t, out, "<em>italic</em>")
+	assert.Contains(t, out, "<code>a &lt; b</code>")
+	assert.Contains(t, out, "<pre><code class=\"language-go\">if a &lt; b {}</code></pre>")
+	assert.Contains(t, out, "<ul>\n<li>one</li>\n<li>two\n<ul>\n<li>nested</li>\n</ul>\n</li>\n</ul>")
+	assert.Contains(t, out, "<ol>\n<li>first</li>\n<li>second</li>\n</ol>")
+	assert.Contains(t, out, "<blockquote>\n<p>quoted</p>\n</blockquote>")
+	assert.Contains(t, out, "<hr>")
+	assert.Contains(t, out, "<th>Col</th><th style=\"text-align:right\">Num</th>")
+	assert.Contains(t, out, "<td>a</td><td style=\"text-align:right\">1</td>")
+}
+
+func TestRenderMarkdownEscapes(t *testing.T) {
+	out := RenderMarkdown("<script>alert(1)</script>\n\n[x](javascript:alert(1)) [docs](https://example.com/a?b=1&c=2)")
+	assert.NotContains(t, out, "<script>")
+	assert.Contains(t, out, "&lt;script&gt;")
+	assert.NotContains(t, out, "javascript:alert(1)\"")
+	assert.Contains(t, out, `<a href="https://example.com/a?b=1&amp;c=2" rel="noopener noreferrer">docs</a>`)
+}
+
+func TestRenderMarkdownImagesAreLinks(t *testing.T) {
+	out := RenderMarkdown("![diagram](https://example.com/d.png)")
+	assert.NotContains(t, out, "<img")
+	assert.Contains(t, out, `<a href="https://example.com/d.png" rel="noopener noreferrer">image: diagram</a>`)
+}
+
+func TestRenderMarkdownUnclosedFence(t *testing.T) {
+	out := RenderMarkdown("```\ncode without end")
+	assert.Contains(t, out, "<pre><code>code without end</code></pre>")
+}
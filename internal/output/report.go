@@ -0,0 +1,286 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+// ReportFile is the default name of the HTML report in a run directory.
+const ReportFile = "report.html"
+
+type reportData struct {
+	Manifest  *Manifest
+	Started   string
+	Panels    []reportPanel
+	Findings  []MergedFinding
+	TotalCost string
+}
+
+type reportPanel struct {
+	Index     int
+	ToolID    string
+	Raider    string
+	Status    string
+	Duration  string
+	Cost      string
+	Tokens    string
+	Findings  string
+	Diagnosis *runner.Diagnosis
+	Stderr    string
+	Body      template.HTML
+}
+
+// BuildHTMLReport renders a run as a single self-contained HTML page: the
+// prompt, one tab per agent with its rendered response, status, duration and
+// cost badges, failure diagnoses, and the merged findings table when the run
+// has one. All styles are inline and nothing is loaded from the network.
+func BuildHTMLReport(m *Manifest, runDir string) ([]byte, error) {
+	data := reportData{Manifest: m, Started: m.StartedAt.Local().Format(time.RFC1123)}
+
+	var total float64
+	for _, r := range m.Results {
+		p := reportPanel{
+			Index:    len(data.Panels),
+			ToolID:   r.ToolID,
+			Raider:   r.Expert,
+			Status:   r.Status,
+			Duration: r.Duration,
+		}
+		if r.Cost != nil {
+			p.Cost, p.Tokens = costBadges(r.Cost)
+			total += r.Cost.TotalUSD
+		}
+		if m.FindingsFile != "" && r.Status == "success" {
+			if r.FindingsError != "" {
+				p.Findings = "findings: invalid"
+			} else {
+				p.Findings = fmt.Sprintf("%d findings", r.Findings)
+			}
+		}
+		if body, err := os.ReadFile(filepath.Join(runDir, r.OutputFile)); err == nil {
+			p.Body = template.HTML(RenderMarkdown(string(body)))
+		}
+		if r.Status != "success" {
+			p.Diagnosis, p.Stderr = reportStderr(runDir, r.ToolID, r.StderrFile, r.ExitCode)
+		}
+		data.Panels = append(data.Panels, p)
+	}
+
+	if s := m.Synthesis; s != nil {
+		p := reportPanel{
+			Index:    len(data.Panels),
+			ToolID:   SynthesisID,
+			Raider:   "via " + s.ToolID,
+			Status:   s.Status,
+			Duration: s.Duration,
+		}
+		if s.Cost != nil {
+			p.Cost, p.Tokens = costBadges(s.Cost)
+			total += s.Cost.TotalUSD
+		}
+		if body, err := os.ReadFile(filepath.Join(runDir, s.OutputFile)); err == nil {
+			p.Body = template.HTML(RenderMarkdown(string(body)))
+		}
+		if s.Status != "success" {
+			p.Diagnosis, p.Stderr = reportStderr(runDir, s.ToolID, s.StderrFile, s.ExitCode)
+		}
+		data.Panels = append(data.Panels, p)
+	}
+
+	if total > 0 {
+		data.TotalCost = fmt.Sprintf("$%.2f", total)
+	}
+	if m.FindingsFile != "" {
+		if report, err := ReadFindings(runDir); err == nil {
+			data.Findings = report.Findings
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reportTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func costBadges(c *runner.Cost) (cost, tokens string) {
+	if c.TotalUSD > 0 {
+		cost = fmt.Sprintf("$%.2f", c.TotalUSD)
+	}
+	if c.InputTokens > 0 || c.OutputTokens > 0 {
+		tokens = fmt.Sprintf("%d in / %d out", c.InputTokens, c.OutputTokens)
+	}
+	return cost, tokens
+}
+
+const maxReportStderr = 4000
+
+func reportStderr(runDir, toolID, stderrFile string, exitCode int) (*runner.Diagnosis, string) {
+	if stderrFile == "" {
+		return nil, ""
+	}
+	raw, err := os.ReadFile(filepath.Join(runDir, stderrFile))
+	if err != nil {
+		return nil, ""
+	}
+	snippet := strings.TrimSpace(string(raw))
+	if len(snippet) > maxReportStderr {
+		snippet = snippet[len(snippet)-maxReportStderr:]
+	}
+	return runner.Diagnose(toolID, raw, exitCode), snippet
+}
+
+var reportFuncs = template.FuncMap{
+	"location": func(f MergedFinding) string {
+		if f.File == "" {
+			return ""
+		}
+		loc := f.File
+		if f.StartLine > 0 {
+			loc += fmt.Sprintf(":%d", f.StartLine)
+			if f.EndLine > f.StartLine {
+				loc += fmt.Sprintf("-%d", f.EndLine)
+			}
+		}
+		return loc
+	},
+	"reporters": func(f MergedFinding) string {
+		names := make([]string, len(f.Reporters))
+		for i, r := range f.Reporters {
+			names[i] = r.ToolID
+		}
+		return strings.Join(names, ", ")
+	},
+}
+
+var reportTmpl = template.Must(template.New("report").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>horde report — {{.Started}}</title>
+<style>
+:root { --fg: #1f2328; --muted: #656d76; --border: #d0d7de; --bg-soft: #f6f8fa; --accent: #0969da; }
+* { box-sizing: border-box; }
+body { font: 15px/1.55 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: var(--fg); margin: 0; padding: 24px; max-width: 1400px; margin: 0 auto; }
+h1 { font-size: 1.6em; margin: 0 0 4px; }
+h2 { font-size: 1.25em; border-bottom: 1px solid var(--border); padding-bottom: 4px; margin-top: 28px; }
+.meta { color: var(--muted); font-size: 0.9em; }
+.meta span { margin-right: 16px; }
+pre { background: var(--bg-soft); padding: 12px; overflow-x: auto; border-radius: 6px; font-size: 0.88em; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+:not(pre) > code { background: var(--bg-soft); padding: 1px 4px; border-radius: 4px; font-size: 0.88em; }
+.prompt { white-space: pre-wrap; }
+table { border-collapse: collapse; margin: 8px 0; }
+th, td { border: 1px solid var(--border); padding: 4px 10px; text-align: left; vertical-align: top; }
+th { background: var(--bg-soft); }
+blockquote { margin: 0; padding: 0 12px; border-left: 4px solid var(--border); color: var(--muted); }
+.badge { display: inline-block; padding: 1px 8px; border-radius: 10px; font-size: 0.8em; font-weight: 600; background: var(--bg-soft); border: 1px solid var(--border); margin-right: 4px; white-space: nowrap; }
+.status-success { background: #dafbe1; border-color: #4ac26b; color: #116329; }
+.status-failed, .status-error { background: #ffebe9; border-color: #ff8182; color: #a40e26; }
+.status-timeout, .status-cancelled { background: #fff8c5; border-color: #d4a72c; color: #7d4e00; }
+.sev-critical, .sev-high { background: #ffebe9; border-color: #ff8182; color: #a40e26; }
+.sev-medium { background: #fff8c5; border-color: #d4a72c; color: #7d4e00; }
+.diagnosis { border: 1px solid #ff8182; background: #ffebe9; border-radius: 6px; padding: 8px 12px; margin: 8px 0; }
+.toggle, .tab-input { position: absolute; opacity: 0; pointer-events: none; }
+.tabs { display: flex; flex-wrap: wrap; gap: 4px; border-bottom: 1px solid var(--border); margin-top: 12px; }
+.tabs label { padding: 6px 12px; cursor: pointer; border: 1px solid transparent; border-bottom: none; border-radius: 6px 6px 0 0; color: var(--muted); }
+.view-toggle { display: inline-block; margin-top: 12px; cursor: pointer; color: var(--accent); font-size: 0.9em; }
+.panel { display: none; padding: 12px 4px; min-width: 0; }
+.panel-head { border-bottom: 1px dashed var(--border); padding-bottom: 8px; margin-bottom: 8px; }
+.panel-head h3 { display: inline; margin-right: 8px; }
+#columns:checked ~ .tabs { display: none; }
+#columns:checked ~ .panels { display: grid; grid-template-columns: repeat(auto-fit, minmax(420px, 1fr)); gap: 16px; }
+#columns:checked ~ .panels .panel { display: block; border: 1px solid var(--border); border-radius: 6px; padding: 12px; }
+{{- range .Panels}}
+#tab-{{.Index}}:checked ~ .panels #panel-{{.Index}} { display: block; }
+#tab-{{.Index}}:checked ~ .tabs label[for="tab-{{.Index}}"] { border-color: var(--border); background: #fff; color: var(--fg); font-weight: 600; margin-bottom: -1px; }
+{{- end}}
+@media print { .tabs, .view-toggle { display: none; } .panel { display: block !important; page-break-before: always; } }
+</style>
+</head>
+<body>
+<header>
+<h1>Horde run report</h1>
+<div class="meta">
+<span>Started {{.Started}}</span>
+<span>Duration {{.Manifest.Duration}}</span>
+<span>Read-only {{.Manifest.Config.ReadOnly}}</span>
+{{- with .Manifest.Git}}<span>Git {{.Branch}} {{printf "%.12s" .Head}}{{if .Dirty}} (dirty){{end}}</span>{{end}}
+{{- with .Manifest.HordeVersion}}<span>horde {{.}}</span>{{end}}
+{{- with .TotalCost}}<span>Total cost {{.}}</span>{{end}}
+</div>
+</header>
+
+<h2>Prompt</h2>
+<pre class="prompt">{{.Manifest.Prompt}}</pre>
+
+<h2>Agents</h2>
+<table>
+<thead><tr><th>Agent</th><th>Raider</th><th>Status</th><th>Duration</th><th>Cost</th><th>Tokens</th></tr></thead>
+<tbody>
+{{- range .Panels}}
+<tr><td>{{.ToolID}}</td><td>{{.Raider}}</td><td><span class="badge status-{{.Status}}">{{.Status}}</span></td><td>{{.Duration}}</td><td>{{.Cost}}</td><td>{{.Tokens}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+{{- if .Findings}}
+<h2>Findings</h2>
+<table>
+<thead><tr><th>Severity</th><th>Finding</th><th>Location</th><th>Reported by</th><th>Suggested fix</th></tr></thead>
+<tbody>
+{{- range .Findings}}
+<tr><td><span class="badge sev-{{.Severity}}">{{.Severity}}</span></td><td><strong>{{.Title}}</strong>{{with .Rationale}}<br>{{.}}{{end}}</td><td><code>{{location .}}</code></td><td>{{len .Reporters}}: {{reporters .}}</td><td>{{.SuggestedFix}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+<h2>Responses</h2>
+<section class="responses">
+{{- range $i, $p := .Panels}}
+<input class="tab-input" type="radio" name="tab" id="tab-{{$p.Index}}"{{if eq $i 0}} checked{{end}}>
+{{- end}}
+<input class="toggle" type="checkbox" id="columns">
+<label class="view-toggle" for="columns">Toggle tabs / side-by-side columns</label>
+<nav class="tabs">
+{{- range .Panels}}
+<label for="tab-{{.Index}}">{{.ToolID}}{{with .Raider}} · {{.}}{{end}}</label>
+{{- end}}
+</nav>
+<div class="panels">
+{{- range .Panels}}
+<article class="panel" id="panel-{{.Index}}">
+<div class="panel-head">
+<h3>{{.ToolID}}</h3>
+{{- with .Raider}}<span class="badge">{{.}}</span>{{end}}
+<span class="badge status-{{.Status}}">{{.Status}}</span>
+<span class="badge">{{.Duration}}</span>
+{{- with .Cost}}<span class="badge">{{.}}</span>{{end}}
+{{- with .Tokens}}<span class="badge">{{.}}</span>{{end}}
+{{- with .Findings}}<span class="badge">{{.}}</span>{{end}}
+</div>
+{{- with .Diagnosis}}
+<div class="diagnosis"><strong>{{.Message}}</strong><br>{{.Suggestion}}</div>
+{{- end}}
+{{- with .Stderr}}
+<details open><summary>stderr</summary><pre>{{.}}</pre></details>
+{{- end}}
+<div class="markdown">
+{{.Body}}
+</div>
+</article>
+{{- end}}
+</div>
+</section>
+</body>
+</html>
+`))
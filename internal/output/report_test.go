@@ -0,0 +1,70 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+func TestBuildHTMLReport(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude.md"), []byte(sampleResponse), 0o600)
+	os.WriteFile(filepath.Join(dir, "gemini@security.md"), []byte("## Verdict\n\nLooks **fine**."), 0o600)
+	os.WriteFile(filepath.Join(dir, "codex.md"), nil, 0o600)
+	os.WriteFile(filepath.Join(dir, "codex.stderr"), []byte("Error: 401 Unauthorized"), 0o600)
+	os.WriteFile(filepath.Join(dir, "synthesis.md"), []byte("# Consensus\n\nAll agree."), 0o600)
+
+	m := &Manifest{
+		Prompt:    "review <auth>",
+		StartedAt: time.Now(),
+		Duration:  "42s",
+		Config:    ManifestConfig{ReadOnly: "enforced"},
+		Results: []ManifestResult{
+			{ToolID: "claude", Status: "success", Duration: "30s", OutputFile: "claude.md",
+				Cost: &runner.Cost{InputTokens: 1000, OutputTokens: 200, TotalUSD: 0.25}},
+			{ToolID: "gemini@security", Expert: "security", Status: "success", Duration: "20s", OutputFile: "gemini@security.md"},
+			{ToolID: "codex", Status: "failed", Duration: "1s", ExitCode: 1, OutputFile: "codex.md", StderrFile: "codex.stderr"},
+		},
+		Synthesis: &ManifestSynthesis{ToolID: "claude", Status: "success", Duration: "5s", OutputFile: "synthesis.md",
+			Cost: &runner.Cost{TotalUSD: 0.05}},
+	}
+	m.FindingsFile = FindingsFile
+	report := CollectFindings(m, dir)
+	assert.NoError(t, WriteFindings(dir, report))
+
+	data, err := BuildHTMLReport(m, dir)
+	assert.NoError(t, err)
+	html := string(data)
+
+	assert.Contains(t, html, "review &lt;auth&gt;")
+	assert.Contains(t, html, "<h2>Verdict</h2>")
+	assert.Contains(t, html, "<h1>Consensus</h1>")
+	assert.Contains(t, html, `<span class="badge status-failed">failed</span>`)
+	assert.Contains(t, html, "$0.25")
+	assert.Contains(t, html, "Total cost $0.30")
+	assert.Contains(t, html, "Authentication failed")
+	assert.Contains(t, html, "SQL injection in login query")
+	assert.Contains(t, html, "auth/login.go:42-48")
+	assert.Contains(t, html, `id="panel-3"`) // synthesis tab
+
+	// Self-contained: no external scripts, stylesheets or images.
+	assert.False(t, regexp.MustCompile(`<(script|link|img)\b`).MatchString(html))
+	assert.NotContains(t, html, "src=")
+}
+
+func TestBuildHTMLReportWithoutFindings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude.md"), []byte("hello"), 0o600)
+	m := &Manifest{Results: []ManifestResult{{ToolID: "claude", Status: "success", OutputFile: "claude.md"}}}
+
+	data, err := BuildHTMLReport(m, dir)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "<h2>Findings</h2>")
+	assert.Contains(t, string(data), "<p>hello</p>")
+}
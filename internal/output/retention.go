@@ -0,0 +1,201 @@
+package output
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy decides which runs cleanup removes. Zero fields disable
+// their rule; KeepLast on its own, without MaxAge or MaxSize, removes every
+// run but the N newest. Pinned runs are always kept.
+type RetentionPolicy struct {
+	MaxAge     time.Duration // remove runs started longer ago than this
+	KeepLast   int           // never remove the N newest runs
+	MaxSize    int64         // remove oldest runs until the total size fits
+	KeepFailed time.Duration // age limit for runs with failed agents, if longer than MaxAge (or MaxAge is unset)
+}
+
+// PruneCandidate is a run selected for removal and the rule that selected it.
+type PruneCandidate struct {
+	Candidate
+	Size   int64
+	Reason string
+}
+
+// SkippedDir is a directory cleanup leaves alone regardless of policy.
+type SkippedDir struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// SelectForPrune applies p to the runs in baseDir and returns the runs to
+// remove, oldest first. Age is measured from the manifest's StartedAt
+// (directory mtime for runs without a manifest). Directories that are not
+// horde runs, and runs still being written, are returned as skipped.
+func SelectForPrune(baseDir string, p RetentionPolicy, now time.Time) ([]PruneCandidate, []SkippedDir, error) {
+	dirs, err := ScanRuns(baseDir) // newest first
+	if err != nil {
+		return nil, nil, err
+	}
+	var runs []Candidate
+	var skipped []SkippedDir
+	for _, d := range dirs {
+		switch lock, locked := ActiveLock(d.Path); {
+		case !d.IsRun():
+			skipped = append(skipped, SkippedDir{d.Name, d.Path, "not a horde run (no " + MarkerFile + " or run.json)"})
+		case locked:
+			skipped = append(skipped, SkippedDir{d.Name, d.Path, fmt.Sprintf("in progress (locked by pid %d)", lock.PID)})
+		default:
+			runs = append(runs, d)
+		}
+	}
+
+	sizes := make([]int64, len(runs))
+	var total int64
+	if p.MaxSize > 0 {
+		for i, r := range runs {
+			sizes[i] = dirSize(r.Path)
+			total += sizes[i]
+		}
+	}
+
+	selected := make([]string, len(runs)) // reason per run, "" when kept
+	kept := 0
+	for i, r := range runs {
+		if r.Pinned() {
+			continue
+		}
+		kept++
+		if kept <= p.KeepLast {
+			continue
+		}
+		limit, what := p.MaxAge, "older than "+FormatDuration(p.MaxAge)
+		if p.KeepFailed > limit && hasFailures(r) {
+			limit, what = p.KeepFailed, "failed run older than "+FormatDuration(p.KeepFailed)
+		}
+		if limit <= 0 {
+			// Without an age or size rule, KeepLast alone keeps the N newest
+			// runs and removes the rest.
+			if p.KeepLast > 0 && p.MaxSize <= 0 {
+				selected[i] = fmt.Sprintf("not among the %d newest runs", p.KeepLast)
+			}
+			continue
+		}
+		if age := now.Sub(r.sortTime()); age > limit {
+			selected[i] = fmt.Sprintf("%s (started %s ago)", what, FormatDuration(age))
+			total -= sizes[i]
+		}
+	}
+
+	if p.MaxSize > 0 && total > p.MaxSize {
+		protected := 0
+		for i, r := range runs {
+			if !r.Pinned() {
+				protected++
+			}
+			if r.Pinned() || protected <= p.KeepLast {
+				sizes[i] = -1 // mark as not removable
+			}
+		}
+		for i := len(runs) - 1; i >= 0 && total > p.MaxSize; i-- {
+			if selected[i] != "" || sizes[i] < 0 {
+				continue
+			}
+			selected[i] = fmt.Sprintf("over size quota (%s > %s)", FormatSize(total), FormatSize(p.MaxSize))
+			total -= sizes[i]
+		}
+	}
+
+	var out []PruneCandidate
+	for i := len(runs) - 1; i >= 0; i-- {
+		if selected[i] != "" {
+			out = append(out, PruneCandidate{Candidate: runs[i], Size: max(sizes[i], 0), Reason: selected[i]})
+		}
+	}
+	return out, skipped, nil
+}
+
+// hasFailures reports whether any agent of an indexed run did not succeed.
+func hasFailures(c Candidate) bool {
+	if c.Entry == nil {
+		return false
+	}
+	for _, r := range c.Entry.Results {
+		if r.Status != "success" {
+			return true
+		}
+	}
+	return false
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// ParseSize parses a size such as "500MB", "2G" or "1024" (bytes). Units
+// are powers of 1024.
+func ParseSize(input string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(input))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSuffix(s, u.suffix), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", input)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// FormatSize renders a byte count with a binary unit.
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// FormatDuration renders a duration in the largest whole unit of the
+// cleanup syntax (w, d, h, m, s).
+func FormatDuration(d time.Duration) string {
+	units := []struct {
+		suffix string
+		d      time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}}
+	for _, u := range units {
+		if d >= u.d {
+			return fmt.Sprintf("%d%s", d/u.d, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
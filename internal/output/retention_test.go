@@ -0,0 +1,160 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// retentionRun is a run started at started whose one agent output is size
+// bytes.
+func retentionRun(started time.Time, status string, size int) testRun {
+	return testRun{
+		StartedAt: started,
+		Results:   []ManifestResult{{ToolID: "a", Status: status, OutputFile: "a.md"}},
+		Files:     map[string]string{"a.md": strings.Repeat("x", size)},
+	}
+}
+
+func pruneNames(c []PruneCandidate) []string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestSelectForPrune(t *testing.T) {
+	now := time.Now()
+	base := t.TempDir()
+	writeTestRun(t, base, "run-1", retentionRun(now.Add(-10*24*time.Hour), "success", 1000))
+	writeTestRun(t, base, "run-2", retentionRun(now.Add(-8*24*time.Hour), "failed", 1000))
+	writeTestRun(t, base, "run-3", retentionRun(now.Add(-3*24*time.Hour), "success", 1000))
+	writeTestRun(t, base, "run-4", retentionRun(now.Add(-1*time.Hour), "success", 1000))
+
+	t.Run("max age uses StartedAt", func(t *testing.T) {
+		// Touch every directory: mtime must not matter.
+		for _, n := range []string{"run-1", "run-2", "run-3", "run-4"} {
+			assert.NoError(t, os.Chtimes(filepath.Join(base, n), now, now))
+		}
+		c, _, err := SelectForPrune(base, RetentionPolicy{MaxAge: 2 * 24 * time.Hour}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-1", "run-2", "run-3"}, pruneNames(c))
+		assert.Equal(t, "older than 2d (started 1w ago)", c[0].Reason)
+	})
+
+	t.Run("keep last", func(t *testing.T) {
+		c, _, err := SelectForPrune(base, RetentionPolicy{MaxAge: time.Hour / 2, KeepLast: 3}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-1"}, pruneNames(c))
+	})
+
+	t.Run("keep failed longer", func(t *testing.T) {
+		c, _, err := SelectForPrune(base, RetentionPolicy{MaxAge: 2 * 24 * time.Hour, KeepFailed: 30 * 24 * time.Hour}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-1", "run-3"}, pruneNames(c))
+	})
+
+	t.Run("size quota removes oldest first", func(t *testing.T) {
+		c, _, err := SelectForPrune(base, RetentionPolicy{MaxSize: 3500}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-1", "run-2"}, pruneNames(c))
+		assert.True(t, strings.HasPrefix(c[0].Reason, "over size quota"), c[0].Reason)
+		assert.Greater(t, c[0].Size, int64(1000))
+	})
+
+	t.Run("keep last alone", func(t *testing.T) {
+		c, _, err := SelectForPrune(base, RetentionPolicy{KeepLast: 2}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-1", "run-2"}, pruneNames(c))
+		assert.Equal(t, "not among the 2 newest runs", c[0].Reason)
+	})
+
+	t.Run("keep last protects from the size quota only", func(t *testing.T) {
+		c, _, err := SelectForPrune(base, RetentionPolicy{KeepLast: 3, MaxSize: 3500}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-1"}, pruneNames(c))
+	})
+
+	t.Run("keep failed alone", func(t *testing.T) {
+		c, _, err := SelectForPrune(base, RetentionPolicy{KeepFailed: 7 * 24 * time.Hour}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-2"}, pruneNames(c))
+	})
+
+	t.Run("pinned runs are kept", func(t *testing.T) {
+		dir := filepath.Join(base, "run-1")
+		m, err := ReadManifest(dir)
+		assert.NoError(t, err)
+		m.Pinned = true
+		assert.NoError(t, WriteManifest(dir, m))
+
+		c, _, err := SelectForPrune(base, RetentionPolicy{MaxAge: time.Minute}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"run-2", "run-3", "run-4"}, pruneNames(c))
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"1024", 1024},
+		{"2KB", 2048},
+		{"1.5k", 1536},
+		{"500MB", 500 << 20},
+		{"2GiB", 2 << 30},
+		{"1 G", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+	for _, bad := range []string{"", "MB", "-1", "ten"} {
+		_, err := ParseSize(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFormatSizeAndDuration(t *testing.T) {
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KB", FormatSize(1536))
+	assert.Equal(t, "2.0 GB", FormatSize(2<<30))
+	assert.Equal(t, "2w", FormatDuration(15*24*time.Hour))
+	assert.Equal(t, "3h", FormatDuration(3*time.Hour+5*time.Minute))
+	assert.Equal(t, "42s", FormatDuration(42*time.Second))
+}
+
+func TestSelectForPruneSkipsForeignAndActive(t *testing.T) {
+	now := time.Now()
+	base := t.TempDir()
+	writeTestRun(t, base, "run-done", retentionRun(now.Add(-48*time.Hour), "success", 10))
+	writeTestRun(t, base, "run-active", retentionRun(now.Add(-48*time.Hour), "success", 10))
+	assert.NoError(t, LockRun(filepath.Join(base, "run-active")))
+	assert.NoError(t, os.Mkdir(filepath.Join(base, "notes"), 0o700))
+	assert.NoError(t, os.Mkdir(filepath.Join(base, ".horde-import-123"), 0o700))
+
+	c, skipped, err := SelectForPrune(base, RetentionPolicy{MaxAge: time.Hour}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"run-done"}, pruneNames(c))
+
+	reasons := map[string]string{}
+	for _, s := range skipped {
+		reasons[s.Name] = s.Reason
+	}
+	assert.Len(t, reasons, 2)
+	assert.Contains(t, reasons["run-active"], "in progress")
+	assert.Contains(t, reasons["notes"], "not a horde run")
+
+	assert.NoError(t, UnlockRun(filepath.Join(base, "run-active")))
+	c, skipped, err = SelectForPrune(base, RetentionPolicy{MaxAge: time.Hour}, now)
+	assert.NoError(t, err)
+	assert.Len(t, c, 2)
+	assert.Len(t, skipped, 1)
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	// MarkerFile identifies a directory as a horde run. Cleanup only removes
+	// directories carrying it or a run manifest.
+	MarkerFile = ".horde-run"
+	// LockFile exists while a run is being written.
+	LockFile = ".horde-lock"
+
+	markerVersion = 1
+
+	// staleLockAge bounds how long a lock from another host is honoured,
+	// since its process cannot be checked.
+	staleLockAge = 48 * time.Hour
+)
+
+type runMarker struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RunLock describes the process writing a run.
+type RunLock struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// WriteMarker marks dir as a horde run directory.
+func WriteMarker(dir string) error {
+	data, err := json.Marshal(runMarker{Version: markerVersion, CreatedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	return AtomicWrite(filepath.Join(dir, MarkerFile), append(data, '\n'), 0o600)
+}
+
+// HasMarker reports whether dir carries a valid run marker.
+func HasMarker(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, MarkerFile))
+	if err != nil {
+		return false
+	}
+	var m runMarker
+	return json.Unmarshal(data, &m) == nil && m.Version > 0
+}
+
+// LockRun records that the current process is writing dir. It fails if
+// another live process holds the lock; stale locks are taken over.
+func LockRun(dir string) error {
+	if lock, ok := ActiveLock(dir); ok {
+		return fmt.Errorf("run %s is in use by pid %d on %s", filepath.Base(dir), lock.PID, lock.Host)
+	}
+	host, _ := os.Hostname()
+	data, err := json.Marshal(RunLock{PID: os.Getpid(), Host: host, StartedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	return AtomicWrite(filepath.Join(dir, LockFile), append(data, '\n'), 0o600)
+}
+
+// UnlockRun releases the lock on dir.
+func UnlockRun(dir string) error {
+	err := os.Remove(filepath.Join(dir, LockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ActiveLock returns the lock on dir if the process holding it may still be
+// running. Locks of dead processes on this host, and locks from other hosts
+// older than two days, are stale.
+func ActiveLock(dir string) (*RunLock, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil, false
+	}
+	var lock RunLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		// Unreadable lock (for example a write cut short): honour it until
+		// it is old enough to be stale.
+		info, err := os.Stat(filepath.Join(dir, LockFile))
+		if err != nil || time.Since(info.ModTime()) > staleLockAge {
+			return nil, false
+		}
+		return &lock, true
+	}
+	if host, _ := os.Hostname(); lock.Host == host {
+		if !processAlive(lock.PID) {
+			return nil, false
+		}
+	} else if time.Since(lock.StartedAt) > staleLockAge {
+		return nil, false
+	}
+	return &lock, true
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
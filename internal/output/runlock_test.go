@@ -0,0 +1,54 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunDirMarksAndLocks(t *testing.T) {
+	dir, err := RunDir(t.TempDir(), "review the cache")
+	assert.NoError(t, err)
+	assert.True(t, HasMarker(dir))
+
+	lock, locked := ActiveLock(dir)
+	if assert.True(t, locked) {
+		assert.Equal(t, os.Getpid(), lock.PID)
+	}
+	err = LockRun(dir)
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "in use"), err.Error())
+	}
+
+	assert.NoError(t, UnlockRun(dir))
+	_, locked = ActiveLock(dir)
+	assert.False(t, locked)
+	assert.NoError(t, UnlockRun(dir), "unlocking twice is fine")
+}
+
+func TestHasMarkerRejectsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	assert.False(t, HasMarker(dir))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, MarkerFile), []byte("junk"), 0o600))
+	assert.False(t, HasMarker(dir))
+}
+
+func TestActiveLockFromOtherHost(t *testing.T) {
+	dir := t.TempDir()
+	write := func(started time.Time) {
+		data := `{"pid":1,"host":"elsewhere.invalid","startedAt":"` + started.Format(time.RFC3339) + `"}`
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, LockFile), []byte(data), 0o600))
+	}
+
+	write(time.Now().Add(-time.Hour))
+	_, locked := ActiveLock(dir)
+	assert.True(t, locked, "cannot check processes on other hosts")
+
+	write(time.Now().Add(-72 * time.Hour))
+	_, locked = ActiveLock(dir)
+	assert.False(t, locked, "old locks from other hosts are stale")
+}
@@ -0,0 +1,237 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// fingerprintKey names the partial fingerprint horde emits. It hashes
+	// the file and normalized title, not line numbers, so a finding keeps
+	// its identity when surrounding code moves between runs.
+	fingerprintKey = "hordeFinding/v1"
+
+	// lineFingerprintKey names a secondary fingerprint that adds the start
+	// line, so findings with the same title at different places in a file
+	// stay distinct alerts.
+	lineFingerprintKey = "hordeFindingLine/v1"
+)
+
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool              SarifTool               `json:"tool"`
+	AutomationDetails *SarifAutomationDetails `json:"automationDetails,omitempty"`
+	Invocations       []SarifInvocation       `json:"invocations,omitempty"`
+	Results           []SarifResult           `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID               string            `json:"id"`
+	ShortDescription SarifMessage      `json:"shortDescription"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type SarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type SarifInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+type SarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             SarifMessage      `json:"message"`
+	Locations           []SarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]string `json:"properties,omitempty"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type SarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// BuildSARIF converts the structured findings in each agent's response into
+// a SARIF 2.1.0 log with one run (tool driver) per agent and raider. Agents
+// without a valid findings block are skipped.
+func BuildSARIF(m *Manifest, runDir string) (*SarifLog, error) {
+	log := &SarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []SarifRun{}}
+	for _, r := range m.Results {
+		if r.Status != "success" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(runDir, r.OutputFile))
+		if err != nil {
+			continue
+		}
+		findings, err := ParseFindings(string(data))
+		if err != nil {
+			continue
+		}
+		log.Runs = append(log.Runs, sarifRun(m, r, findings))
+	}
+	if len(log.Runs) == 0 {
+		return nil, fmt.Errorf("no structured findings in run (raid with --findings to request them)")
+	}
+	return log, nil
+}
+
+func sarifRun(m *Manifest, r ManifestResult, findings []Finding) SarifRun {
+	name := "horde/" + agentName(r.ToolID)
+	if r.Expert != "" {
+		name += "@" + r.Expert
+	}
+	run := SarifRun{
+		Tool: SarifTool{Driver: SarifDriver{
+			Name:           name,
+			Version:        m.HordeVersion,
+			InformationURI: "https://github.com/codebeauty/horde",
+			Rules:          []SarifRule{},
+		}},
+		AutomationDetails: &SarifAutomationDetails{ID: "horde/" + r.ToolID + "/"},
+		Invocations:       []SarifInvocation{{ExecutionSuccessful: true}},
+		Results:           []SarifResult{},
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, f := range findings {
+		id := FindingRuleID(f)
+		idx, ok := ruleIndex[id]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[id] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, SarifRule{
+				ID:               id,
+				ShortDescription: SarifMessage{Text: f.Title},
+				Properties:       map[string]string{"severity": f.Severity},
+			})
+		}
+
+		res := SarifResult{
+			RuleID:              id,
+			RuleIndex:           idx,
+			Level:               sarifLevel(f.Severity),
+			Message:             SarifMessage{Text: sarifMessage(f)},
+			PartialFingerprints: sarifFingerprints(f),
+			Properties:          map[string]string{"severity": f.Severity, "agent": r.ToolID},
+		}
+		if r.Expert != "" {
+			res.Properties["raider"] = r.Expert
+		}
+		if f.File != "" {
+			loc := SarifLocation{PhysicalLocation: SarifPhysicalLocation{
+				ArtifactLocation: SarifArtifactLocation{URI: filepath.ToSlash(f.File), URIBaseID: "%SRCROOT%"},
+			}}
+			if f.StartLine > 0 {
+				loc.PhysicalLocation.Region = &SarifRegion{StartLine: f.StartLine, EndLine: f.EndLine}
+			}
+			res.Locations = []SarifLocation{loc}
+		}
+		run.Results = append(run.Results, res)
+	}
+	return run
+}
+
+// FindingRuleID derives a stable rule ID from a finding's normalized title,
+// so the same issue maps to the same rule across agents and runs.
+func FindingRuleID(f Finding) string {
+	return "horde." + SHA256Hex(normalizedTitle(f.Title))[:12]
+}
+
+// FindingFingerprint identifies a finding by file and normalized title.
+func FindingFingerprint(f Finding) string {
+	return SHA256Hex(f.File + "\x00" + normalizedTitle(f.Title))
+}
+
+func sarifFingerprints(f Finding) map[string]string {
+	fp := FindingFingerprint(f)
+	fps := map[string]string{fingerprintKey: fp}
+	if f.StartLine > 0 {
+		fps[lineFingerprintKey] = SHA256Hex(fmt.Sprintf("%s\x00%d", fp, f.StartLine))
+	}
+	return fps
+}
+
+func normalizedTitle(title string) string {
+	words := make([]string, 0)
+	for w := range titleWords(title) {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sarifMessage(f Finding) string {
+	parts := []string{f.Title}
+	if f.Rationale != "" {
+		parts = append(parts, f.Rationale)
+	}
+	if f.SuggestedFix != "" {
+		parts = append(parts, "Suggested fix: "+f.SuggestedFix)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// MarshalSARIF renders a SARIF log as indented JSON.
+func MarshalSARIF(log *SarifLog) ([]byte, error) {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
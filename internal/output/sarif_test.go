@@ -0,0 +1,371 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sarifRunDir(t *testing.T) (string, *Manifest) {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude@security.md"), []byte(sampleResponse), 0o600)
+	os.WriteFile(filepath.Join(dir, "gemini.md"), []byte(sampleResponse), 0o600)
+	os.WriteFile(filepath.Join(dir, "codex.md"), []byte("no findings block"), 0o600)
+	m := &Manifest{
+		HordeVersion: "1.2.3",
+		Results: []ManifestResult{
+			{ToolID: "claude@security", Expert: "security", Status: "success", OutputFile: "claude@security.md"},
+			{ToolID: "gemini", Status: "success", OutputFile: "gemini.md"},
+			{ToolID: "codex", Status: "success", OutputFile: "codex.md"},
+			{ToolID: "amp", Status: "failed", OutputFile: "amp.md"},
+		},
+	}
+	return dir, m
+}
+
+func TestBuildSARIF(t *testing.T) {
+	dir, m := sarifRunDir(t)
+	log, err := BuildSARIF(m, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 2)
+	assert.Equal(t, "horde/claude@security", log.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, "horde/gemini", log.Runs[1].Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", log.Runs[0].Tool.Driver.Version)
+
+	run := log.Runs[0]
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Len(t, run.Results, 2)
+
+	high := run.Results[0]
+	assert.Equal(t, "error", high.Level)
+	assert.Equal(t, run.Tool.Driver.Rules[high.RuleIndex].ID, high.RuleID)
+	assert.Equal(t, "auth/login.go", high.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &SarifRegion{StartLine: 42, EndLine: 48}, high.Locations[0].PhysicalLocation.Region)
+	assert.Contains(t, high.Message.Text, "Suggested fix: parameterize")
+	assert.Equal(t, "security", high.Properties["raider"])
+
+	general := run.Results[1]
+	assert.Equal(t, "note", general.Level)
+	assert.Empty(t, general.Locations)
+}
+
+func TestBuildSARIFStableIdentity(t *testing.T) {
+	dir, m := sarifRunDir(t)
+	a, err := BuildSARIF(m, dir)
+	assert.NoError(t, err)
+	b, err := BuildSARIF(m, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	// The same issue reported by different agents shares rule and fingerprint.
+	assert.Equal(t, a.Runs[0].Results[0].RuleID, a.Runs[1].Results[0].RuleID)
+	assert.Equal(t, a.Runs[0].Results[0].PartialFingerprints, a.Runs[1].Results[0].PartialFingerprints)
+
+	// Fingerprints survive rewording of the title order and moved lines.
+	f1 := Finding{Title: "SQL injection in login query", File: "auth/login.go", StartLine: 42}
+	f2 := Finding{Title: "Login query: SQL injection", File: "auth/login.go", StartLine: 80}
+	assert.Equal(t, FindingFingerprint(f1), FindingFingerprint(f2))
+	assert.Equal(t, FindingRuleID(f1), FindingRuleID(f2))
+	assert.NotEqual(t, FindingFingerprint(f1), FindingFingerprint(Finding{Title: f1.Title, File: "other.go"}))
+
+	// The line is only a secondary fingerprint: the primary one survives
+	// moved lines, and the same issue at two places in a file is two alerts.
+	fp1, fp2 := sarifFingerprints(f1), sarifFingerprints(f2)
+	assert.Equal(t, fp1[fingerprintKey], fp2[fingerprintKey])
+	assert.NotEqual(t, fp1[lineFingerprintKey], fp2[lineFingerprintKey])
+	assert.Equal(t, fp1, sarifFingerprints(Finding{Title: "Login query: SQL injection", File: f1.File, StartLine: 42}))
+	assert.NotContains(t, sarifFingerprints(Finding{Title: f1.Title}), lineFingerprintKey)
+}
+
+func TestBuildSARIFNoFindings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude.md"), []byte("prose only"), 0o600)
+	m := &Manifest{Results: []ManifestResult{{ToolID: "claude", Status: "success", OutputFile: "claude.md"}}}
+	_, err := BuildSARIF(m, dir)
+	assert.Error(t, err)
+}
+
+func TestSarifLevel(t *testing.T) {
+	assert.Equal(t, "error", sarifLevel("critical"))
+	assert.Equal(t, "error", sarifLevel("high"))
+	assert.Equal(t, "warning", sarifLevel("medium"))
+	assert.Equal(t, "note", sarifLevel("low"))
+	assert.Equal(t, "note", sarifLevel("info"))
+}
+
+func TestMarshalSARIF(t *testing.T) {
+	dir, m := sarifRunDir(t)
+	log, err := BuildSARIF(m, dir)
+	assert.NoError(t, err)
+	data, err := MarshalSARIF(log)
+	assert.NoError(t, err)
+
+	// Check the encoded form consumers such as code scanning read: results
+	// point at rules that exist, and regions use 1-based lines.
+	var doc struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID              string            `json:"ruleId"`
+				RuleIndex           *int              `json:"ruleIndex"`
+				Level               string            `json:"level"`
+				Message             map[string]string `json:"message"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+				Locations           []struct {
+					PhysicalLocation struct {
+						Region *struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, sarifSchema, doc.Schema)
+	assert.Equal(t, "2.1.0", doc.Version)
+	for _, run := range doc.Runs {
+		for _, res := range run.Results {
+			if assert.NotNil(t, res.RuleIndex) && assert.Less(t, *res.RuleIndex, len(run.Tool.Driver.Rules)) {
+				assert.Equal(t, res.RuleID, run.Tool.Driver.Rules[*res.RuleIndex].ID)
+			}
+			assert.Contains(t, []string{"error", "warning", "note"}, res.Level)
+			assert.NotEmpty(t, res.Message["text"])
+			assert.NotEmpty(t, res.PartialFingerprints[fingerprintKey])
+			for _, loc := range res.Locations {
+				if r := loc.PhysicalLocation.Region; r != nil {
+					assert.GreaterOrEqual(t, r.StartLine, 1)
+				}
+			}
+		}
+	}
+}
+
+func TestSARIFMatchesSchema(t *testing.T) {
+	dir, m := sarifRunDir(t)
+	log, err := BuildSARIF(m, dir)
+	assert.NoError(t, err)
+	data, err := MarshalSARIF(log)
+	assert.NoError(t, err)
+
+	// testdata/sarif-schema-2.1.0.json is the OASIS SARIF 2.1.0 schema
+	// (errata01), unmodified.
+	schemaData, err := os.ReadFile(filepath.Join("testdata", "sarif-schema-2.1.0.json"))
+	assert.NoError(t, err)
+	var schema, doc map[string]any
+	assert.NoError(t, json.Unmarshal(schemaData, &schema))
+	assert.NoError(t, json.Unmarshal(data, &doc))
+
+	v := &schemaValidator{root: schema}
+	v.validate("$", schema, doc)
+	assert.Empty(t, v.errs)
+
+	// The validator must reject what the schema forbids.
+	run := doc["runs"].([]any)[0].(map[string]any)
+	run["results"].([]any)[0].(map[string]any)["level"] = "fatal"
+	delete(run["tool"].(map[string]any)["driver"].(map[string]any), "name")
+	v = &schemaValidator{root: schema}
+	v.validate("$", schema, doc)
+	assert.Len(t, v.errs, 2)
+}
+
+// schemaValidator checks a document against the draft-04 JSON Schema keywords
+// used by testdata/sarif-schema-2.1.0.json.
+type schemaValidator struct {
+	root map[string]any
+	errs []string
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// matches reports whether val is valid against schema, without recording
+// errors.
+func (v *schemaValidator) matches(path string, schema, val any) bool {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(path, schema.(map[string]any), val)
+	return len(sub.errs) == 0
+}
+
+func (v *schemaValidator) validate(path string, schema map[string]any, val any) {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := v.root["definitions"].(map[string]any)[strings.TrimPrefix(ref, "#/definitions/")]
+		v.validate(path, def.(map[string]any), val)
+		return
+	}
+	if types, ok := schema["type"]; ok && !matchesType(types, val) {
+		v.fail(path, "type %v, got %T", types, val)
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if e == val {
+				found = true
+			}
+		}
+		if !found {
+			v.fail(path, "%v not in %v", val, enum)
+		}
+	}
+	if n, isNum := val.(float64); isNum {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			v.fail(path, "%v below minimum %v", n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			v.fail(path, "%v above maximum %v", n, max)
+		}
+	}
+	if s, isString := val.(string); isString {
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			v.fail(path, "%q does not match %q", s, pattern)
+		}
+		if format, ok := schema["format"].(string); ok && !matchesFormat(format, s) {
+			v.fail(path, "%q is not a %s", s, format)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for _, alt := range anyOf {
+			if v.matches(path, alt, val) {
+				matched = true
+			}
+		}
+		if !matched {
+			v.fail(path, "matches none of anyOf")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, alt := range oneOf {
+			if v.matches(path, alt, val) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "matches %d of oneOf", matched)
+		}
+	}
+
+	switch x := val.(type) {
+	case map[string]any:
+		for _, req := range asSlice(schema["required"]) {
+			if _, ok := x[req.(string)]; !ok {
+				v.fail(path, "missing required %q", req)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for k, pv := range x {
+			if ps, ok := props[k].(map[string]any); ok {
+				v.validate(path+"."+k, ps, pv)
+				continue
+			}
+			switch ap := schema["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					v.fail(path, "unexpected property %q", k)
+				}
+			case map[string]any:
+				v.validate(path+"."+k, ap, pv)
+			}
+		}
+	case []any:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(x)) < min {
+			v.fail(path, "%d items, want at least %v", len(x), min)
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			for i := range x {
+				for j := i + 1; j < len(x); j++ {
+					if reflect.DeepEqual(x[i], x[j]) {
+						v.fail(path, "items %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range x {
+				v.validate(fmt.Sprintf("%s[%d]", path, i), items, item)
+			}
+		}
+	}
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func matchesType(types, val any) bool {
+	names := []any{types}
+	if s, ok := types.([]any); ok {
+		names = s
+	}
+	for _, n := range names {
+		switch n {
+		case "object":
+			if _, ok := val.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := val.([]any); ok {
+				return true
+			}
+		case "string":
+			if _, ok := val.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := val.(bool); ok {
+				return true
+			}
+		case "number":
+			if _, ok := val.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := val.(float64); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "null":
+			if val == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesFormat(format, s string) bool {
+	switch format {
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "uri-reference":
+		_, err := url.Parse(s)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SearchOptions filters a search across run history. Empty fields match
+// everything.
+type SearchOptions struct {
+	Agent  string // agent ID, with or without the @raider suffix
+	Raider string
+	Status string
+	Tags   []string // runs must carry all of these tags
+	Since  time.Time
+	Limit  int // maximum number of hits (0 = unlimited)
+}
+
+// SearchHit is the first match of a query in one document of a run: its
+// prompt or one agent's response.
+type SearchHit struct {
+	RunDir     string    `json:"runDir"`
+	StartedAt  time.Time `json:"startedAt"`
+	Prompt     string    `json:"prompt"`
+	Source     string    `json:"source"` // "prompt", "note" or the response file name
+	ToolID     string    `json:"toolId,omitempty"`
+	Raider     string    `json:"raider,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	AgentIndex int       `json:"agentIndex"` // index into the manifest results, -1 for the prompt and note
+	Line       int       `json:"line"`
+	Matches    int       `json:"matches"`
+	Snippet    string    `json:"snippet"`
+	Highlights [][2]int  `json:"highlights"` // byte ranges of query terms in Snippet
+}
+
+const snippetRadius = 80
+
+// SearchRuns searches the prompts and agent responses of all runs in baseDir,
+// newest first. All whitespace-separated terms of query must occur in a
+// document (case-insensitively) for it to match. Prompt and note matches are
+// only reported when no agent, raider or status filter is set.
+func SearchRuns(baseDir, query string, opts SearchOptions) ([]SearchHit, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	runs, err := ScanRuns(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	agentFilter := opts.Agent != "" || opts.Raider != "" || opts.Status != ""
+	var hits []SearchHit
+	for _, run := range runs {
+		if run.Entry == nil {
+			continue // no manifest
+		}
+		started := run.sortTime()
+		if !opts.Since.IsZero() && started.Before(opts.Since) {
+			continue
+		}
+		if !HasTags(run.Entry.Tags, opts.Tags) {
+			continue
+		}
+		if agentFilter && !indexHasResult(run.Entry, opts) {
+			continue
+		}
+		m, err := ReadManifest(run.Path)
+		if err != nil {
+			continue
+		}
+
+		if !agentFilter {
+			for _, doc := range []struct{ source, text string }{{"prompt", m.Prompt}, {"note", m.Note}} {
+				if hit, ok := matchDocument(doc.text, terms); ok {
+					hit.RunDir, hit.StartedAt, hit.Prompt = run.Path, started, m.Prompt
+					hit.Source = doc.source
+					hit.AgentIndex = -1
+					hits = append(hits, hit)
+				}
+			}
+		}
+
+		for i, r := range m.Results {
+			if !matchesResult(r, opts) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(run.Path, r.OutputFile))
+			if err != nil {
+				continue
+			}
+			hit, ok := matchDocument(string(data), terms)
+			if !ok {
+				continue
+			}
+			hit.RunDir, hit.StartedAt, hit.Prompt = run.Path, started, m.Prompt
+			hit.Source = r.OutputFile
+			hit.ToolID = r.ToolID
+			hit.Raider = r.Expert
+			hit.Status = r.Status
+			hit.AgentIndex = i
+			hits = append(hits, hit)
+		}
+
+		if opts.Limit > 0 && len(hits) >= opts.Limit {
+			return hits[:opts.Limit], nil
+		}
+	}
+	return hits, nil
+}
+
+func matchesResult(r ManifestResult, opts SearchOptions) bool {
+	return matchesFilter(r.ToolID, r.Expert, r.Status, opts)
+}
+
+// indexHasResult reports whether an indexed run has any result passing the
+// filters, so runs without one are skipped without reading their manifest.
+func indexHasResult(e *IndexEntry, opts SearchOptions) bool {
+	for _, r := range e.Results {
+		if matchesFilter(r.ToolID, r.Expert, r.Status, opts) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesFilter(toolID, raider, status string, opts SearchOptions) bool {
+	if opts.Agent != "" && opts.Agent != toolID && opts.Agent != agentName(toolID) {
+		return false
+	}
+	if opts.Raider != "" && opts.Raider != raider {
+		return false
+	}
+	if opts.Status != "" && opts.Status != status {
+		return false
+	}
+	return true
+}
+
+// matchDocument reports whether text contains every term and builds a
+// snippet around the first line containing the first term.
+func matchDocument(text string, terms []string) (SearchHit, bool) {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowercasing changed byte offsets; search and show the lowered text.
+		text = lower
+	}
+	for _, t := range terms {
+		if !strings.Contains(lower, t) {
+			return SearchHit{}, false
+		}
+	}
+
+	hit := SearchHit{Matches: strings.Count(lower, terms[0])}
+	pos := strings.Index(lower, terms[0])
+	hit.Line = strings.Count(text[:pos], "\n") + 1
+
+	lineStart := strings.LastIndex(text[:pos], "\n") + 1
+	lineEnd := len(text)
+	if i := strings.Index(text[pos:], "\n"); i >= 0 {
+		lineEnd = pos + i
+	}
+	start := max(lineStart, pos-snippetRadius)
+	end := min(lineEnd, pos+len(terms[0])+snippetRadius)
+	start, end = runeBoundary(text, start), runeBoundary(text, end)
+
+	snippet := text[start:end]
+	prefix, suffix := "", ""
+	if start > lineStart {
+		prefix = "…"
+	}
+	if end < lineEnd {
+		suffix = "…"
+	}
+	hit.Snippet = prefix + snippet + suffix
+
+	lowerSnippet := strings.ToLower(hit.Snippet)
+	if len(lowerSnippet) != len(hit.Snippet) {
+		hit.Snippet = lowerSnippet
+	}
+	for _, t := range terms {
+		for off := 0; ; {
+			i := strings.Index(lowerSnippet[off:], t)
+			if i < 0 {
+				break
+			}
+			hit.Highlights = append(hit.Highlights, [2]int{off + i, off + i + len(t)})
+			off += i + len(t)
+		}
+	}
+	hit.Highlights = mergeRanges(hit.Highlights)
+	return hit, true
+}
+
+// runeBoundary moves i back to the start of the UTF-8 sequence it is in.
+func runeBoundary(s string, i int) int {
+	for i > 0 && i < len(s) && s[i]&0xC0 == 0x80 {
+		i--
+	}
+	return i
+}
+
+func mergeRanges(rs [][2]int) [][2]int {
+	if len(rs) < 2 {
+		return rs
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i][0] < rs[j][0] })
+	out := [][2]int{rs[0]}
+	for _, r := range rs[1:] {
+		last := &out[len(out)-1]
+		if r[0] <= last[1] {
+			last[1] = max(last[1], r[1])
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Highlight wraps each highlighted range of a hit's snippet with mark.
+func (h SearchHit) Highlight(mark func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, r := range h.Highlights {
+		b.WriteString(h.Snippet[last:r[0]])
+		b.WriteString(mark(h.Snippet[r[0]:r[1]]))
+		last = r[1]
+	}
+	b.WriteString(h.Snippet[last:])
+	return b.String()
+}
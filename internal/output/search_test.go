@@ -0,0 +1,140 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSearchRuns(t *testing.T) string {
+	base := t.TempDir()
+	now := time.Now()
+	writeTestRun(t, base, "old-run", testRun{
+		Prompt:    "review the cache",
+		StartedAt: now.Add(-72 * time.Hour),
+		Results:   []ManifestResult{{ToolID: "gemini", Status: "success", OutputFile: "gemini.md"}},
+		Files:     map[string]string{"gemini.md": "Intro.\nThere is a Race Condition in the cache eviction path.\n"},
+	})
+	writeTestRun(t, base, "new-run", testRun{
+		Prompt:    "review the race condition fix",
+		StartedAt: now,
+		Results: []ManifestResult{
+			{ToolID: "claude@security", Expert: "security", Status: "success", OutputFile: "claude@security.md"},
+			{ToolID: "gemini", Status: "failed", OutputFile: "gemini.md"},
+		},
+		Files: map[string]string{
+			"claude@security.md": "The race condition in the cache is fixed.",
+			"gemini.md":          "partial: race condition",
+		},
+	})
+	return base
+}
+
+func TestSearchRuns(t *testing.T) {
+	base := setupSearchRuns(t)
+
+	hits, err := SearchRuns(base, "race condition", SearchOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, hits, 4)
+
+	// Newest run first, prompt before responses.
+	assert.Equal(t, "prompt", hits[0].Source)
+	assert.Equal(t, -1, hits[0].AgentIndex)
+	assert.Equal(t, "claude@security", hits[1].ToolID)
+	assert.Equal(t, "security", hits[1].Raider)
+	assert.Equal(t, 0, hits[1].AgentIndex)
+
+	old := hits[3]
+	assert.Equal(t, filepath.Join(base, "old-run"), old.RunDir)
+	assert.Equal(t, 2, old.Line)
+	assert.Equal(t, "There is a Race Condition in the cache eviction path.", old.Snippet)
+	assert.Equal(t, "There is a [Race] [Condition] in the cache eviction path.",
+		old.Highlight(func(s string) string { return "[" + s + "]" }))
+}
+
+func TestSearchRunsFilters(t *testing.T) {
+	base := setupSearchRuns(t)
+
+	hits, err := SearchRuns(base, "race", SearchOptions{Agent: "gemini"})
+	assert.NoError(t, err)
+	assert.Len(t, hits, 2)
+	for _, h := range hits {
+		assert.Equal(t, "gemini", h.ToolID)
+	}
+
+	hits, _ = SearchRuns(base, "race", SearchOptions{Agent: "claude"})
+	assert.Len(t, hits, 1)
+
+	hits, _ = SearchRuns(base, "race", SearchOptions{Raider: "security"})
+	assert.Len(t, hits, 1)
+
+	hits, _ = SearchRuns(base, "race", SearchOptions{Status: "failed"})
+	assert.Len(t, hits, 1)
+	assert.Equal(t, 1, hits[0].AgentIndex)
+
+	hits, _ = SearchRuns(base, "cache", SearchOptions{Since: time.Now().Add(-24 * time.Hour)})
+	for _, h := range hits {
+		assert.Equal(t, filepath.Join(base, "new-run"), h.RunDir)
+	}
+
+	hits, _ = SearchRuns(base, "race", SearchOptions{Limit: 2})
+	assert.Len(t, hits, 2)
+
+	hits, _ = SearchRuns(base, "race nonexistentword", SearchOptions{})
+	assert.Empty(t, hits)
+}
+
+func TestMatchDocumentSnippet(t *testing.T) {
+	long := "prefix " + strings.Repeat("word ", 40) + "needle" + strings.Repeat(" tail", 40)
+	hit, ok := matchDocument(long, []string{"needle"})
+	assert.True(t, ok)
+	assert.Contains(t, hit.Snippet, "needle")
+	assert.True(t, len(hit.Snippet) < len(long))
+	assert.True(t, strings.HasPrefix(hit.Snippet, "…"))
+	assert.True(t, strings.HasSuffix(hit.Snippet, "…"))
+	assert.Len(t, hit.Highlights, 1)
+}
+
+func TestLoadResults(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude.md"), []byte("answer"), 0o600)
+	os.WriteFile(filepath.Join(dir, "claude.stderr"), []byte("warn"), 0o600)
+	m := &Manifest{Results: []ManifestResult{{ToolID: "claude", Status: "failed", Duration: "1.5s", ExitCode: 2,
+		OutputFile: "claude.md", StderrFile: "claude.stderr"}}}
+
+	results := LoadResults(m, dir)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "answer", string(results[0].Stdout))
+	assert.Equal(t, "warn", string(results[0].Stderr))
+	assert.Equal(t, 1500*time.Millisecond, results[0].Duration)
+	assert.Equal(t, 2, results[0].ExitCode)
+}
+
+func TestSearchRunsTagsAndNotes(t *testing.T) {
+	base := setupSearchRuns(t)
+	dir := filepath.Join(base, "old-run")
+	m, err := ReadManifest(dir)
+	assert.NoError(t, err)
+	m.Tags = []string{"baseline"}
+	m.Note = "Compare against the race fix later"
+	assert.NoError(t, WriteManifest(dir, m))
+
+	hits, err := SearchRuns(base, "race", SearchOptions{Tags: []string{"baseline"}})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hits)
+	sources := map[string]bool{}
+	for _, h := range hits {
+		assert.Equal(t, dir, h.RunDir)
+		sources[h.Source] = true
+	}
+	assert.True(t, sources["note"])
+	assert.True(t, sources["gemini.md"])
+
+	hits, err = SearchRuns(base, "race", SearchOptions{Tags: []string{"missing"}})
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
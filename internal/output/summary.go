@@ -33,6 +33,14 @@ func BuildSummary(manifest *Manifest, runDir string) string {
 	// Policy
 	fmt.Fprintf(&b, "**Policy:** read-only=%s\n", manifest.Config.ReadOnly)
 
+	if c := manifest.ContextReport; c != nil && len(c.Suspicious) > 0 {
+		b.WriteString("\n## Context Warnings\n")
+		fmt.Fprintf(&b, "%d passage(s) in the context look like instructions to the agents; check the responses were not steered by them.\n\n", len(c.Suspicious))
+		for _, s := range c.Suspicious {
+			fmt.Fprintf(&b, "- %s:%d (%s): `%s`\n", s.Source, s.Line, s.Rule, strings.ReplaceAll(s.Excerpt, "`", "'"))
+		}
+	}
+
 	// Results section
 	b.WriteString("\n## Results\n")
 
@@ -48,6 +56,13 @@ func BuildSummary(manifest *Manifest, runDir string) string {
 		if r.ExitCode != 0 {
 			fmt.Fprintf(&b, "- Exit code: %d\n", r.ExitCode)
 		}
+		if manifest.FindingsFile != "" && r.Status == "success" {
+			if r.FindingsError != "" {
+				fmt.Fprintf(&b, "- Findings: invalid (%s)\n", r.FindingsError)
+			} else {
+				fmt.Fprintf(&b, "- Findings: %d\n", r.Findings)
+			}
+		}
 
 		// Read the output file for word count and headings
 		outputPath := filepath.Join(runDir, r.OutputFile)
@@ -89,8 +104,25 @@ func BuildSummary(manifest *Manifest, runDir string) string {
 		}
 	}
 
+	if manifest.FindingsFile != "" {
+		if report, err := ReadFindings(runDir); err == nil {
+			writeFindingsSummary(&b, manifest, report)
+		}
+	}
+
+	if s := manifest.Synthesis; s != nil {
+		b.WriteString("\n## Synthesis\n")
+		fmt.Fprintf(&b, "- Agent: %s\n", s.ToolID)
+		fmt.Fprintf(&b, "- Status: %s\n", s.Status)
+		fmt.Fprintf(&b, "- Duration: %s\n", s.Duration)
+		fmt.Fprintf(&b, "- Sources: %s\n", strings.Join(s.Sources, ", "))
+		if s.Status == "success" {
+			fmt.Fprintf(&b, "- Output: %s\n", s.OutputFile)
+		}
+	}
+
 	// Cost summary table
-	hasCost := false
+	hasCost := manifest.Synthesis != nil && manifest.Synthesis.Cost != nil
 	for _, r := range manifest.Results {
 		if r.Cost != nil && (r.Cost.InputTokens > 0 || r.Cost.OutputTokens > 0 || r.Cost.TotalUSD > 0) {
 			hasCost = true
@@ -107,11 +139,50 @@ func BuildSummary(manifest *Manifest, runDir string) string {
 					r.ToolID, r.Cost.InputTokens, r.Cost.OutputTokens, r.Cost.TotalUSD)
 			}
 		}
+		if s := manifest.Synthesis; s != nil && s.Cost != nil {
+			fmt.Fprintf(&b, "| %s (synthesis) | %d | %d | $%.2f |\n",
+				s.ToolID, s.Cost.InputTokens, s.Cost.OutputTokens, s.Cost.TotalUSD)
+		}
 	}
 
 	return b.String()
 }
 
+const maxSummaryFindings = 20
+
+func writeFindingsSummary(b *strings.Builder, manifest *Manifest, report *FindingsReport) {
+	agents := 0
+	for _, r := range manifest.Results {
+		if r.Status == "success" {
+			agents++
+		}
+	}
+	fmt.Fprintf(b, "\n## Findings\n")
+	fmt.Fprintf(b, "%d merged finding(s) from %d agent(s), see %s\n\n", len(report.Findings), report.Agents, manifest.FindingsFile)
+	for i, f := range report.Findings {
+		if i == maxSummaryFindings {
+			fmt.Fprintf(b, "- ... %d more\n", len(report.Findings)-maxSummaryFindings)
+			break
+		}
+		loc := ""
+		if f.File != "" {
+			loc = " — " + f.File
+			if f.StartLine > 0 {
+				loc += fmt.Sprintf(":%d", f.StartLine)
+				if f.EndLine > f.StartLine {
+					loc += fmt.Sprintf("-%d", f.EndLine)
+				}
+			}
+		}
+		names := make([]string, len(f.Reporters))
+		for j, r := range f.Reporters {
+			names[j] = r.ToolID
+		}
+		fmt.Fprintf(b, "- **[%s]** %s%s (%d/%d agents: %s)\n",
+			f.Severity, f.Title, loc, len(f.Reporters), agents, strings.Join(names, ", "))
+	}
+}
+
 func WriteSummary(dir, content string) error {
 	return AtomicWrite(filepath.Join(dir, "summary.md"), []byte(content), 0o600)
 }
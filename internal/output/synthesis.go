@@ -0,0 +1,96 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+// SynthesisID is the tool ID used for the synthesis agent, so its response
+// lands in synthesis.md next to the individual agent outputs.
+const SynthesisID = "synthesis"
+
+// ManifestSynthesis records the LLM synthesis step of a run.
+type ManifestSynthesis struct {
+	ToolID     string       `json:"toolId"`
+	Status     string       `json:"status"`
+	Duration   string       `json:"duration"`
+	ExitCode   int          `json:"exitCode"`
+	OutputFile string       `json:"outputFile"`
+	StderrFile string       `json:"stderrFile"`
+	Sources    []string     `json:"sources"`
+	Cost       *runner.Cost `json:"cost,omitempty"`
+}
+
+// BuildSynthesisPrompt assembles the prompt for the synthesis agent from all
+// successful responses in a run. Each response is labelled with its agent
+// and raider. Returns the prompt and the tool IDs it includes.
+func BuildSynthesisPrompt(m *Manifest, runDir string) (string, []string, error) {
+	var responses strings.Builder
+	var sources []string
+	for _, r := range m.Results {
+		if r.Status != "success" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(runDir, r.OutputFile))
+		if err != nil || strings.TrimSpace(string(data)) == "" {
+			continue
+		}
+		sources = append(sources, r.ToolID)
+		fmt.Fprintf(&responses, "### Response %d — agent: %s", len(sources), agentName(r.ToolID))
+		if r.Expert != "" {
+			fmt.Fprintf(&responses, ", raider: %s", r.Expert)
+		}
+		fmt.Fprintf(&responses, "\n\n%s\n\n", strings.TrimSpace(string(data)))
+	}
+	if len(sources) == 0 {
+		return "", nil, fmt.Errorf("no successful responses to synthesize")
+	}
+
+	var b strings.Builder
+	b.WriteString("# Synthesis Request\n\n")
+	fmt.Fprintf(&b, "%d independent AI agents answered the same question. ", len(sources))
+	b.WriteString("Merge their responses into a single report.\n\n")
+	b.WriteString("## Original Question\n\n")
+	b.WriteString(strings.TrimSpace(m.Prompt))
+	b.WriteString("\n\n## Responses\n\n")
+	b.WriteString(responses.String())
+	b.WriteString("## Instructions\n\n")
+	b.WriteString("Write the synthesis in markdown with these sections:\n")
+	b.WriteString("- **Consensus** — points most agents agree on, noting how many agreed\n")
+	b.WriteString("- **Disagreements** — where agents contradict each other, with each position and your assessment of which is right\n")
+	b.WriteString("- **Unique findings** — important points raised by only one agent, attributed to it\n")
+	b.WriteString("- **Recommendation** — the overall conclusion and next steps\n\n")
+	b.WriteString("Attribute claims to agents by name. Do not invent findings that no agent made.\n")
+	return b.String(), sources, nil
+}
+
+// BuildSynthesis converts the synthesis agent's result into its manifest entry.
+func BuildSynthesis(agentID string, r runner.Result, sources []string) *ManifestSynthesis {
+	s := &ManifestSynthesis{
+		ToolID:     agentID,
+		Status:     string(r.Status),
+		Duration:   r.Duration.Round(time.Millisecond).String(),
+		ExitCode:   r.ExitCode,
+		OutputFile: SynthesisID + ".md",
+		StderrFile: SynthesisID + ".stderr",
+		Sources:    sources,
+	}
+	if r.Cost.TotalUSD > 0 || r.Cost.InputTokens > 0 {
+		cost := r.Cost
+		s.Cost = &cost
+	}
+	return s
+}
+
+// agentName strips the raider part from a composite tool ID (agent@raider).
+func agentName(toolID string) string {
+	if i := strings.Index(toolID, "@"); i >= 0 {
+		toolID = toolID[:i]
+	}
+	return toolID
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codebeauty/horde/internal/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSynthesisPrompt(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "claude@security.md"), []byte("SQL injection in login"), 0o600)
+	os.WriteFile(filepath.Join(dir, "gemini.md"), []byte("Looks fine to me"), 0o600)
+	os.WriteFile(filepath.Join(dir, "codex.md"), []byte("partial output"), 0o600)
+	os.WriteFile(filepath.Join(dir, "amp.md"), []byte("   \n"), 0o600)
+
+	m := &Manifest{
+		Prompt: "review the login handler",
+		Results: []ManifestResult{
+			{ToolID: "claude@security", Status: "success", OutputFile: "claude@security.md", Expert: "security"},
+			{ToolID: "gemini", Status: "success", OutputFile: "gemini.md"},
+			{ToolID: "codex", Status: "failed", OutputFile: "codex.md"},
+			{ToolID: "amp", Status: "success", OutputFile: "amp.md"},
+		},
+	}
+
+	prompt, sources, err := BuildSynthesisPrompt(m, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"claude@security", "gemini"}, sources)
+	assert.Contains(t, prompt, "review the login handler")
+	assert.Contains(t, prompt, "### Response 1 — agent: claude, raider: security")
+	assert.Contains(t, prompt, "SQL injection in login")
+	assert.Contains(t, prompt, "### Response 2 — agent: gemini\n")
+	assert.Contains(t, prompt, "**Consensus**")
+	assert.Contains(t, prompt, "**Disagreements**")
+	assert.Contains(t, prompt, "**Unique findings**")
+	assert.NotContains(t, prompt, "partial output", "failed responses are excluded")
+}
+
+func TestBuildSynthesisPromptNoSuccess(t *testing.T) {
+	m := &Manifest{Results: []ManifestResult{{ToolID: "codex", Status: "failed", OutputFile: "codex.md"}}}
+	_, _, err := BuildSynthesisPrompt(m, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestBuildSynthesis(t *testing.T) {
+	s := BuildSynthesis("claude-opus", runner.Result{
+		Status:   runner.StatusSuccess,
+		Duration: 1500 * time.Millisecond,
+		Cost:     runner.Cost{InputTokens: 100, OutputTokens: 20, TotalUSD: 0.01},
+	}, []string{"a", "b"})
+	assert.Equal(t, "claude-opus", s.ToolID)
+	assert.Equal(t, "success", s.Status)
+	assert.Equal(t, "1.5s", s.Duration)
+	assert.Equal(t, "synthesis.md", s.OutputFile)
+	assert.Equal(t, []string{"a", "b"}, s.Sources)
+	assert.NotNil(t, s.Cost)
+
+	s = BuildSynthesis("claude-opus", runner.Result{Status: runner.StatusFailed}, nil)
+	assert.Nil(t, s.Cost)
+}
+
+func TestBuildSummaryWithSynthesis(t *testing.T) {
+	m := &Manifest{
+		Prompt: "p",
+		Config: ManifestConfig{ReadOnly: "bestEffort"},
+		Synthesis: &ManifestSynthesis{
+			ToolID: "claude-opus", Status: "success", Duration: "3s",
+			OutputFile: "synthesis.md", Sources: []string{"gemini", "codex"},
+			Cost: &runner.Cost{InputTokens: 10, OutputTokens: 5, TotalUSD: 0.02},
+		},
+	}
+	summary := BuildSummary(m, t.TempDir())
+	assert.Contains(t, summary, "## Synthesis")
+	assert.Contains(t, summary, "- Agent: claude-opus")
+	assert.Contains(t, summary, "- Sources: gemini, codex")
+	assert.Contains(t, summary, "- Output: synthesis.md")
+	assert.Contains(t, summary, "| claude-opus (synthesis) | 10 | 5 | $0.02 |")
+}
@@ -30,6 +30,9 @@ func Slug(prompt string) string {
 	return s
 }
 
+// RunDir creates the directory for a new run, marks it as a horde run and
+// locks it. The caller releases the lock with UnlockRun once the run is
+// complete.
 func RunDir(baseDir, prompt string) (string, error) {
 	slug := Slug(prompt)
 	ts := time.Now().Unix()
@@ -38,6 +41,12 @@ func RunDir(baseDir, prompt string) (string, error) {
 	if err := os.MkdirAll(path, 0o700); err != nil {
 		return "", fmt.Errorf("creating output dir: %w", err)
 	}
+	if err := WriteMarker(path); err != nil {
+		return "", fmt.Errorf("marking run dir: %w", err)
+	}
+	if err := LockRun(path); err != nil {
+		return "", err
+	}
 	return path, nil
 }
 
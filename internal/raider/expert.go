@@ -37,22 +37,15 @@ func Dir() string {
 	return raidersDir // default to new name
 }
 
-// Load reads an expert file by ID from the given directory.
+// Load reads an expert file by ID from the given directory and returns its
+// text without front matter, ready for Inject.
 // Validates the ID to prevent path traversal.
 func Load(id, dir string) (string, error) {
-	if err := ValidateID(id); err != nil {
-		return "", err
-	}
-	path := filepath.Join(dir, id+".md")
-	data, err := os.ReadFile(path)
+	r, err := Read(id, dir)
 	if err != nil {
-		return "", fmt.Errorf("raider %q not found: %w", id, err)
-	}
-	content := string(data)
-	if strings.TrimSpace(content) == "" {
-		return "", fmt.Errorf("raider %q is empty", id)
+		return "", err
 	}
-	return content, nil
+	return r.Body, nil
 }
 
 // List returns sorted expert IDs (filenames without .md) from the directory.
@@ -168,9 +161,14 @@ func Inject(expertText, prompt string) string {
 }
 
 // Builtins contains the 6 built-in expert presets.
-// Keys are expert IDs, values are the full markdown content.
+// Keys are expert IDs, values are the full markdown content, front matter
+// included.
 var Builtins = map[string]string{
-	"security": `You are a senior security engineer conducting a thorough security review.
+	"security": `---
+description: Finds vulnerabilities, attack vectors and exposed secrets
+tags: [security, review]
+---
+You are a senior security engineer conducting a thorough security review.
 
 Focus on:
 - Vulnerabilities and attack surfaces
@@ -183,7 +181,11 @@ Focus on:
 
 Be specific: name the vulnerability type, point to the exact code, explain the attack vector, and suggest a concrete fix. Prioritize findings by severity (critical, high, medium, low).`,
 
-	"performance": `You are a performance engineer analyzing code for efficiency and scalability.
+	"performance": `---
+description: Looks for hot paths, allocations and scalability limits
+tags: [performance, review]
+---
+You are a performance engineer analyzing code for efficiency and scalability.
 
 Focus on:
 - Algorithmic complexity (time and space) — flag O(n²) or worse
@@ -196,7 +198,11 @@ Focus on:
 
 Be specific: identify the hot path, estimate the impact, and propose a measurable improvement with before/after complexity.`,
 
-	"architect": `You are a senior software architect reviewing for long-term maintainability.
+	"architect": `---
+description: Reviews design, coupling and long-term maintainability
+tags: [architecture, design]
+---
+You are a senior software architect reviewing for long-term maintainability.
 
 Focus on:
 - SOLID principle violations
@@ -209,7 +215,11 @@ Focus on:
 
 Be specific: name the principle violated, explain the downstream consequence, and propose a restructuring with concrete file/package moves.`,
 
-	"reviewer": `You are a thorough code reviewer focused on correctness and quality.
+	"reviewer": `---
+description: Checks correctness, edge cases and test coverage
+tags: [review, correctness]
+---
+You are a thorough code reviewer focused on correctness and quality.
 
 Focus on:
 - Bugs: off-by-one, nil/null dereferences, unhandled errors, race conditions
@@ -221,7 +231,11 @@ Focus on:
 
 Be direct: "This will panic when X is nil" is better than "Consider checking for nil." Fix suggestions should be copy-pasteable.`,
 
-	"devil": `You are a devil's advocate. Your job is to find flaws, challenge assumptions, and argue the opposite position.
+	"devil": `---
+description: Challenges assumptions and argues the opposite position
+tags: [critique, planning]
+---
+You are a devil's advocate. Your job is to find flaws, challenge assumptions, and argue the opposite position.
 
 Focus on:
 - Hidden assumptions that might be wrong
@@ -233,7 +247,11 @@ Focus on:
 
 Be constructive but relentless. Don't accept premises at face value. If the author says "this is fast," ask "compared to what?" If they say "users want X," ask "how do you know?"`,
 
-	"product": `You are a product lead evaluating from the user's perspective.
+	"product": `---
+description: Weighs user impact, acceptance criteria and priorities
+tags: [product, planning]
+---
+You are a product lead evaluating from the user's perspective.
 
 Focus on:
 - User impact: does this solve a real problem? How often do users hit this?
@@ -0,0 +1,202 @@
+package raider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Meta is the optional YAML front matter of a raider file, written between
+// "---" lines at the top of the file:
+//
+//	---
+//	description: Finds vulnerabilities and unsafe patterns
+//	tags: [security, review]
+//	---
+//	You are a senior security engineer...
+type Meta struct {
+	Description       string   `yaml:"description"`
+	Tags              []string `yaml:"tags"`
+	RecommendedAgents []string `yaml:"recommendedAgents"` // agent IDs the raider works best with
+	Timeout           int      `yaml:"timeout"`           // suggested per-agent timeout in seconds
+	OutputFormat      string   `yaml:"outputFormat"`      // one of OutputFormats
+	MinHordeVersion   string   `yaml:"minHordeVersion"`
+}
+
+// OutputFormats are the accepted values of Meta.OutputFormat, a hint for
+// how the raider's responses are structured.
+var OutputFormats = []string{"markdown", "text", "json", "findings"}
+
+// Raider is a raider file split into its front matter and the text that
+// Inject adds to prompts.
+type Raider struct {
+	ID   string
+	Meta Meta
+	Body string
+}
+
+// Read loads a raider file by ID from the given directory and parses its
+// front matter.
+func Read(id, dir string) (*Raider, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id+".md"))
+	if err != nil {
+		return nil, fmt.Errorf("raider %q not found: %w", id, err)
+	}
+	meta, body, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("raider %q: %w", id, err)
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("raider %q is empty", id)
+	}
+	return &Raider{ID: id, Meta: meta, Body: body}, nil
+}
+
+// Parse splits raider file content into its front matter and body. Content
+// without front matter is all body. Unknown fields are ignored, so raiders
+// written for newer versions still load; Lint reports them.
+func Parse(content string) (Meta, string, error) {
+	var meta Meta
+	front, body := splitFrontMatter(content)
+	if front == "" {
+		return meta, body, nil
+	}
+	if err := yaml.Unmarshal([]byte(front), &meta); err != nil {
+		return meta, body, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return meta, body, nil
+}
+
+// splitFrontMatter returns the YAML between the opening and closing "---"
+// lines, and the rest of content with leading blank lines removed. An
+// opening "---" that is never closed is a markdown rule, not front matter,
+// so such content is all body.
+func splitFrontMatter(content string) (front, body string) {
+	rest, ok := cutLine(content, "---")
+	if !ok {
+		return "", content
+	}
+	for i := 0; i < len(rest); {
+		if next, ok := cutLine(rest[i:], "---"); ok {
+			return rest[:i], strings.TrimLeft(next, "\r\n")
+		}
+		line, _, _ := strings.Cut(rest[i:], "\n")
+		i += len(line) + 1
+	}
+	return "", content
+}
+
+// cutLine reports whether s starts with a line holding only marker, and
+// returns what follows that line.
+func cutLine(s, marker string) (string, bool) {
+	line, rest, _ := strings.Cut(s, "\n")
+	if strings.TrimRight(line, " \t\r") != marker {
+		return "", false
+	}
+	return rest, true
+}
+
+// Lint checks raider file content and returns its problems: malformed or
+// unknown front matter fields, invalid values, a minimum version newer than
+// hordeVersion, and an empty body. hordeVersion is not compared when it is
+// not a release version. Recommended agents are not checked, as that needs
+// the config.
+func Lint(content, hordeVersion string) []string {
+	front, body := splitFrontMatter(content)
+
+	var problems []string
+	var meta Meta
+	if front != "" {
+		dec := yaml.NewDecoder(strings.NewReader(front))
+		dec.KnownFields(true)
+		// Front matter holding only comments decodes as io.EOF.
+		if err := dec.Decode(&meta); err != nil && !errors.Is(err, io.EOF) {
+			problems = append(problems, yamlProblems(err)...)
+		}
+	}
+	if strings.TrimSpace(body) == "" {
+		problems = append(problems, "no role text after the front matter")
+	}
+
+	for _, tag := range meta.Tags {
+		if tag == "" || strings.ContainsAny(tag, " \t,") {
+			problems = append(problems, fmt.Sprintf("tag %q must be a single word", tag))
+		}
+	}
+	if meta.Timeout < 0 {
+		problems = append(problems, fmt.Sprintf("timeout %d must not be negative", meta.Timeout))
+	}
+	if meta.OutputFormat != "" && !slices.Contains(OutputFormats, meta.OutputFormat) {
+		problems = append(problems, fmt.Sprintf("outputFormat %q must be one of %s", meta.OutputFormat, strings.Join(OutputFormats, ", ")))
+	}
+	if v := meta.MinHordeVersion; v != "" {
+		if _, ok := parseVersion(v); !ok {
+			problems = append(problems, fmt.Sprintf("minHordeVersion %q is not a version like 1.4.0", v))
+		} else if p := meta.Requires(hordeVersion); p != "" {
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// Requires reports, as a problem, when the raider needs a newer horde than
+// hordeVersion, and returns "" otherwise. Versions that do not parse, such
+// as development builds, are not compared.
+func (m Meta) Requires(hordeVersion string) string {
+	min, ok := parseVersion(m.MinHordeVersion)
+	current, isRelease := parseVersion(hordeVersion)
+	if !ok || !isRelease || compareVersions(min, current) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("requires horde %s or newer (this is %s)", m.MinHordeVersion, hordeVersion)
+}
+
+// yamlProblems turns a YAML decoding error into one problem per field.
+func yamlProblems(err error) []string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []string{"invalid front matter: " + strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	var problems []string
+	for _, e := range typeErr.Errors {
+		e = strings.Replace(e, "not found in type raider.Meta", "is not a known field", 1)
+		problems = append(problems, "front matter "+e)
+	}
+	return problems
+}
+
+// parseVersion parses a version like 1.4, v1.4.0 or 1.4.0-rc.1, ignoring
+// any pre-release or build suffix.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+func compareVersions(a, b [3]int) int {
+	return slices.Compare(a[:], b[:])
+}
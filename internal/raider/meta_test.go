@@ -0,0 +1,121 @@
+package raider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	content := "---\ndescription: Go specialist\ntags: [go, review]\nrecommendedAgents:\n  - claude\ntimeout: 300\n" +
+		"outputFormat: findings\nminHordeVersion: 1.4.0\n---\n\nYou are a Go expert.\n"
+	meta, body, err := Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, Meta{
+		Description:       "Go specialist",
+		Tags:              []string{"go", "review"},
+		RecommendedAgents: []string{"claude"},
+		Timeout:           300,
+		OutputFormat:      "findings",
+		MinHordeVersion:   "1.4.0",
+	}, meta)
+	assert.Equal(t, "You are a Go expert.\n", body)
+
+	meta, body, err = Parse("You are a Go expert.\n---\nnot front matter\n")
+	assert.NoError(t, err)
+	assert.Empty(t, meta)
+	assert.Equal(t, "You are a Go expert.\n---\nnot front matter\n", body)
+
+	meta, body, err = Parse("---\r\ndescription: crlf\r\nfuture: field\r\n---\r\nbody")
+	assert.NoError(t, err, "unknown fields are ignored")
+	assert.Equal(t, "crlf", meta.Description)
+	assert.Equal(t, "body", body)
+
+	// An unclosed opening line is a markdown rule starting the body.
+	meta, body, err = Parse("---\nYou are a Go expert.\n")
+	assert.NoError(t, err)
+	assert.Empty(t, meta)
+	assert.Equal(t, "---\nYou are a Go expert.\n", body)
+	_, _, err = Parse("---\ntags: [unclosed\n---\nbody")
+	assert.ErrorContains(t, err, "invalid front matter")
+}
+
+func TestLoadStripsFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "golang.md"), []byte("---\ndescription: Go specialist\n---\nYou are a Go expert."), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "blank.md"), []byte("---\ndescription: nothing else\n---\n\n"), 0o600))
+
+	content, err := Load("golang", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "You are a Go expert.", content)
+	assert.Equal(t, "## Role\n\nYou are a Go expert.\n\n---\n\nReview", Inject(content, "Review"))
+
+	r, err := Read("golang", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "Go specialist", r.Meta.Description)
+
+	_, err = Load("blank", dir)
+	assert.EqualError(t, err, `raider "blank" is empty`)
+}
+
+func TestLint(t *testing.T) {
+	assert.Empty(t, Lint("You are a Go expert.", "1.0.0"))
+	assert.Empty(t, Lint("---\n# only a comment\n---\nYou are a Go expert.", "1.0.0"))
+	assert.Empty(t, Lint("---\nminHordeVersion: v1.4\n---\nYou are a Go expert.", "dev"), "dev builds are not compared")
+
+	for id, content := range Builtins {
+		assert.Empty(t, Lint(content, "1.0.0"), id)
+		meta, _, err := Parse(content)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, meta.Description, id)
+	}
+
+	problems := Lint("---\ndescripton: typo\ntags: [ok, two words]\ntimeout: -5\noutputFormat: xml\nminHordeVersion: 2.1.0\n---\n", "2.0.3")
+	assert.Equal(t, []string{
+		"front matter line 1: field descripton is not a known field",
+		"no role text after the front matter",
+		`tag "two words" must be a single word`,
+		"timeout -5 must not be negative",
+		`outputFormat "xml" must be one of markdown, text, json, findings`,
+		"requires horde 2.1.0 or newer (this is 2.0.3)",
+	}, problems)
+
+	assert.Equal(t, []string{`minHordeVersion "soon" is not a version like 1.4.0`}, Lint("---\nminHordeVersion: soon\n---\nbody", "1.0.0"))
+	assert.Equal(t, []string{"front matter line 1: cannot unmarshal !!str `often` into int"}, Lint("---\ntimeout: often\n---\nbody", "1.0.0"))
+	assert.Empty(t, Lint("---\nbody", "1.0.0"))
+}
+
+func TestRequires(t *testing.T) {
+	meta := Meta{MinHordeVersion: "1.4.0"}
+	assert.Equal(t, "requires horde 1.4.0 or newer (this is 1.3.9)", meta.Requires("1.3.9"))
+	assert.Empty(t, meta.Requires("1.4.0"))
+	assert.Empty(t, meta.Requires("v2.0.0"))
+	assert.Empty(t, meta.Requires("dev"))
+	assert.Empty(t, Meta{}.Requires("1.0.0"))
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [3]int
+		ok   bool
+	}{
+		{"1.4.0", [3]int{1, 4, 0}, true},
+		{"v2.10", [3]int{2, 10, 0}, true},
+		{"1.5.0-rc.1", [3]int{1, 5, 0}, true},
+		{"3", [3]int{3, 0, 0}, true},
+		{"dev", [3]int{}, false},
+		{"1.2.3.4", [3]int{}, false},
+		{"", [3]int{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVersion(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		if tt.ok {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+	assert.Positive(t, compareVersions([3]int{1, 10, 0}, [3]int{1, 9, 9}))
+}
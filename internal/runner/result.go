@@ -25,4 +25,8 @@ type Result struct {
 	Duration time.Duration `json:"duration"`
 	Cost     Cost          `json:"cost,omitempty"`
 	ExitCode int           `json:"exitCode"`
+
+	// Invocation is the command the adapter built for this run. It is kept
+	// out of JSON because args and stdin may carry the full prompt.
+	Invocation adapter.Invocation `json:"-"`
 }
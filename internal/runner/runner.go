@@ -18,16 +18,23 @@ import (
 	"golang.org/x/sync/semaphore"
 
 	"github.com/codebeauty/horde/internal/adapter"
+	"github.com/codebeauty/horde/internal/secrets"
 )
 
-var allowedEnvKeys = map[string]bool{
-	"PATH": true, "HOME": true, "USER": true, "SHELL": true, "TERM": true,
-	"ANTHROPIC_API_KEY": true, "OPENAI_API_KEY": true,
-	"GEMINI_API_KEY": true, "GOOGLE_API_KEY": true,
-	"AMP_API_KEY": true,
-	"HTTP_PROXY": true, "HTTPS_PROXY": true, "NO_PROXY": true,
-	"TMPDIR": true,
-}
+// allowedEnvKeys are the environment variables passed to agents. Agents
+// need their credentials (secrets.EnvKeys); the secret scanner keeps the
+// same values out of prompts.
+var allowedEnvKeys = func() map[string]bool {
+	keys := map[string]bool{
+		"PATH": true, "HOME": true, "USER": true, "SHELL": true, "TERM": true,
+		"HTTP_PROXY": true, "HTTPS_PROXY": true, "NO_PROXY": true,
+		"TMPDIR": true,
+	}
+	for _, k := range secrets.EnvKeys {
+		keys[k] = true
+	}
+	return keys
+}()
 
 var injectedEnv = []string{
 	"CI=true",
@@ -147,10 +154,11 @@ func (r *Runner) execTool(ctx context.Context, tool Tool, params adapter.RunPara
 	stdoutFile, err := os.OpenFile(stdoutPath, os.O_CREATE|os.O_WRONLY, 0o600)
 	if err != nil {
 		return Result{
-			ToolID:   tool.ID,
-			Status:   StatusFailed,
-			Duration: time.Since(start),
-			Stderr:   []byte(fmt.Sprintf("failed to create output file: %v", err)),
+			ToolID:     tool.ID,
+			Status:     StatusFailed,
+			Duration:   time.Since(start),
+			Stderr:     []byte(fmt.Sprintf("failed to create output file: %v", err)),
+			Invocation: inv,
 		}
 	}
 	defer stdoutFile.Close()
@@ -164,11 +172,12 @@ func (r *Runner) execTool(ctx context.Context, tool Tool, params adapter.RunPara
 
 	if err := cmd.Start(); err != nil {
 		return Result{
-			ToolID:   tool.ID,
-			Status:   StatusFailed,
-			Duration: time.Since(start),
-			Stderr:   []byte(err.Error()),
-			ExitCode: -1,
+			ToolID:     tool.ID,
+			Status:     StatusFailed,
+			Duration:   time.Since(start),
+			Stderr:     []byte(err.Error()),
+			ExitCode:   -1,
+			Invocation: inv,
 		}
 	}
 
@@ -185,11 +194,12 @@ func (r *Runner) execTool(ctx context.Context, tool Tool, params adapter.RunPara
 	}
 
 	result := Result{
-		ToolID:   tool.ID,
-		Stdout:   stripANSI(stdoutBuf.Bytes()),
-		Stderr:   stripANSI(stderrBuf.Bytes()),
-		Duration: duration,
-		Cost:     tool.Adapter.ParseCost(stderrBuf.Bytes()),
+		ToolID:     tool.ID,
+		Stdout:     StripANSI(stdoutBuf.Bytes()),
+		Stderr:     StripANSI(stderrBuf.Bytes()),
+		Duration:   duration,
+		Cost:       tool.Adapter.ParseCost(stderrBuf.Bytes()),
+		Invocation: inv,
 	}
 
 	if waitErr != nil {
@@ -256,6 +266,7 @@ func (w *limitedWriter) Write(p []byte) (int, error) {
 
 var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 
-func stripANSI(b []byte) []byte {
+// StripANSI removes terminal color and cursor escape sequences from b.
+func StripANSI(b []byte) []byte {
 	return ansiPattern.ReplaceAll(b, nil)
 }
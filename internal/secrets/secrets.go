@@ -0,0 +1,200 @@
+// Package secrets detects and redacts credentials in text such as prompts,
+// gathered context and agent responses.
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is a single secret pattern. When Group is non-zero only that
+// submatch is the secret (for example the value of a key=value pair).
+// Check, when set, must also accept the secret.
+type Rule struct {
+	ID    string
+	Re    *regexp.Regexp
+	Group int
+	Check func(string) bool
+}
+
+// EnvKeys are the environment variables holding agent credentials. They
+// are passed through to agents, so their values must never appear in a
+// prompt.
+var EnvKeys = []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "GEMINI_API_KEY", "GOOGLE_API_KEY", "AMP_API_KEY"}
+
+// Rules are the built-in secret patterns, most specific first.
+var Rules = []Rule{
+	{ID: "private-key", Re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{ID: "aws-access-key", Re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{ID: "github-token", Re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{ID: "anthropic-key", Re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{ID: "openai-key", Re: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{ID: "google-api-key", Re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{ID: "slack-token", Re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{ID: "jwt", Re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	// Quoted values of assignments such as password = "...", "api_key": "...".
+	// The optional backslash also matches quotes escaped inside JSON strings.
+	{ID: "assignment", Re: regexp.MustCompile(`(?i)\b(?:[a-z0-9_]*(?:api[_-]?key|secret|token|passw(?:or)?d))\b\\?["']?\s*(?::=|[:=])\s*\\?["']([^\s"'\\]{8,})\\?["']`), Group: 1},
+	// Unquoted values in .env and YAML files: PASSWORD=... and api_key: ...
+	// at the start of a line. Code assigning a variable is not a literal.
+	{ID: "assignment", Re: regexp.MustCompile(`(?im)^[ \t]*(?:export[ \t]+|-[ \t]+)?(?:[a-z0-9_]*(?:api[_-]?key|secret|token|passw(?:or)?d))(?:=|:[ \t]+)([^\s"'#]{8,})`), Group: 1, Check: literalValue},
+	// .env-style lines naming a credential, such as DB_PASS=... or
+	// export STRIPE_KEY=....
+	{ID: "dotenv", Re: regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?[A-Z][A-Z0-9_]*(?:KEY|PASS|PWD|AUTH|CREDENTIALS?)[A-Z0-9_]*[ \t]*=[ \t]*["']?([^\s"'#]{8,})`), Group: 1, Check: literalValue},
+	{ID: "url-credentials", Re: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s:/@]{3,})@`), Group: 1},
+	// Long random-looking tokens. The optional prefix takes in the checksum
+	// marker of lockfile digests so that highEntropy can skip them.
+	{ID: "high-entropy", Re: regexp.MustCompile(`(?:\bh1:)?[A-Za-z0-9+/_-]{32,}={0,2}`), Check: highEntropy},
+}
+
+// digestPrefix matches the start of checksums in lockfiles: go.sum's "h1:"
+// and the Subresource Integrity "sha512-" of package-lock.json, yarn.lock
+// and pnpm-lock.yaml.
+var digestPrefix = regexp.MustCompile(`^(?:h1:|sha(?:1|256|384|512)-)`)
+
+// minEntropy is the Shannon entropy, in bits per character, above which a
+// long token looks random. Hex strings such as commit hashes stay below it.
+const minEntropy = 4.3
+
+// highEntropy reports whether token mixes letters and digits and looks
+// random rather than like a word, path or hash.
+func highEntropy(token string) bool {
+	if digestPrefix.MatchString(token) {
+		return false
+	}
+	if !strings.ContainsAny(token, "0123456789") || strings.IndexFunc(token, isLetter) < 0 {
+		return false
+	}
+	counts := make(map[rune]int)
+	for _, r := range token {
+		counts[r]++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(len(token))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minEntropy
+}
+
+// codeReference matches values that are code rather than literals: dotted
+// identifier paths such as cfg.Database.Password, optionally indexed or
+// followed by a comma, and identifiers ending a statement or struct field.
+var codeReference = regexp.MustCompile(`^[A-Za-z_$][\w$]*(?:(?:\.[A-Za-z_$][\w$]*)+(?:[\[,;].*)?|[,;])$`)
+
+// literalValue reports whether an unquoted assigned value looks like a
+// literal rather than a call, expression or variable reference.
+func literalValue(value string) bool {
+	return !strings.Contains(value, "(") && !codeReference.MatchString(value)
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// EnvRules returns rules matching the literal values of the EnvKeys set in
+// environ (as from os.Environ). Values too short to be credentials are
+// skipped.
+func EnvRules(environ []string) []Rule {
+	var rules []Rule
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || len(value) < 8 || !isEnvKey(key) {
+			continue
+		}
+		rules = append(rules, Rule{ID: "env:" + key, Re: regexp.MustCompile(regexp.QuoteMeta(value))})
+	}
+	return rules
+}
+
+func isEnvKey(key string) bool {
+	for _, k := range EnvKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Match is a secret found in a text, as a byte range.
+type Match struct {
+	Rule  string `json:"rule"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Line  int    `json:"line"`
+}
+
+// Find returns the non-overlapping secrets in text, in order of position.
+// Where matches overlap, the earliest rule wins.
+func Find(text string) []Match {
+	return FindRules(text, Rules)
+}
+
+// FindRules is like Find with the given rules.
+func FindRules(text string, rules []Rule) []Match {
+	var matches []Match
+	for _, r := range rules {
+		for _, loc := range r.Re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*r.Group], loc[2*r.Group+1]
+			if start < 0 || overlaps(matches, start, end) {
+				continue
+			}
+			if r.Check != nil && !r.Check(text[start:end]) {
+				continue
+			}
+			matches = append(matches, Match{Rule: r.ID, Start: start, End: end})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	line, pos := 1, 0
+	for i := range matches {
+		for ; pos < matches[i].Start; pos++ {
+			if text[pos] == '\n' {
+				line++
+			}
+		}
+		matches[i].Line = line
+	}
+	return matches
+}
+
+func overlaps(matches []Match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.End && m.Start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// Placeholder returns the text substituted for a secret matched by rule.
+func Placeholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
+
+// Redact replaces every secret in text with its placeholder and returns the
+// result and the number of secrets replaced.
+func Redact(text string) (string, int) {
+	matches := Find(text)
+	return RedactMatches(text, matches), len(matches)
+}
+
+// RedactMatches replaces the given matches, as returned by Find or
+// FindRules for text, with their placeholders.
+func RedactMatches(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return text
+	}
+	out := make([]byte, 0, len(text))
+	last := 0
+	for _, m := range matches {
+		out = append(out, text[last:m.Start]...)
+		out = append(out, Placeholder(m.Rule)...)
+		last = m.End
+	}
+	out = append(out, text[last:]...)
+	return string(out)
+}
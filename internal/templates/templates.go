@@ -0,0 +1,213 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/codebeauty/horde/internal/config"
+)
+
+// ProjectDir is where a project keeps its templates, relative to the
+// project root.
+const ProjectDir = ".horde/templates"
+
+// Sources of a template, from highest to lowest precedence.
+const (
+	SourceProject = "project"
+	SourceGlobal  = "global"
+	SourceBuiltin = "built-in"
+)
+
+var validID = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ValidateID checks that a template ID is safe for use as a filename.
+func ValidateID(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid template ID %q: must match [a-zA-Z0-9._-]+", id)
+	}
+	return nil
+}
+
+// Dir returns the global templates directory.
+func Dir() string {
+	return filepath.Join(config.GlobalConfigDir(), "templates")
+}
+
+// Template is a prompt template and where it was found.
+type Template struct {
+	ID     string
+	Source string
+	Path   string // empty for built-ins
+	Text   string
+}
+
+// dirs returns the template directories for a project, in precedence order.
+func dirs(projectDir string) []struct{ source, dir string } {
+	return []struct{ source, dir string }{
+		{SourceProject, filepath.Join(projectDir, ProjectDir)},
+		{SourceGlobal, Dir()},
+	}
+}
+
+// Load finds a template by ID: in the project's .horde/templates, then in
+// the global templates directory, then among the built-ins.
+func Load(id, projectDir string) (*Template, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	for _, d := range dirs(projectDir) {
+		path := filepath.Join(d.dir, id+".md")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading template %q: %w", id, err)
+		}
+		return &Template{ID: id, Source: d.source, Path: path, Text: string(data)}, nil
+	}
+	if text, ok := Builtins[id]; ok {
+		return &Template{ID: id, Source: SourceBuiltin, Text: text}, nil
+	}
+	return nil, fmt.Errorf("template %q not found (see 'horde templates list')", id)
+}
+
+// List returns every available template, sorted by ID. A template that
+// shadows another with the same ID is listed once, from its highest
+// precedence source.
+func List(projectDir string) ([]Template, error) {
+	seen := make(map[string]bool)
+	var list []Template
+	for _, d := range dirs(projectDir) {
+		entries, err := os.ReadDir(d.dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			id, ok := strings.CutSuffix(e.Name(), ".md")
+			if e.IsDir() || !ok || seen[id] || ValidateID(id) != nil {
+				continue
+			}
+			seen[id] = true
+			list = append(list, Template{ID: id, Source: d.source, Path: filepath.Join(d.dir, e.Name())})
+		}
+	}
+	for id, text := range Builtins {
+		if !seen[id] {
+			list = append(list, Template{ID: id, Source: SourceBuiltin, Text: text})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Data is what a template is filled with. Built-in fields are available as
+// .Prompt, .Context, .GitBranch and .Files, and variables by name, as in
+// {{.audience}}.
+type Data struct {
+	Prompt    string
+	Context   string
+	GitBranch string
+	Files     []string
+	Vars      map[string]string
+}
+
+// ParseVars parses key=value pairs given with --var.
+func ParseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		key, value, ok := strings.Cut(p, "=")
+		if !ok || !validVar.MatchString(key) {
+			return nil, fmt.Errorf("invalid --var %q: want key=value with a key like my_var", p)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+var validVar = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Render executes the template text with data. Referring to a variable that
+// was not given is an error.
+func Render(t *Template, data Data) (string, error) {
+	values := map[string]any{
+		"Prompt":    data.Prompt,
+		"Context":   data.Context,
+		"GitBranch": data.GitBranch,
+		"Files":     data.Files,
+	}
+	for k, v := range data.Vars {
+		if _, ok := values[k]; ok {
+			return "", fmt.Errorf("--var %s: %s is a built-in template field", k, k)
+		}
+		values[k] = v
+	}
+	tmpl, err := template.New(t.ID).Option("missingkey=error").Parse(t.Text)
+	if err != nil {
+		return "", fmt.Errorf("parsing template %q: %w", t.ID, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, values); err != nil {
+		return "", fmt.Errorf("rendering template %q: %w", t.ID, err)
+	}
+	return b.String(), nil
+}
+
+// Builtins contains the built-in templates. "default" is the framing used
+// for raids with context when no template is chosen.
+var Builtins = map[string]string{
+	"default": `# Second Opinion Request
+
+## Question
+
+{{.Prompt}}
+{{- if .Context}}
+
+## Context
+
+{{.Context}}
+{{- end}}
+
+## Instructions
+
+You are providing an independent second opinion. Be critical and thorough.
+- Analyze the question in the context provided
+- Identify risks, tradeoffs, and blind spots
+- Suggest alternatives if you see better approaches
+- Be direct and opinionated — don't hedge
+- Structure your response with clear headings
+- Keep your response focused and actionable
+`,
+
+	"review": `# Code Review{{if .GitBranch}}: {{.GitBranch}}{{end}}
+
+{{.Prompt}}
+{{- if .Files}}
+
+Files in scope:
+{{- range .Files}}
+- {{.}}
+{{- end}}
+{{- end}}
+{{- if .Context}}
+
+## Context
+
+{{.Context}}
+{{- end}}
+
+## Instructions
+
+Review the changes as a careful senior engineer would before approving them.
+- Point out bugs, regressions and unhandled edge cases, citing file and line
+- Flag security, concurrency and error-handling problems
+- Note missing or weak tests
+- Skip style nits unless they hide a real problem
+- Finish with a clear verdict: approve, approve with changes, or request changes
+`,
+}
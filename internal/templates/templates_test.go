@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupHome points the global config directory at a temp dir.
+func setupHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	dir := filepath.Join(home, "Library", "Application Support", "horde", "templates")
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	return dir
+}
+
+func writeTemplate(t *testing.T, dir, id, text string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, id+".md"), []byte(text), 0o600))
+}
+
+func TestRenderDefault(t *testing.T) {
+	tmpl := &Template{ID: "default", Text: Builtins["default"]}
+
+	got, err := Render(tmpl, Data{Prompt: "How should I refactor this?", Context: "some code here"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(got, "# Second Opinion Request\n\n## Question\n\nHow should I refactor this?\n\n"+
+		"## Context\n\nsome code here\n\n## Instructions\n\n"), got)
+	assert.Contains(t, got, "- Keep your response focused and actionable\n")
+
+	got, err = Render(tmpl, Data{Prompt: "What is the best approach? {{.Context}}"})
+	assert.NoError(t, err)
+	assert.Contains(t, got, "## Question\n\nWhat is the best approach? {{.Context}}\n\n## Instructions\n\n", "the prompt is data, not template text")
+	assert.NotContains(t, got, "## Context")
+}
+
+func TestRenderVars(t *testing.T) {
+	tmpl := &Template{ID: "t", Text: "{{.Prompt}} for {{.audience}} on {{.GitBranch}}:{{range .Files}} {{.}}{{end}}"}
+
+	got, err := Render(tmpl, Data{Prompt: "Explain", GitBranch: "main", Files: []string{"a.go", "b.go"}, Vars: map[string]string{"audience": "new hires"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Explain for new hires on main: a.go b.go", got)
+
+	_, err = Render(tmpl, Data{Prompt: "Explain"})
+	assert.ErrorContains(t, err, `rendering template "t"`)
+	assert.ErrorContains(t, err, `"audience"`)
+
+	_, err = Render(tmpl, Data{Vars: map[string]string{"audience": "x", "Prompt": "y"}})
+	assert.EqualError(t, err, "--var Prompt: Prompt is a built-in template field")
+
+	_, err = Render(&Template{ID: "bad", Text: "{{.Prompt"}, Data{})
+	assert.ErrorContains(t, err, `parsing template "bad"`)
+}
+
+func TestParseVars(t *testing.T) {
+	vars, err := ParseVars([]string{"audience=new hires", "tone=a=b", "empty="})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"audience": "new hires", "tone": "a=b", "empty": ""}, vars)
+
+	for _, bad := range []string{"novalue", "=x", "two words=x", "1st=x"} {
+		_, err := ParseVars([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLoadAndList(t *testing.T) {
+	global := setupHome(t)
+	project := t.TempDir()
+	writeTemplate(t, global, "review", "global review")
+	writeTemplate(t, global, "explain", "global explain")
+	writeTemplate(t, filepath.Join(project, ProjectDir), "explain", "project explain")
+
+	tmpl, err := Load("explain", project)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceProject, tmpl.Source)
+	assert.Equal(t, "project explain", tmpl.Text)
+
+	tmpl, err = Load("review", project)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceGlobal, tmpl.Source)
+	assert.Equal(t, filepath.Join(global, "review.md"), tmpl.Path)
+
+	tmpl, err = Load("default", project)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceBuiltin, tmpl.Source)
+	assert.Empty(t, tmpl.Path)
+
+	_, err = Load("missing", project)
+	assert.EqualError(t, err, `template "missing" not found (see 'horde templates list')`)
+	_, err = Load("../escape", project)
+	assert.Error(t, err)
+
+	list, err := List(project)
+	assert.NoError(t, err)
+	var got []string
+	for _, l := range list {
+		got = append(got, l.ID+" "+l.Source)
+	}
+	assert.Equal(t, []string{"default built-in", "explain project", "review global"}, got)
+}
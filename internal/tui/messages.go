@@ -26,8 +26,9 @@ type ToolCompletedMsg struct {
 }
 
 type AllCompletedMsg struct {
-	Results []runner.Result
-	RunDir  string
+	Results  []runner.Result
+	RunDir   string
+	Warnings []string
 }
 
 type ErrorMsg struct {
@@ -28,6 +28,7 @@ type Model struct {
 	width    int
 	height   int
 	Err      error
+	Warnings []string // problems after the agents finished, shown on exit
 	quitting bool
 
 	// Phase models
@@ -135,6 +136,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case AllCompletedMsg:
+		m.Warnings = msg.Warnings
 		m.summaryModel = NewSummaryModel(msg.Results, msg.RunDir, m.width, m.height)
 		m.phase = PhaseSummary
 		return m, nil
@@ -175,6 +175,23 @@ func TestQuitQ_InSummaryPhase(t *testing.T) {
 	assert.NotNil(t, cmd) // tea.Quit
 }
 
+func TestAllCompleted_KeepsWarnings(t *testing.T) {
+	cfg := RunConfig{
+		AllToolIDs: []string{"claude"},
+		Adapters:   map[string]string{"claude": "claude"},
+		Prompt:     "test",
+		SkipSelect: true,
+		SkipExpert: true,
+	}
+	m := NewModel(cfg, noopDispatch)
+
+	result, _ := m.Update(AllCompletedMsg{Results: testResults(), RunDir: "/tmp/run", Warnings: []string{"synthesis: no outputs"}})
+	m = result.(Model)
+	assert.Equal(t, PhaseSummary, m.phase)
+	assert.Equal(t, []string{"synthesis: no outputs"}, m.Warnings)
+	assert.Nil(t, m.Err)
+}
+
 func TestConfirmToProgress_SetsCancel(t *testing.T) {
 	dispatched := false
 	dispatch := func(_ context.Context, _ []string, _ string) {
@@ -319,3 +336,17 @@ func TestSummaryModel_WindowResize(t *testing.T) {
 	assert.Equal(t, 120, sm.width)
 	assert.Equal(t, 40, sm.height)
 }
+
+func TestViewerModelOpensRequestedTab(t *testing.T) {
+	results := []runner.Result{
+		{ToolID: "claude", Status: runner.StatusSuccess, Stdout: []byte("claude output")},
+		{ToolID: "gemini", Status: runner.StatusSuccess, Stdout: []byte("gemini output")},
+	}
+	m := NewViewerModel(results, "/tmp/run", 1)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(ViewerModel)
+	assert.Contains(t, m.View(), "gemini output")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	assert.NotNil(t, cmd)
+}
@@ -137,6 +137,16 @@ func wordWrap(s string, width int) string {
 	return out.String()
 }
 
+// SetActiveTab selects the result at index i, if it exists.
+func (m *SummaryModel) SetActiveTab(i int) {
+	if i < 0 || i >= len(m.Results) {
+		return
+	}
+	m.activeTab = i
+	m.viewport.SetContent(m.activeContent())
+	m.viewport.GotoTop()
+}
+
 func (m *SummaryModel) switchTab(delta int) {
 	if len(m.Results) == 0 {
 		return
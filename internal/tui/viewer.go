@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/codebeauty/horde/internal/runner"
+)
+
+// ViewerModel shows the results of a finished run outside of a raid, for
+// example when opening a search hit.
+type ViewerModel struct {
+	summary SummaryModel
+}
+
+// NewViewerModel opens the results of runDir with tab active.
+func NewViewerModel(results []runner.Result, runDir string, tab int) ViewerModel {
+	summary := NewSummaryModel(results, runDir, 80, 24)
+	summary.SetActiveTab(tab)
+	return ViewerModel{summary: summary}
+}
+
+func (m ViewerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if key.Matches(msg, Keys.Quit) || key.Matches(msg, Keys.QuitSummary) {
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.summary, cmd = m.summary.Update(msg)
+	return m, cmd
+}
+
+func (m ViewerModel) View() string {
+	return m.summary.View()
+}
+
+// ViewRun opens a full-screen viewer on the results of a past run.
+func ViewRun(results []runner.Result, runDir string, tab int) error {
+	_, err := tea.NewProgram(NewViewerModel(results, runDir, tab), tea.WithAltScreen()).Run()
+	return err
+}
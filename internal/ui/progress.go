@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,7 @@ type Progress struct {
 	states    map[string]*ToolStatus
 	mu        sync.Mutex
 	isTTY     bool
+	log       bool // plain timestamped log lines (CI)
 	startTime time.Time
 	done      chan struct{}
 	stopOnce  sync.Once
@@ -41,25 +43,46 @@ func NewProgress(toolIDs []string) *Progress {
 	}
 }
 
+// NewLogProgress returns a Progress that never animates and prefixes each
+// line with a timestamp, for CI logs.
+func NewLogProgress(toolIDs []string) *Progress {
+	p := NewProgress(toolIDs)
+	p.isTTY = false
+	p.log = true
+	return p
+}
+
+// printf writes a plain progress line, timestamped in log mode.
+func (p *Progress) printf(format string, args ...any) {
+	if p.log {
+		format = time.Now().Format("15:04:05") + " " + strings.TrimLeft(format, " ")
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
 func (p *Progress) Start() {
 	if !p.isTTY {
-		fmt.Fprintf(os.Stderr, "Running %d tool(s)...\n", len(p.toolIDs))
+		p.printf("Running %d tool(s)...\n", len(p.toolIDs))
 		return
 	}
 	fmt.Fprintf(os.Stderr, "\nThis may take more than 10 minutes.\n\n")
 	go p.animate()
 }
 
+// Stop ends the animation and clears the spinner lines. Safe to call more
+// than once.
 func (p *Progress) Stop() {
-	p.stopOnce.Do(func() { close(p.done) })
-	if p.isTTY {
-		// Clear spinner lines
-		p.mu.Lock()
-		for range p.toolIDs {
-			fmt.Fprintf(os.Stderr, "\033[A\033[2K")
+	p.stopOnce.Do(func() {
+		close(p.done)
+		if p.isTTY {
+			// Clear spinner lines
+			p.mu.Lock()
+			for range p.toolIDs {
+				fmt.Fprintf(os.Stderr, "\033[A\033[2K")
+			}
+			p.mu.Unlock()
 		}
-		p.mu.Unlock()
-	}
+	})
 }
 
 func (p *Progress) MarkRunning(toolID string) {
@@ -70,7 +93,7 @@ func (p *Progress) MarkRunning(toolID string) {
 		s.Started = time.Now()
 	}
 	if !p.isTTY {
-		fmt.Fprintf(os.Stderr, "  started: %s\n", toolID)
+		p.printf("  started: %s\n", toolID)
 	}
 }
 
@@ -82,7 +105,13 @@ func (p *Progress) MarkDone(toolID, status string, words int) {
 		s.Words = words
 	}
 	if !p.isTTY {
-		fmt.Fprintf(os.Stderr, "  %s: %s (%d words)\n", status, toolID, words)
+		elapsed := ""
+		if p.log {
+			if st, ok := p.states[toolID]; ok && !st.Started.IsZero() {
+				elapsed = ", " + time.Since(st.Started).Round(time.Second).String()
+			}
+		}
+		p.printf("  %s: %s (%d words%s)\n", status, toolID, words, elapsed)
 	}
 }
 